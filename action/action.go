@@ -58,6 +58,9 @@ type SingleAction struct {
 	Protocol   *Protocol       `json:"protocol,omitempty"`
 	Cdc        *AddCDCFile     `json:"cdc,omitempty"`
 	CommitInfo *CommitInfo     `json:"commitInfo,omitempty"`
+	// CheckpointMetadata and Sidecar are only allowed in V2 checkpoints
+	CheckpointMetadata *CheckpointMetadata `json:"checkpointMetadata,omitempty"`
+	Sidecar            *Sidecar            `json:"sidecar,omitempty"`
 }
 
 func (s *SingleAction) Unwrap() Action {
@@ -75,6 +78,10 @@ func (s *SingleAction) Unwrap() Action {
 		return s.Cdc
 	} else if s.CommitInfo != nil {
 		return s.CommitInfo
+	} else if s.CheckpointMetadata != nil {
+		return s.CheckpointMetadata
+	} else if s.Sidecar != nil {
+		return s.Sidecar
 	} else {
 		return nil
 	}
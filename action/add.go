@@ -8,13 +8,30 @@ import (
 )
 
 type AddFile struct {
-	Path             string            `json:"path"`
-	DataChange       bool              `json:"dataChange"`
-	PartitionValues  map[string]string `json:"partitionValues"`
-	Size             int64             `json:"size"`
-	ModificationTime int64             `json:"modificationTime"`
-	Stats            string            `json:"stats,omitempty"`
-	Tags             map[string]string `json:"tags,omitempty"`
+	Path             string                    `json:"path"`
+	DataChange       bool                      `json:"dataChange"`
+	PartitionValues  map[string]string         `json:"partitionValues"`
+	Size             int64                     `json:"size"`
+	ModificationTime int64                     `json:"modificationTime"`
+	Stats            string                    `json:"stats,omitempty"`
+	Tags             map[string]string         `json:"tags,omitempty"`
+	DeletionVector   *DeletionVectorDescriptor `json:"deletionVector,omitempty"`
+	// StatsParsed and PartitionValuesParsed are read from the stats_parsed and partitionValues_parsed columns of a checkpoint,
+	// they are nil if the checkpoint does not have them and never written into the json log.
+	StatsParsed           *StatsParsed   `json:"-"`
+	PartitionValuesParsed map[string]any `json:"-"`
+}
+
+// StatsParsed is the typed form of the column statistics in AddFile.Stats. The values are keyed by the physical column names,
+// the statistics of the nested columns are nested maps. The go types of the values follow the types of the columns:
+// int8, int16, int32, int64, float32, float64, string, bool, decimal.Decimal and time.Time for dates and timestamps,
+// the null counts are int64.
+type StatsParsed struct {
+	NumRecords  *int64
+	MinValues   map[string]any
+	MaxValues   map[string]any
+	NullCount   map[string]any
+	TightBounds *bool
 }
 
 func (a *AddFile) IsDataChanged() bool {
@@ -49,7 +66,16 @@ func (a *AddFile) RemoveWithTimestamp(ts *int64, dataChange *bool) *RemoveFile {
 		Path:              a.Path,
 		DeletionTimestamp: ts,
 		DataChange:        *dataChange,
+		DeletionVector:    a.DeletionVector,
+	}
+}
+
+// DeletionVectorUniqueId returns the unique id of the deletion vector, or empty if the file has none.
+func (a *AddFile) DeletionVectorUniqueId() string {
+	if a.DeletionVector == nil {
+		return ""
 	}
+	return a.DeletionVector.UniqueId()
 }
 
 func (a *AddFile) Copy(dataChange bool, path string) *AddFile {
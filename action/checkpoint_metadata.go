@@ -0,0 +1,15 @@
+package action
+
+// CheckpointMetadata describes a V2 checkpoint, it is only allowed in checkpoints.
+type CheckpointMetadata struct {
+	Version int64             `json:"version"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+func (c *CheckpointMetadata) Wrap() *SingleAction {
+	return &SingleAction{CheckpointMetadata: c}
+}
+
+func (c *CheckpointMetadata) Json() (string, error) {
+	return jsonString(c)
+}
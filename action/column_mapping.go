@@ -0,0 +1,142 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/types"
+)
+
+// ColumnMappingMode is the mode of column mapping, see the table property delta.columnMapping.mode.
+type ColumnMappingMode string
+
+const (
+	ColumnMappingModeNone ColumnMappingMode = "none"
+	ColumnMappingModeName ColumnMappingMode = "name"
+	ColumnMappingModeId   ColumnMappingMode = "id"
+)
+
+const (
+	ColumnMappingModeProp = "delta.columnMapping.mode"
+	// the keys of the StructField metadata
+	ColumnMappingPhysicalNameKey = "delta.columnMapping.physicalName"
+	ColumnMappingIdKey           = "delta.columnMapping.id"
+)
+
+// ColumnMappingMode returns the column mapping mode of the table.
+func (m *Metadata) ColumnMappingMode() (ColumnMappingMode, error) {
+	v, ok := m.Configuration[ColumnMappingModeProp]
+	if !ok {
+		return ColumnMappingModeNone, nil
+	}
+	switch mode := ColumnMappingMode(v); mode {
+	case ColumnMappingModeNone, ColumnMappingModeName, ColumnMappingModeId:
+		return mode, nil
+	default:
+		return "", errno.UnsupportedColumnMappingModeError(v)
+	}
+}
+
+// PhysicalNames returns the mapping from the logical names of the top-level columns to their physical names,
+// which are the keys of the partition values and the column statistics of the data files.
+// The physical name is the logical name if column mapping is disabled.
+func (m *Metadata) PhysicalNames() (map[string]string, error) {
+	mode, err := m.ColumnMappingMode()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := m.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(schema.Fields))
+	for _, f := range schema.Fields {
+		name, err := physicalName(f, mode)
+		if err != nil {
+			return nil, err
+		}
+		res[f.Name] = name
+	}
+	return res, nil
+}
+
+// PhysicalSchema returns the schema with the physical names of the (nested) columns, as used in the data files.
+// In id mode, the readers should resolve the columns by the field ids in the StructField metadata instead.
+func (m *Metadata) PhysicalSchema() (*types.StructType, error) {
+	mode, err := m.ColumnMappingMode()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := m.Schema()
+	if err != nil {
+		return nil, err
+	}
+	if mode == ColumnMappingModeNone {
+		return schema, nil
+	}
+
+	dt, err := toPhysicalType(schema, mode)
+	if err != nil {
+		return nil, err
+	}
+	return dt.(*types.StructType), nil
+}
+
+// ColumnMappingId returns the field id of the column in column mapping mode.
+func ColumnMappingId(f *types.StructField) (int64, bool) {
+	// numbers in the metadata are parsed as float64
+	id, ok := f.Metadata[ColumnMappingIdKey].(float64)
+	return int64(id), ok
+}
+
+func physicalName(f *types.StructField, mode ColumnMappingMode) (string, error) {
+	if mode == ColumnMappingModeNone {
+		return f.Name, nil
+	}
+	name, ok := f.Metadata[ColumnMappingPhysicalNameKey].(string)
+	if !ok || len(name) == 0 {
+		return "", errno.IllegalStateError(fmt.Sprintf("missing physical name of column %s in column mapping mode %s", f.Name, mode))
+	}
+	if _, ok := ColumnMappingId(f); !ok && mode == ColumnMappingModeId {
+		return "", errno.IllegalStateError(fmt.Sprintf("missing field id of column %s in column mapping mode %s", f.Name, mode))
+	}
+	return name, nil
+}
+
+func toPhysicalType(dt types.DataType, mode ColumnMappingMode) (types.DataType, error) {
+	switch v := dt.(type) {
+	case *types.StructType:
+		fields := make([]*types.StructField, len(v.Fields))
+		for i, f := range v.Fields {
+			name, err := physicalName(f, mode)
+			if err != nil {
+				return nil, err
+			}
+			fieldType, err := toPhysicalType(f.DataType, mode)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = &types.StructField{Name: name, DataType: fieldType, Nullable: f.Nullable, Metadata: f.Metadata}
+		}
+		return types.NewStructType(fields), nil
+	case *types.ArrayType:
+		elementType, err := toPhysicalType(v.ElementType, mode)
+		if err != nil {
+			return nil, err
+		}
+		return &types.ArrayType{ElementType: elementType, ContainsNull: v.ContainsNull}, nil
+	case *types.MapType:
+		keyType, err := toPhysicalType(v.KeyType, mode)
+		if err != nil {
+			return nil, err
+		}
+		valueType, err := toPhysicalType(v.ValueType, mode)
+		if err != nil {
+			return nil, err
+		}
+		return &types.MapType{KeyType: keyType, ValueType: valueType, ValueContainsNull: v.ValueContainsNull}, nil
+	default:
+		return dt, nil
+	}
+}
@@ -0,0 +1,73 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const columnMappingTestSchema = `{"type":"struct","fields":[
+{"name":"a","type":"integer","nullable":true,"metadata":{"delta.columnMapping.id":1,"delta.columnMapping.physicalName":"col-a"}},
+{"name":"b","type":{"type":"struct","fields":[
+	{"name":"c","type":"string","nullable":true,"metadata":{"delta.columnMapping.id":3,"delta.columnMapping.physicalName":"col-c"}}
+]},"nullable":true,"metadata":{"delta.columnMapping.id":2,"delta.columnMapping.physicalName":"col-b"}}
+]}`
+
+func TestMetadata_column_mapping_mode(t *testing.T) {
+	m := &Metadata{Configuration: map[string]string{}}
+	mode, err := m.ColumnMappingMode()
+	assert.NoError(t, err)
+	assert.Equal(t, ColumnMappingModeNone, mode)
+
+	m.Configuration[ColumnMappingModeProp] = "id"
+	mode, err = m.ColumnMappingMode()
+	assert.NoError(t, err)
+	assert.Equal(t, ColumnMappingModeId, mode)
+
+	m.Configuration[ColumnMappingModeProp] = "unknown"
+	_, err = m.ColumnMappingMode()
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+}
+
+func TestMetadata_physical_names(t *testing.T) {
+	m := &Metadata{SchemaString: columnMappingTestSchema, Configuration: map[string]string{}}
+
+	names, err := m.PhysicalNames()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "a", "b": "b"}, names)
+
+	m.Configuration[ColumnMappingModeProp] = "name"
+	names, err = m.PhysicalNames()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "col-a", "b": "col-b"}, names)
+
+	schema, err := m.PhysicalSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"col-a", "col-b"}, schema.FieldNames())
+	nested := schema.Fields[1].DataType.(*types.StructType)
+	assert.Equal(t, []string{"col-c"}, nested.FieldNames())
+	id, ok := ColumnMappingId(nested.Fields[0])
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), id)
+}
+
+func TestMetadata_physical_names_missing(t *testing.T) {
+	m := &Metadata{
+		SchemaString:  `{"type":"struct","fields":[{"name":"a","type":"integer","nullable":true,"metadata":{"delta.columnMapping.physicalName":"col-a"}}]}`,
+		Configuration: map[string]string{ColumnMappingModeProp: "name"},
+	}
+	_, err := m.PhysicalNames()
+	assert.NoError(t, err)
+
+	// the field id is required in id mode
+	m.Configuration[ColumnMappingModeProp] = "id"
+	_, err = m.PhysicalNames()
+	assert.ErrorIs(t, err, errno.ErrIllegalState)
+
+	m.SchemaString = `{"type":"struct","fields":[{"name":"a","type":"integer","nullable":true,"metadata":{}}]}`
+	m.Configuration[ColumnMappingModeProp] = "name"
+	_, err = m.PhysicalSchema()
+	assert.ErrorIs(t, err, errno.ErrIllegalState)
+}
@@ -0,0 +1,93 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/z85"
+	"github.com/rotisserie/eris"
+)
+
+// The storage types of a deletion vector.
+const (
+	// DeletionVectorStorageUUIDRelative means the deletion vector is stored in a file in the table directory,
+	// pathOrInlineDv is <random prefix><z85 encoded uuid>.
+	DeletionVectorStorageUUIDRelative = "u"
+	// DeletionVectorStorageInline means the deletion vector is stored z85 encoded in pathOrInlineDv.
+	DeletionVectorStorageInline = "i"
+	// DeletionVectorStorageAbsolutePath means the deletion vector is stored in the file at the absolute path pathOrInlineDv.
+	DeletionVectorStorageAbsolutePath = "p"
+)
+
+// the length of a z85 encoded uuid
+const encodedUUIDLength = 20
+
+// DeletionVectorDescriptor describes the rows of a data file which are logically deleted.
+type DeletionVectorDescriptor struct {
+	StorageType    string `json:"storageType"`
+	PathOrInlineDv string `json:"pathOrInlineDv"`
+	// Offset is the start of the deletion vector in the file, absent for inline deletion vectors.
+	Offset *int32 `json:"offset,omitempty"`
+	// SizeInBytes is the size of the serialized deletion vector.
+	SizeInBytes int32 `json:"sizeInBytes"`
+	// Cardinality is the number of deleted rows.
+	Cardinality int64  `json:"cardinality"`
+	MaxRowIndex *int64 `json:"maxRowIndex,omitempty"`
+}
+
+// UniqueId identifies the deletion vector, a data file with a deletion vector is identified by (path, UniqueId).
+func (d *DeletionVectorDescriptor) UniqueId() string {
+	if d.Offset != nil {
+		return fmt.Sprintf("%s%s@%d", d.StorageType, d.PathOrInlineDv, *d.Offset)
+	}
+	return d.StorageType + d.PathOrInlineDv
+}
+
+func (d *DeletionVectorDescriptor) IsInline() bool {
+	return d.StorageType == DeletionVectorStorageInline
+}
+
+func (d *DeletionVectorDescriptor) IsOnDisk() bool {
+	return !d.IsInline()
+}
+
+// InlineData returns the serialized deletion vector stored in the descriptor.
+func (d *DeletionVectorDescriptor) InlineData() ([]byte, error) {
+	if !d.IsInline() {
+		return nil, eris.Wrap(errno.ErrIllegalState, "deletion vector is not inline")
+	}
+	b, err := z85.Decode(d.PathOrInlineDv)
+	if err != nil {
+		return nil, eris.Wrap(errno.ErrIllegalArgument, err.Error())
+	}
+	if int(d.SizeInBytes) > len(b) {
+		return nil, eris.Wrap(errno.ErrIllegalArgument, "inline deletion vector is shorter than sizeInBytes")
+	}
+	return b[:d.SizeInBytes], nil
+}
+
+// AbsolutePath returns the path of the file storing the deletion vector, tablePath is the root of the table.
+func (d *DeletionVectorDescriptor) AbsolutePath(tablePath string) (string, error) {
+	switch d.StorageType {
+	case DeletionVectorStorageAbsolutePath:
+		return d.PathOrInlineDv, nil
+	case DeletionVectorStorageUUIDRelative:
+		if len(d.PathOrInlineDv) < encodedUUIDLength {
+			return "", eris.Wrap(errno.ErrIllegalArgument, "invalid deletion vector path "+d.PathOrInlineDv)
+		}
+		prefixLen := len(d.PathOrInlineDv) - encodedUUIDLength
+		b, err := z85.Decode(d.PathOrInlineDv[prefixLen:])
+		if err != nil {
+			return "", eris.Wrap(errno.ErrIllegalArgument, err.Error())
+		}
+		uuid := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+
+		p := strings.TrimSuffix(tablePath, "/") + "/"
+		if prefixLen > 0 {
+			p += d.PathOrInlineDv[:prefixLen] + "/"
+		}
+		return p + "deletion_vector_" + uuid + ".bin", nil
+	}
+	return "", eris.Wrap(errno.ErrIllegalState, "deletion vector has no file, storage type "+d.StorageType)
+}
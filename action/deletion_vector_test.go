@@ -0,0 +1,44 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/csimplestring/delta-go/internal/util/z85"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletionVectorDescriptor_AbsolutePath(t *testing.T) {
+	dv := &DeletionVectorDescriptor{StorageType: "u", PathOrInlineDv: "ab^-aqEH.-t@S}K{vb[*k^"}
+	p, err := dv.AbsolutePath("s3://mytable/")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://mytable/ab/deletion_vector_d2c639aa-8816-431a-aaf6-d3fe2512ff61.bin", p)
+
+	dv = &DeletionVectorDescriptor{StorageType: "p", PathOrInlineDv: "s3://bucket/dv.bin"}
+	p, err = dv.AbsolutePath("s3://mytable")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://bucket/dv.bin", p)
+
+	dv = &DeletionVectorDescriptor{StorageType: "i", PathOrInlineDv: z85.Encode([]byte{1, 2, 3}), SizeInBytes: 3}
+	_, err = dv.AbsolutePath("s3://mytable")
+	assert.Error(t, err)
+	b, err := dv.InlineData()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, b)
+}
+
+func TestDeletionVectorDescriptor_json(t *testing.T) {
+	s := `{"add":{"path":"a","dataChange":true,"partitionValues":{},"size":1,"modificationTime":1,` +
+		`"deletionVector":{"storageType":"u","pathOrInlineDv":"ab^-aqEH.-t@S}K{vb[*k^","offset":4,"sizeInBytes":40,"cardinality":6}}}`
+
+	a, err := FromJson(s)
+	assert.NoError(t, err)
+	add := a.(*AddFile)
+	assert.Equal(t, "uab^-aqEH.-t@S}K{vb[*k^@4", add.DeletionVectorUniqueId())
+	assert.Equal(t, int64(6), add.DeletionVector.Cardinality)
+
+	j, err := add.Json()
+	assert.NoError(t, err)
+	assert.JSONEq(t, s, j)
+
+	assert.Equal(t, "", (&AddFile{Path: "b"}).DeletionVectorUniqueId())
+}
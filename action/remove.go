@@ -7,13 +7,14 @@ import (
 )
 
 type RemoveFile struct {
-	Path                 string            `json:"path"`
-	DataChange           bool              `json:"dataChange"`
-	DeletionTimestamp    *int64            `json:"deletionTimestamp,omitempty"`
-	ExtendedFileMetadata bool              `json:"extendedFileMetadata,omitempty"`
-	PartitionValues      map[string]string `json:"partitionValues,omitempty"`
-	Size                 *int64            `json:"size,omitempty"`
-	Tags                 map[string]string `json:"tags,omitempty"`
+	Path                 string                    `json:"path"`
+	DataChange           bool                      `json:"dataChange"`
+	DeletionTimestamp    *int64                    `json:"deletionTimestamp,omitempty"`
+	ExtendedFileMetadata bool                      `json:"extendedFileMetadata,omitempty"`
+	PartitionValues      map[string]string         `json:"partitionValues,omitempty"`
+	Size                 *int64                    `json:"size,omitempty"`
+	Tags                 map[string]string         `json:"tags,omitempty"`
+	DeletionVector       *DeletionVectorDescriptor `json:"deletionVector,omitempty"`
 }
 
 func (r *RemoveFile) IsDataChanged() bool {
@@ -39,6 +40,14 @@ func (r *RemoveFile) DelTimestamp() int64 {
 	return *r.DeletionTimestamp
 }
 
+// DeletionVectorUniqueId returns the unique id of the deletion vector, or empty if the file has none.
+func (r *RemoveFile) DeletionVectorUniqueId() string {
+	if r.DeletionVector == nil {
+		return ""
+	}
+	return r.DeletionVector.UniqueId()
+}
+
 func (r *RemoveFile) Copy(dataChange bool, path string) *RemoveFile {
 	dst := &RemoveFile{}
 	deepcopier.Copy(r).To(dst)
@@ -0,0 +1,18 @@
+package action
+
+// Sidecar references a sidecar file of a V2 checkpoint, which holds the file actions of the checkpoint.
+// The Path is relative to the _delta_log/_sidecars directory.
+type Sidecar struct {
+	Path             string            `json:"path"`
+	SizeInBytes      int64             `json:"sizeInBytes"`
+	ModificationTime int64             `json:"modificationTime"`
+	Tags             map[string]string `json:"tags,omitempty"`
+}
+
+func (s *Sidecar) Wrap() *SingleAction {
+	return &SingleAction{Sidecar: s}
+}
+
+func (s *Sidecar) Json() (string, error) {
+	return jsonString(s)
+}
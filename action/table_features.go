@@ -0,0 +1,154 @@
+package action
+
+import (
+	"slices"
+	"sort"
+)
+
+// The protocol versions which list the table features explicitly in ReaderFeatures and WriterFeatures.
+const (
+	TableFeaturesReaderVersion int32 = 3
+	TableFeaturesWriterVersion int32 = 7
+)
+
+// The names of the table features.
+const (
+	FeatureAppendOnly       = "appendOnly"
+	FeatureInvariants       = "invariants"
+	FeatureCheckConstraints = "checkConstraints"
+	FeatureChangeDataFeed   = "changeDataFeed"
+	FeatureGeneratedColumns = "generatedColumns"
+	FeatureColumnMapping    = "columnMapping"
+	FeatureIdentityColumns  = "identityColumns"
+	FeatureDeletionVectors  = "deletionVectors"
+	FeatureTimestampNtz     = "timestampNtz"
+	FeatureDomainMetadata   = "domainMetadata"
+	FeatureV2Checkpoint     = "v2Checkpoint"
+	FeatureIcebergCompatV1  = "icebergCompatV1"
+	FeatureRowTracking      = "rowTracking"
+	FeatureVacuumProtocol   = "vacuumProtocolCheck"
+)
+
+// TableFeature describes a table feature of the Delta protocol.
+type TableFeature struct {
+	Name string
+	// ReaderWriter is true if the feature must be understood by readers as well, i.e. it is listed in both ReaderFeatures and WriterFeatures.
+	ReaderWriter bool
+	// MinReaderVersion and MinWriterVersion are the legacy protocol versions which enable the feature implicitly,
+	// 0 if the feature can only be enabled explicitly by the table features protocol.
+	MinReaderVersion int32
+	MinWriterVersion int32
+	// ReadSupported and WriteSupported indicate if delta-go can read and write tables with the feature enabled.
+	ReadSupported  bool
+	WriteSupported bool
+}
+
+// tableFeatures is the registry of the known table features.
+var tableFeatures = map[string]*TableFeature{}
+
+func registerTableFeature(f *TableFeature) {
+	tableFeatures[f.Name] = f
+}
+
+func init() {
+	registerTableFeature(&TableFeature{Name: FeatureAppendOnly, MinReaderVersion: 1, MinWriterVersion: 2, ReadSupported: true, WriteSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureInvariants, MinReaderVersion: 1, MinWriterVersion: 2, ReadSupported: true, WriteSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureCheckConstraints, MinReaderVersion: 1, MinWriterVersion: 3, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureChangeDataFeed, MinReaderVersion: 1, MinWriterVersion: 4, ReadSupported: true, WriteSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureGeneratedColumns, MinReaderVersion: 1, MinWriterVersion: 4, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureColumnMapping, ReaderWriter: true, MinReaderVersion: 2, MinWriterVersion: 5, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureIdentityColumns, MinReaderVersion: 1, MinWriterVersion: 6, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureDeletionVectors, ReaderWriter: true, ReadSupported: true, WriteSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureTimestampNtz, ReaderWriter: true})
+	registerTableFeature(&TableFeature{Name: FeatureDomainMetadata, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureV2Checkpoint, ReaderWriter: true, ReadSupported: true, WriteSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureIcebergCompatV1, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureRowTracking, ReadSupported: true})
+	registerTableFeature(&TableFeature{Name: FeatureVacuumProtocol, ReaderWriter: true})
+}
+
+// GetTableFeature returns the registered table feature by name.
+func GetTableFeature(name string) (*TableFeature, bool) {
+	f, ok := tableFeatures[name]
+	return f, ok
+}
+
+// IsLegacy checks if the feature can be enabled by the legacy protocol versions.
+func (f *TableFeature) IsLegacy() bool {
+	return f.MinWriterVersion > 0
+}
+
+// ReaderFeatureNames returns the reader features supported by the protocol,
+// either listed explicitly (reader version 3) or implied by the legacy reader version.
+func (p *Protocol) ReaderFeatureNames() []string {
+	if p.MinReaderVersion >= TableFeaturesReaderVersion {
+		return p.ReaderFeatures
+	}
+	return p.implicitFeatures(func(f *TableFeature) bool {
+		return f.ReaderWriter && f.MinReaderVersion <= p.MinReaderVersion
+	})
+}
+
+// WriterFeatureNames returns the writer features supported by the protocol,
+// either listed explicitly (writer version 7) or implied by the legacy writer version.
+func (p *Protocol) WriterFeatureNames() []string {
+	if p.MinWriterVersion >= TableFeaturesWriterVersion {
+		return p.WriterFeatures
+	}
+	return p.implicitFeatures(func(f *TableFeature) bool {
+		return f.MinWriterVersion <= p.MinWriterVersion
+	})
+}
+
+func (p *Protocol) implicitFeatures(enabled func(f *TableFeature) bool) []string {
+	var res []string
+	for _, f := range tableFeatures {
+		if f.IsLegacy() && enabled(f) {
+			res = append(res, f.Name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// IsFeatureSupported checks if the feature is supported by the protocol.
+func (p *Protocol) IsFeatureSupported(name string) bool {
+	return slices.Contains(p.WriterFeatureNames(), name)
+}
+
+// WithFeatures returns a new protocol which supports the features in addition to those of p.
+// A legacy protocol is upgraded to writer version 7, and to reader version 3 if any reader-writer feature is added,
+// listing the features implied by the legacy versions explicitly.
+// It returns p itself if all the features are already supported.
+func (p *Protocol) WithFeatures(features ...*TableFeature) *Protocol {
+	var missing []*TableFeature
+	for _, f := range features {
+		if !p.IsFeatureSupported(f.Name) {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) == 0 {
+		return p
+	}
+
+	res := &Protocol{
+		MinReaderVersion: p.MinReaderVersion,
+		MinWriterVersion: TableFeaturesWriterVersion,
+		WriterFeatures:   slices.Clone(p.WriterFeatureNames()),
+	}
+	if p.MinReaderVersion >= TableFeaturesReaderVersion {
+		res.ReaderFeatures = slices.Clone(p.ReaderFeatures)
+	}
+	for _, f := range missing {
+		res.WriterFeatures = append(res.WriterFeatures, f.Name)
+		if !f.ReaderWriter {
+			continue
+		}
+		if res.MinReaderVersion < TableFeaturesReaderVersion {
+			res.MinReaderVersion = TableFeaturesReaderVersion
+			res.ReaderFeatures = slices.Clone(p.ReaderFeatureNames())
+		}
+		res.ReaderFeatures = append(res.ReaderFeatures, f.Name)
+	}
+	return res
+}
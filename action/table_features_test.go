@@ -0,0 +1,61 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtocol_feature_names(t *testing.T) {
+	p := &Protocol{MinReaderVersion: 1, MinWriterVersion: 2}
+	assert.Empty(t, p.ReaderFeatureNames())
+	assert.Equal(t, []string{FeatureAppendOnly, FeatureInvariants}, p.WriterFeatureNames())
+
+	p = &Protocol{MinReaderVersion: 2, MinWriterVersion: 5}
+	assert.Equal(t, []string{FeatureColumnMapping}, p.ReaderFeatureNames())
+	assert.Equal(t, []string{
+		FeatureAppendOnly, FeatureChangeDataFeed, FeatureCheckConstraints,
+		FeatureColumnMapping, FeatureGeneratedColumns, FeatureInvariants,
+	}, p.WriterFeatureNames())
+
+	p = &Protocol{MinReaderVersion: 3, MinWriterVersion: 7,
+		ReaderFeatures: []string{FeatureDeletionVectors},
+		WriterFeatures: []string{FeatureAppendOnly, FeatureDeletionVectors}}
+	assert.Equal(t, []string{FeatureDeletionVectors}, p.ReaderFeatureNames())
+	assert.True(t, p.IsFeatureSupported(FeatureAppendOnly))
+	assert.False(t, p.IsFeatureSupported(FeatureInvariants))
+}
+
+func TestProtocol_with_features(t *testing.T) {
+	cdf, _ := GetTableFeature(FeatureChangeDataFeed)
+	dv, _ := GetTableFeature(FeatureDeletionVectors)
+	appendOnly, _ := GetTableFeature(FeatureAppendOnly)
+
+	legacy := DefaultProtocol()
+	assert.Same(t, legacy, legacy.WithFeatures(appendOnly))
+
+	assert.Equal(t, &Protocol{
+		MinReaderVersion: 1,
+		MinWriterVersion: 7,
+		WriterFeatures:   []string{FeatureAppendOnly, FeatureInvariants, FeatureChangeDataFeed},
+	}, legacy.WithFeatures(cdf))
+
+	assert.Equal(t, &Protocol{
+		MinReaderVersion: 3,
+		MinWriterVersion: 7,
+		ReaderFeatures:   []string{FeatureDeletionVectors},
+		WriterFeatures:   []string{FeatureAppendOnly, FeatureInvariants, FeatureChangeDataFeed, FeatureDeletionVectors},
+	}, legacy.WithFeatures(cdf, dv))
+
+	p := &Protocol{MinReaderVersion: 3, MinWriterVersion: 7,
+		ReaderFeatures: []string{FeatureDeletionVectors},
+		WriterFeatures: []string{FeatureDeletionVectors}}
+	assert.Equal(t, &Protocol{
+		MinReaderVersion: 3,
+		MinWriterVersion: 7,
+		ReaderFeatures:   []string{FeatureDeletionVectors},
+		WriterFeatures:   []string{FeatureDeletionVectors, FeatureChangeDataFeed},
+	}, p.WithFeatures(cdf, dv))
+	// the original protocol is not modified
+	assert.Equal(t, []string{FeatureDeletionVectors}, p.WriterFeatures)
+}
@@ -0,0 +1,147 @@
+package deltago
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// ChangeType is the type of the changes in a file of the change data feed.
+type ChangeType string
+
+const (
+	ChangeTypeInsert ChangeType = "insert"
+	ChangeTypeDelete ChangeType = "delete"
+	// ChangeTypeCDC marks a change data file written by the engine, the change type of each row is in its _change_type column,
+	// i.e. insert, delete, update_preimage or update_postimage.
+	ChangeTypeCDC ChangeType = "cdc"
+)
+
+// ChangeFile is a file of the change data feed of a table.
+type ChangeFile struct {
+	Version int64
+	// Timestamp is the commit timestamp of the version in milliseconds.
+	Timestamp  int64
+	ChangeType ChangeType
+	// File is an *action.AddCDCFile, or an *action.AddFile (insert) or *action.RemoveFile (delete) if the version has no change data files.
+	File action.FileAction
+}
+
+// TableChanges returns the change data feed between startVersion and endVersion (both inclusive) in increasing order of version.
+// For each version, the change data files are returned if the commit has any, otherwise the added and removed data files
+// are returned as inserts and deletes. Files which do not change data, e.g. by compaction, are skipped.
+// The change data feed must be enabled (delta.enableChangeDataFeed) for the whole range.
+func (l *logImpl) TableChanges(startVersion int64, endVersion int64) (iter.Iter[*ChangeFile], error) {
+	return l.TableChangesContext(context.Background(), startVersion, endVersion)
+}
+
+// TableChangesContext is TableChanges with a context to cancel the reading of the log.
+func (l *logImpl) TableChangesContext(ctx context.Context, startVersion int64, endVersion int64) (iter.Iter[*ChangeFile], error) {
+	res, err := l.tableChanges(ctx, startVersion, endVersion)
+	return res, errno.ContextError(ctx, err)
+}
+
+func (l *logImpl) tableChanges(ctx context.Context, startVersion int64, endVersion int64) (iter.Iter[*ChangeFile], error) {
+	s, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if startVersion < 0 || startVersion > endVersion || endVersion > s.Version() {
+		return nil, errno.InvalidVersionRangeError(startVersion, endVersion, s.Version())
+	}
+
+	start, err := l.snapshotReader.getSnapshotForVersionAsOf(ctx, startVersion)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := start.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	if !DeltaConfigEnableChangeDataFeed.fromMetadata(metadata) {
+		return nil, errno.ChangeDataFeedNotEnabledError(startVersion)
+	}
+
+	commits, err := l.history.getCommits(ctx, l.store, l.store.Root(), startVersion, endVersion+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*ChangeFile
+	for i, c := range commits {
+		if c.version != startVersion+int64(i) {
+			return nil, errno.IllegalStateError(fmt.Sprintf("version %d of the change data feed is missing", startVersion+int64(i)))
+		}
+		changes, err := l.versionChanges(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, changes...)
+	}
+	if int64(len(commits)) != endVersion-startVersion+1 {
+		return nil, errno.IllegalStateError(fmt.Sprintf("version %d of the change data feed is missing", startVersion+int64(len(commits))))
+	}
+
+	return iter.FromSlice(res), nil
+}
+
+// TableChangesForTimestamps returns the change data feed of the versions committed between startTimestamp and endTimestamp (both inclusive),
+// see TableChanges.
+func (l *logImpl) TableChangesForTimestamps(startTimestamp int64, endTimestamp int64) (iter.Iter[*ChangeFile], error) {
+	return l.TableChangesForTimestampsContext(context.Background(), startTimestamp, endTimestamp)
+}
+
+// TableChangesForTimestampsContext is TableChangesForTimestamps with a context to cancel the reading of the log.
+func (l *logImpl) TableChangesForTimestampsContext(ctx context.Context, startTimestamp int64, endTimestamp int64) (iter.Iter[*ChangeFile], error) {
+	startVersion, err := l.VersionAtOrAfterTimestampContext(ctx, startTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	endVersion, err := l.VersionBeforeOrAtTimestampContext(ctx, endTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	return l.TableChangesContext(ctx, startVersion, endVersion)
+}
+
+func (l *logImpl) versionChanges(ctx context.Context, c *commit) ([]*ChangeFile, error) {
+	lines, err := l.store.Read(ctx, filenames.DeltaFile(l.store.Root(), c.version))
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	actions, err := iter.Map(lines, action.FromJson)
+	if err != nil {
+		return nil, err
+	}
+
+	var cdcFiles, dataFiles []*ChangeFile
+	for _, a := range actions {
+		switch v := a.(type) {
+		case *action.Metadata:
+			if !DeltaConfigEnableChangeDataFeed.fromMetadata(v) {
+				return nil, errno.ChangeDataFeedNotEnabledError(c.version)
+			}
+		case *action.AddCDCFile:
+			cdcFiles = append(cdcFiles, &ChangeFile{Version: c.version, Timestamp: c.timestamp, ChangeType: ChangeTypeCDC, File: v})
+		case *action.AddFile:
+			if v.DataChange {
+				dataFiles = append(dataFiles, &ChangeFile{Version: c.version, Timestamp: c.timestamp, ChangeType: ChangeTypeInsert, File: v})
+			}
+		case *action.RemoveFile:
+			if v.DataChange {
+				dataFiles = append(dataFiles, &ChangeFile{Version: c.version, Timestamp: c.timestamp, ChangeType: ChangeTypeDelete, File: v})
+			}
+		}
+	}
+
+	if len(cdcFiles) != 0 {
+		return cdcFiles, nil
+	}
+	return dataFiles, nil
+}
@@ -0,0 +1,101 @@
+package deltago
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestLog_table_changes(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	metadata := getTestMetedata()
+	metadata.Configuration = map[string]string{DeltaConfigEnableChangeDataFeed.Key: "true"}
+	cdc := &action.AddCDCFile{Path: "_change_data/cdc-1", PartitionValues: map[string]string{}, Size: 1}
+	b2 := testAddFile("b2")
+	b2.DataChange = false
+
+	commitTestActions(t, log, metadata, testAddFile("a"), testAddFile("b")) // 0
+	commitTestActions(t, log, testRemoveFile("a"), testAddFile("a2"), cdc)  // 1
+	commitTestActions(t, log, testRemoveFile("b"), b2)                      // 2, b2 does not change data
+	commitTestActions(t, log, testRemoveFile("a2"))                         // 3
+
+	// commit i was at base + i seconds
+	u, err := url.Parse(tt.urlstr)
+	assert.NoError(t, err)
+	logDir := filepath.Join(u.Path, tt.tempDir, "_delta_log")
+	base := time.UnixMilli(1_600_000_000_000)
+	for i := int64(0); i < 4; i++ {
+		ts := base.Add(time.Duration(i) * time.Second)
+		assert.NoError(t, os.Chtimes(filepath.Join(logDir, filenames.DeltaFile("", i)), ts, ts))
+	}
+
+	type change struct {
+		version    int64
+		timestamp  int64
+		changeType ChangeType
+		path       string
+	}
+	collect := func(it iter.Iter[*ChangeFile]) []change {
+		files, err := iter.ToSlice(it)
+		assert.NoError(t, err)
+		var res []change
+		for _, f := range files {
+			u, err := f.File.PathAsUri()
+			assert.NoError(t, err)
+			res = append(res, change{f.Version, f.Timestamp, f.ChangeType, filepath.Base(u.Path)})
+		}
+		return res
+	}
+	ts := func(version int64) int64 {
+		return base.UnixMilli() + version*1000
+	}
+
+	it, err := log.TableChanges(0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []change{
+		{0, ts(0), ChangeTypeInsert, "a"},
+		{0, ts(0), ChangeTypeInsert, "b"},
+		{1, ts(1), ChangeTypeCDC, "cdc-1"},
+		{2, ts(2), ChangeTypeDelete, "b"},
+		{3, ts(3), ChangeTypeDelete, "a2"},
+	}, collect(it))
+
+	it, err = log.TableChangesForTimestamps(ts(1)-500, ts(2))
+	assert.NoError(t, err)
+	assert.Equal(t, []change{
+		{1, ts(1), ChangeTypeCDC, "cdc-1"},
+		{2, ts(2), ChangeTypeDelete, "b"},
+	}, collect(it))
+
+	_, err = log.TableChanges(2, 1)
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+	_, err = log.TableChanges(0, 4)
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+
+	// disable the change data feed
+	disabled := getTestMetedata()
+	disabled.Configuration = map[string]string{DeltaConfigEnableChangeDataFeed.Key: "false"}
+	commitTestActions(t, log, disabled)
+	commitTestActions(t, log, testAddFile("c"))
+
+	_, err = log.TableChanges(0, 5)
+	assert.ErrorIs(t, err, errno.ErrIllegalState)
+	_, err = log.TableChanges(5, 5)
+	assert.ErrorIs(t, err, errno.ErrIllegalState)
+	_, err = log.TableChanges(1, 3)
+	assert.NoError(t, err)
+}
@@ -1,10 +1,13 @@
 package deltago
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/csimplestring/delta-go/errno"
@@ -30,10 +33,22 @@ var MaxInstance = CheckpointInstance{Version: -1, NumParts: mo.None[int]()}
 type CheckpointInstance struct {
 	Version  int64
 	NumParts mo.Option[int]
+	// V2Name is the file name of the top level file if it is a V2 checkpoint
+	V2Name mo.Option[string]
 }
 
+// Compare orders the checkpoints by version, a V2 checkpoint is preferred to a classic one of the same version.
 func (t *CheckpointInstance) Compare(other CheckpointInstance) int {
 	if t.Version == other.Version {
+		if t.V2Name.IsPresent() || other.V2Name.IsPresent() {
+			if t.V2Name.IsAbsent() {
+				return -1
+			}
+			if other.V2Name.IsAbsent() {
+				return 1
+			}
+			return strings.Compare(t.V2Name.MustGet(), other.V2Name.MustGet())
+		}
 		return t.NumParts.OrElse(1) - other.NumParts.OrElse(1)
 	}
 	if t.Version-other.Version < 0 {
@@ -63,6 +78,9 @@ func (t *CheckpointInstance) IsNotLaterThan(other CheckpointInstance) bool {
 }
 
 func (t *CheckpointInstance) GetCorrespondingFiles(dir string) (res []string) {
+	if name, ok := t.V2Name.Get(); ok {
+		return []string{dir + name}
+	}
 	if t.NumParts.IsAbsent() {
 		return []string{filenames.CheckpointFileSingular(dir, t.Version)}
 	} else {
@@ -74,6 +92,9 @@ func FromPath(path string) *CheckpointInstance {
 	version := filenames.CheckpointVersion(path)
 	numParts := filenames.NumCheckpointParts(path)
 
+	if filenames.IsV2CheckpointFile(path) {
+		return &CheckpointInstance{Version: version, NumParts: numParts, V2Name: mo.Some(filepath.Base(path))}
+	}
 	return &CheckpointInstance{Version: version, NumParts: numParts}
 }
 
@@ -88,21 +109,27 @@ func FromMetadata(metadata CheckpointMetaDataJSON) *CheckpointInstance {
 	return i
 }
 
-func LastCheckpoint(s store.Store) (mo.Option[*CheckpointMetaDataJSON], error) {
-	return LoadMetadataFromFile(s)
+func LastCheckpoint(ctx context.Context, s store.Store) (mo.Option[*CheckpointMetaDataJSON], error) {
+	return LoadMetadataFromFile(ctx, s)
 }
 
-func LoadMetadataFromFile(s store.Store) (mo.Option[*CheckpointMetaDataJSON], error) {
+func LoadMetadataFromFile(ctx context.Context, s store.Store) (mo.Option[*CheckpointMetaDataJSON], error) {
 
 	for i := 0; i < 3; i++ {
 		if i != 0 {
-			time.Sleep(time.Second)
+			select {
+			case <-ctx.Done():
+				return mo.None[*CheckpointMetaDataJSON](), errno.CheckContext(ctx)
+			case <-time.After(time.Second):
+			}
 		}
 
-		lines, err := s.Read(LastCheckpointPath)
+		lines, err := s.Read(ctx, LastCheckpointPath)
 		if err != nil {
 			if eris.Is(err, errno.ErrFileNotFound) {
 				return mo.None[*CheckpointMetaDataJSON](), nil
+			} else if eris.Is(err, errno.ErrCanceled) {
+				return mo.None[*CheckpointMetaDataJSON](), err
 			} else {
 				continue
 			}
@@ -133,7 +160,7 @@ func LoadMetadataFromFile(s store.Store) (mo.Option[*CheckpointMetaDataJSON], er
 	// Hit a partial file. This could happen on Azure as overwriting _last_checkpoint file is
 	// not atomic. We will try to list all files to find the latest checkpoint and restore
 	// CheckpointMetaData from it.
-	if lastCheckpoint, err := FindLastCompleteCheckpoint(s, MaxInstance); err != nil {
+	if lastCheckpoint, err := FindLastCompleteCheckpoint(ctx, s, MaxInstance); err != nil {
 		return mo.None[*CheckpointMetaDataJSON](), eris.Wrap(err, "FindLastCompleteCheckpoint")
 	} else {
 		return manuallyLoadCheckpoint(lastCheckpoint), nil
@@ -153,12 +180,12 @@ func manuallyLoadCheckpoint(t mo.Option[*CheckpointInstance]) mo.Option[*Checkpo
 	}
 }
 
-func FindLastCompleteCheckpoint(s store.Store, cv CheckpointInstance) (mo.Option[*CheckpointInstance], error) {
+func FindLastCompleteCheckpoint(ctx context.Context, s store.Store, cv CheckpointInstance) (mo.Option[*CheckpointInstance], error) {
 
 	cur := util.MaxInt64(cv.Version, 0)
 	for cur >= 0 {
 
-		iter, err := s.ListFrom(filenames.CheckpointPrefix(s.Root(), util.MaxInt64(0, cur-1000)))
+		iter, err := s.ListFrom(ctx, filenames.CheckpointPrefix(s.Root(), util.MaxInt64(0, cur-1000)))
 		if err != nil {
 			return mo.None[*CheckpointInstance](), eris.Wrap(err, "")
 		}
@@ -202,9 +229,13 @@ type instanceKey struct {
 	Version  int64
 	NumParts int
 	HasParts bool
+	V2Name   string
 }
 
 func (i instanceKey) toInstance() *CheckpointInstance {
+	if i.V2Name != "" {
+		return &CheckpointInstance{Version: i.Version, NumParts: mo.None[int](), V2Name: mo.Some(i.V2Name)}
+	}
 	if i.HasParts {
 		return &CheckpointInstance{Version: i.Version, NumParts: mo.Some(i.NumParts)}
 	}
@@ -218,7 +249,7 @@ func GetLatestCompleteCheckpointFromList(instances []*CheckpointInstance, notLat
 		if !i.IsNotLaterThan(notLaterThan) {
 			continue
 		}
-		k := instanceKey{Version: i.Version, NumParts: i.NumParts.OrElse(1), HasParts: i.NumParts.IsPresent()}
+		k := instanceKey{Version: i.Version, NumParts: i.NumParts.OrElse(1), HasParts: i.NumParts.IsPresent(), V2Name: i.V2Name.OrEmpty()}
 		if vals, ok := grouped[k]; ok {
 			vals = append(vals, i)
 			grouped[k] = vals
@@ -244,19 +275,35 @@ func GetLatestCompleteCheckpointFromList(instances []*CheckpointInstance, notLat
 	return mo.None[*CheckpointInstance]()
 }
 
-func checkpoint(logPath string, store store.Store, snapshotToCheckpoint *snapshotImp) error {
+func checkpoint(ctx context.Context, logPath string, store store.Store, snapshotToCheckpoint *snapshotImp, clock Clock) error {
 
 	pw, err := newParquetActionWriter(logPath)
 	if err != nil {
 		return eris.Wrap(err, "can not new ParquetActionWriter")
 	}
 
+	metadata, err := snapshotToCheckpoint.Metadata()
+	if err != nil {
+		return err
+	}
+
+	schemaText, err := checkpointSchemaDefinition(metadata)
+	if err != nil {
+		return err
+	}
+
 	writer := &checkpointWriter{
-		schemaText: actionSchemaDefinitionString,
+		schemaText: schemaText,
 		pw:         pw,
+		partSize:   snapshotToCheckpoint.config.CheckpointPartSize,
 	}
 
-	checkpointMetadata, err := writer.write(snapshotToCheckpoint)
+	var checkpointMetadata *CheckpointMetaDataJSON
+	if useV2Checkpoint(snapshotToCheckpoint.protocolAndMetadata.V1, metadata) {
+		checkpointMetadata, err = writer.writeV2(ctx, snapshotToCheckpoint, store, snapshotToCheckpoint.config.V2CheckpointFormat)
+	} else {
+		checkpointMetadata, err = writer.write(ctx, snapshotToCheckpoint)
+	}
 	if err != nil {
 		return err
 	}
@@ -266,11 +313,14 @@ func checkpoint(logPath string, store store.Store, snapshotToCheckpoint *snapsho
 		return errno.JsonMarshalError(err)
 	}
 
-	if err := store.Write(LastCheckpointPath, iter.FromSlice([]string{string(b)}), true); err != nil {
+	if err := store.Write(ctx, LastCheckpointPath, iter.FromSlice([]string{string(b)}), true); err != nil {
 		return err
 	}
 
-	// todo: doLogCleanup()
+	// the checkpoint is already written, a failed cleanup will be retried after the next checkpoint
+	if err := doLogCleanup(ctx, store, snapshotToCheckpoint.checkpointReader, metadata, clock); err != nil {
+		log.Println("Failed to clean up expired logs. " + err.Error())
+	}
 
 	return nil
 }
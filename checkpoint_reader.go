@@ -2,8 +2,10 @@ package deltago
 
 import (
 	"context"
+	"strings"
 
 	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util/path"
 	"github.com/csimplestring/delta-go/iter"
 	goparquet "github.com/fraugster/parquet-go"
@@ -16,7 +18,7 @@ import (
 )
 
 type checkpointReader interface {
-	Read(path string) (iter.Iter[action.Action], error)
+	Read(ctx context.Context, path string) (iter.Iter[action.Action], error)
 }
 
 func newCheckpointReader(urlstr string, m *blob.URLMux) (checkpointReader, error) {
@@ -46,11 +48,15 @@ type defaultCheckpointReader struct {
 	bucket *blob.Bucket
 }
 
-func (l *defaultCheckpointReader) Read(path string) (iter.Iter[action.Action], error) {
+func (l *defaultCheckpointReader) Read(ctx context.Context, path string) (iter.Iter[action.Action], error) {
+	// some drivers, e.g. fileblob, do not check the context when opening a reader
+	if err := errno.CheckContext(ctx); err != nil {
+		return nil, err
+	}
 
-	r, err := l.bucket.NewReader(context.Background(), path, nil)
+	r, err := l.bucket.NewReader(ctx, path, nil)
 	if err != nil {
-		return nil, eris.Wrap(err, "")
+		return nil, errno.ContextError(ctx, eris.Wrap(err, ""))
 	}
 
 	fr, err := goparquet.NewFileReader(r)
@@ -77,7 +83,7 @@ func (p *defaultParquetIterater) Next() (action.Action, error) {
 
 	obj := interfaces.NewUnmarshallObject(data)
 
-	am := &actionMarshaller{a: &action.SingleAction{}}
+	am := &actionMarshaller{a: &action.SingleAction{}, schema: p.reader.GetSchemaDefinition()}
 	if err := am.UnmarshalParquet(obj); err != nil {
 		return nil, eris.Wrap(err, "failed to read value")
 	}
@@ -116,6 +122,14 @@ message SingleAction {
 		  optional binary value (STRING);
 		}
 	  }
+	  optional group deletionVector {
+		required binary storageType (STRING);
+		required binary pathOrInlineDv (STRING);
+		optional int32 offset;
+		required int32 sizeInBytes;
+		required int64 cardinality;
+		optional int64 maxRowIndex;
+	  }
 	}
 	optional group remove {
 	  required binary path (STRING);
@@ -135,6 +149,14 @@ message SingleAction {
 		  optional binary value (STRING);
 		}
 	  }
+	  optional group deletionVector {
+		required binary storageType (STRING);
+		required binary pathOrInlineDv (STRING);
+		optional int32 offset;
+		required int32 sizeInBytes;
+		required int64 cardinality;
+		optional int64 maxRowIndex;
+	  }
 	}
 	optional group metaData {
 	  optional binary id (STRING);
@@ -230,3 +252,29 @@ message SingleAction {
 	}
   }
 `
+
+// v2CheckpointSchemaDefinitionString is the schema of the top level parquet file of a V2 checkpoint,
+// it has the checkpointMetadata and sidecar actions in addition to the actions of the classic checkpoint.
+var v2CheckpointSchemaDefinitionString = strings.TrimSuffix(strings.TrimSpace(actionSchemaDefinitionString), "}") + `
+	optional group checkpointMetadata {
+	  required int64 version;
+	  optional group tags (MAP) {
+		repeated group key_value {
+		  required binary key (STRING);
+		  optional binary value (STRING);
+		}
+	  }
+	}
+	optional group sidecar {
+	  required binary path (STRING);
+	  required int64 sizeInBytes;
+	  required int64 modificationTime;
+	  optional group tags (MAP) {
+		repeated group key_value {
+		  required binary key (STRING);
+		  optional binary value (STRING);
+		}
+	  }
+	}
+  }
+`
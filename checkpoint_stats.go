@@ -0,0 +1,591 @@
+package deltago
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/types"
+	"github.com/fraugster/parquet-go/floor/interfaces"
+	parq "github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/rotisserie/eris"
+	"github.com/shopspring/decimal"
+)
+
+// The typed columns of the add action in checkpoints, written if delta.checkpoint.writeStatsAsStruct is enabled.
+const (
+	addStatsParsed           = "stats_parsed"
+	addPartitionValuesParsed = "partitionValues_parsed"
+)
+
+// checkpointSchemaDefinition returns the parquet schema of the checkpoint files of the table.
+// If delta.checkpoint.writeStatsAsStruct is enabled, the add action has the stats_parsed and partitionValues_parsed
+// columns in addition, which hold the column statistics and the partition values typed by the table schema.
+func checkpointSchemaDefinition(metadata *action.Metadata) (string, error) {
+	if !DeltaConfigCheckpointWriteStatsAsStruct.fromMetadata(metadata) {
+		return actionSchemaDefinitionString, nil
+	}
+
+	parsed, err := parsedColumnsDefinition(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	sd, err := parquetschema.ParseSchemaDefinition(actionSchemaDefinitionString)
+	if err != nil {
+		return "", eris.Wrap(err, "parsing schema definition")
+	}
+	parsedSd, err := parquetschema.ParseSchemaDefinition("message parsed {\n" + parsed + "}\n")
+	if err != nil {
+		return "", eris.Wrap(err, "parsing schema definition of the parsed columns")
+	}
+
+	add := sd.SubSchema("add").RootColumn
+	add.Children = append(add.Children, parsedSd.RootColumn.Children...)
+	return sd.String(), nil
+}
+
+// parsedColumnsDefinition returns the definitions of the partitionValues_parsed and stats_parsed columns.
+// The columns are named by their physical names, the columns with unsupported types are left out.
+func parsedColumnsDefinition(metadata *action.Metadata) (string, error) {
+	schema, err := metadata.PhysicalSchema()
+	if err != nil {
+		return "", err
+	}
+	physicalNames, err := metadata.PhysicalNames()
+	if err != nil {
+		return "", err
+	}
+	partitionColumns := make(map[string]bool, len(metadata.PartitionColumns))
+	for _, c := range metadata.PartitionColumns {
+		partitionColumns[physicalNames[c]] = true
+	}
+
+	var partitionFields, dataFields []*types.StructField
+	for _, f := range schema.Fields {
+		if partitionColumns[f.Name] {
+			partitionFields = append(partitionFields, f)
+		} else {
+			dataFields = append(dataFields, f)
+		}
+	}
+
+	var sb strings.Builder
+	var partitionValues strings.Builder
+	for _, f := range partitionFields {
+		if c, ok := parsedValueColumn(f.Name, f.DataType); ok {
+			partitionValues.WriteString(c)
+		}
+	}
+	if partitionValues.Len() > 0 {
+		fmt.Fprintf(&sb, "optional group %s {\n%s}\n", addPartitionValuesParsed, partitionValues.String())
+	}
+
+	fmt.Fprintf(&sb, "optional group %s {\n", addStatsParsed)
+	fmt.Fprintf(&sb, "optional int64 %s;\n", statsNumRecords)
+	if minMax := statsColumns(dataFields, true); len(minMax) > 0 {
+		fmt.Fprintf(&sb, "optional group %s {\n%s}\n", statsMinValues, minMax)
+		fmt.Fprintf(&sb, "optional group %s {\n%s}\n", statsMaxValues, minMax)
+	}
+	if nullCount := statsColumns(dataFields, false); len(nullCount) > 0 {
+		fmt.Fprintf(&sb, "optional group %s {\n%s}\n", statsNullCount, nullCount)
+	}
+	fmt.Fprintf(&sb, "optional boolean %s;\n", statsTightBounds)
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+// statsColumns returns the definitions of the min/max values of the fields if minMax is true, otherwise the null counts.
+// The nested columns are nested groups, only the columns which are eligible for data skipping have min/max values.
+func statsColumns(fields []*types.StructField, minMax bool) string {
+	var sb strings.Builder
+	for _, f := range fields {
+		if !isParquetSchemaName(f.Name) {
+			continue
+		}
+		switch dt := f.DataType.(type) {
+		case *types.StructType:
+			if nested := statsColumns(dt.Fields, minMax); len(nested) > 0 {
+				fmt.Fprintf(&sb, "optional group %s {\n%s}\n", f.Name, nested)
+			}
+		case *types.BooleanType, *types.BinaryType, *types.ArrayType, *types.MapType:
+			if !minMax {
+				fmt.Fprintf(&sb, "optional int64 %s;\n", f.Name)
+			}
+		default:
+			if !minMax {
+				fmt.Fprintf(&sb, "optional int64 %s;\n", f.Name)
+			} else if c, ok := parsedValueColumn(f.Name, dt); ok {
+				sb.WriteString(c)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parsedValueColumn returns the definition of the optional parquet column holding a value of the data type,
+// it returns false if the type or the name is not supported.
+func parsedValueColumn(name string, dt types.DataType) (string, bool) {
+	if !isParquetSchemaName(name) {
+		return "", false
+	}
+
+	var def string
+	switch v := dt.(type) {
+	case *types.ByteType:
+		def = "int32 %s (INT(8, true))"
+	case *types.ShortType:
+		def = "int32 %s (INT(16, true))"
+	case *types.IntegerType:
+		def = "int32 %s"
+	case *types.LongType:
+		def = "int64 %s"
+	case *types.FloatType:
+		def = "float %s"
+	case *types.DoubleType:
+		def = "double %s"
+	case *types.StringType:
+		def = "binary %s (STRING)"
+	case *types.BinaryType:
+		def = "binary %s"
+	case *types.BooleanType:
+		def = "boolean %s"
+	case *types.DateType:
+		def = "int32 %s (DATE)"
+	case *types.TimestampType:
+		def = "int64 %s (TIMESTAMP(MICROS, true))"
+	case *types.DecimalType:
+		physical := fmt.Sprintf("fixed_len_byte_array(%d)", decimalBytes(v.Precision))
+		if v.Precision <= 9 {
+			physical = "int32"
+		} else if v.Precision <= 18 {
+			physical = "int64"
+		}
+		def = physical + " %s " + fmt.Sprintf("(DECIMAL(%d, %d))", v.Precision, v.Scale)
+	default:
+		return "", false
+	}
+	return "optional " + fmt.Sprintf(def, name) + ";\n", true
+}
+
+// isParquetSchemaName checks if the column name can be written in a textual parquet schema definition.
+func isParquetSchemaName(name string) bool {
+	return len(name) > 0 && !unicode.IsDigit([]rune(name)[0]) && !strings.ContainsAny(name, " \t\r\n;{}()=,")
+}
+
+// decimalBytes returns the minimal length of the fixed length byte array which holds the unscaled decimals of the precision.
+func decimalBytes(precision int) int {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+	n := 1
+	for new(big.Int).Lsh(big.NewInt(1), uint(8*n-1)).Cmp(max) < 0 {
+		n++
+	}
+	return n
+}
+
+// parquetMarshalAddParsed writes the stats_parsed and partitionValues_parsed columns of the add action if they are in the schema.
+// The values are taken from StatsParsed and PartitionValuesParsed if present, otherwise they are parsed from Stats and PartitionValues.
+// A value which can not be converted to the type of its column is left null.
+func parquetMarshalAddParsed(add *action.AddFile, obj interfaces.MarshalObject, addSchema *parquetschema.SchemaDefinition) {
+	if sd := addSchema.SubSchema(addStatsParsed); sd != nil {
+		if stats, ok := addFileStats(add); ok {
+			values := map[string]any{
+				statsMinValues: stats.MinValues,
+				statsMaxValues: stats.MaxValues,
+				statsNullCount: stats.NullCount,
+			}
+			if stats.NumRecords != nil {
+				values[statsNumRecords] = *stats.NumRecords
+			}
+			if stats.TightBounds != nil {
+				values[statsTightBounds] = *stats.TightBounds
+			}
+			if v, ok := toParquetValue(sd.RootColumn, values); ok {
+				obj.GetData()[addStatsParsed] = v
+			}
+		}
+	}
+
+	if sd := addSchema.SubSchema(addPartitionValuesParsed); sd != nil {
+		values := add.PartitionValuesParsed
+		if values == nil {
+			values = make(map[string]any, len(add.PartitionValues))
+			for k, v := range add.PartitionValues {
+				// an empty partition value is null
+				if len(v) > 0 {
+					values[k] = v
+				}
+			}
+		}
+		if v, ok := toParquetValue(sd.RootColumn, values); ok {
+			obj.GetData()[addPartitionValuesParsed] = v
+		}
+	}
+}
+
+// parquetUnmarshalAddParsed reads the stats_parsed and partitionValues_parsed columns of the add action if they are in the schema.
+func parquetUnmarshalAddParsed(add *action.AddFile, obj interfaces.UnmarshalObject, addSchema *parquetschema.SchemaDefinition) {
+	data := obj.GetData()
+	if sd := addSchema.SubSchema(addStatsParsed); sd != nil {
+		if v, ok := fromParquetValue(sd.RootColumn, data[addStatsParsed]); ok {
+			values := v.(map[string]any)
+			stats := &action.StatsParsed{}
+			if n, ok := values[statsNumRecords].(int64); ok {
+				stats.NumRecords = &n
+			}
+			stats.MinValues, _ = values[statsMinValues].(map[string]any)
+			stats.MaxValues, _ = values[statsMaxValues].(map[string]any)
+			stats.NullCount, _ = values[statsNullCount].(map[string]any)
+			if b, ok := values[statsTightBounds].(bool); ok {
+				stats.TightBounds = &b
+			}
+			add.StatsParsed = stats
+		}
+	}
+
+	if sd := addSchema.SubSchema(addPartitionValuesParsed); sd != nil {
+		if v, ok := fromParquetValue(sd.RootColumn, data[addPartitionValuesParsed]); ok {
+			add.PartitionValuesParsed = v.(map[string]any)
+		} else {
+			// all the partition values are null
+			add.PartitionValuesParsed = map[string]any{}
+		}
+	}
+}
+
+// toParquetValue converts the value to the parquet value of the column, a group is converted from a map.
+// It returns false if the value can not be converted, or the group has no value.
+func toParquetValue(col *parquetschema.ColumnDefinition, v any) (any, bool) {
+	if len(col.Children) == 0 {
+		return toParquetPrimitive(col.SchemaElement, v)
+	}
+
+	values, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	res := make(map[string]interface{}, len(col.Children))
+	for _, c := range col.Children {
+		name := c.SchemaElement.Name
+		if value, ok := values[name]; ok && value != nil {
+			if pv, ok := toParquetValue(c, value); ok {
+				res[name] = pv
+			}
+		}
+	}
+	return res, len(res) > 0
+}
+
+func toParquetPrimitive(elem *parq.SchemaElement, v any) (any, bool) {
+	switch elem.GetType() {
+	case parq.Type_BOOLEAN:
+		switch b := v.(type) {
+		case bool:
+			return b, true
+		case string:
+			res, err := strconv.ParseBool(b)
+			return res, err == nil
+		}
+	case parq.Type_INT32:
+		if parquetIsDate(elem) {
+			t, ok := toTime(v)
+			return int32(math.Floor(float64(t.Unix()) / 86400)), ok
+		}
+		if scale, ok := parquetDecimalScale(elem); ok {
+			i, ok := toUnscaled(v, scale)
+			return int32(i.Int64()), ok && i.IsInt64() && i.Int64() >= math.MinInt32 && i.Int64() <= math.MaxInt32
+		}
+		i, ok := toInt64(v)
+		return int32(i), ok && i >= math.MinInt32 && i <= math.MaxInt32
+	case parq.Type_INT64:
+		if unit, ok := parquetTimestampUnit(elem); ok {
+			t, ok := toTime(v)
+			return t.UnixNano() / int64(unit), ok
+		}
+		if scale, ok := parquetDecimalScale(elem); ok {
+			i, ok := toUnscaled(v, scale)
+			return i.Int64(), ok && i.IsInt64()
+		}
+		return toInt64(v)
+	case parq.Type_FLOAT:
+		f, ok := toFloat64(v)
+		return float32(f), ok
+	case parq.Type_DOUBLE:
+		return toFloat64(v)
+	case parq.Type_BYTE_ARRAY:
+		switch s := v.(type) {
+		case string:
+			return []byte(s), true
+		case []byte:
+			return s, true
+		}
+	case parq.Type_FIXED_LEN_BYTE_ARRAY:
+		if scale, ok := parquetDecimalScale(elem); ok {
+			i, ok := toUnscaled(v, scale)
+			if !ok {
+				return nil, false
+			}
+			return toTwosComplement(i, int(elem.GetTypeLength()))
+		}
+	}
+	return nil, false
+}
+
+// fromParquetValue converts the parquet value of the column to the go value, a group is converted to a map.
+// It returns false if the value is null, or the group has no value.
+func fromParquetValue(col *parquetschema.ColumnDefinition, v any) (any, bool) {
+	if len(col.Children) == 0 {
+		return fromParquetPrimitive(col.SchemaElement, v)
+	}
+
+	values, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	res := make(map[string]any, len(col.Children))
+	for _, c := range col.Children {
+		name := c.SchemaElement.Name
+		if value, ok := fromParquetValue(c, values[name]); ok {
+			res[name] = value
+		}
+	}
+	return res, len(res) > 0
+}
+
+func fromParquetPrimitive(elem *parq.SchemaElement, v any) (any, bool) {
+	switch p := v.(type) {
+	case bool, float32, float64:
+		return p, true
+	case int32:
+		if parquetIsDate(elem) {
+			return time.Unix(int64(p)*86400, 0).UTC(), true
+		}
+		if scale, ok := parquetDecimalScale(elem); ok {
+			return decimal.New(int64(p), -scale), true
+		}
+		switch parquetIntBitWidth(elem) {
+		case 8:
+			return int8(p), true
+		case 16:
+			return int16(p), true
+		}
+		return p, true
+	case int64:
+		if unit, ok := parquetTimestampUnit(elem); ok {
+			return time.Unix(0, p*int64(unit)).UTC(), true
+		}
+		if scale, ok := parquetDecimalScale(elem); ok {
+			return decimal.New(p, -scale), true
+		}
+		return p, true
+	case [12]byte:
+		// the legacy INT96 timestamps, the nanoseconds of the day followed by the julian day, both little endian
+		nanos := int64(0)
+		for i := 7; i >= 0; i-- {
+			nanos = nanos<<8 | int64(p[i])
+		}
+		days := int64(p[8]) | int64(p[9])<<8 | int64(p[10])<<16 | int64(p[11])<<24
+		const julianDayOfEpoch = 2440588
+		return time.Unix((days-julianDayOfEpoch)*86400, nanos).UTC(), true
+	case []byte:
+		if scale, ok := parquetDecimalScale(elem); ok {
+			return decimal.NewFromBigInt(fromTwosComplement(p), -scale), true
+		}
+		if parquetIsString(elem) {
+			return string(p), true
+		}
+		return p, true
+	}
+	return nil, false
+}
+
+func parquetIsDate(elem *parq.SchemaElement) bool {
+	if lt := elem.GetLogicalType(); lt != nil {
+		return lt.IsSetDATE()
+	}
+	return elem.IsSetConvertedType() && elem.GetConvertedType() == parq.ConvertedType_DATE
+}
+
+func parquetIsString(elem *parq.SchemaElement) bool {
+	if lt := elem.GetLogicalType(); lt != nil {
+		return lt.IsSetSTRING()
+	}
+	return elem.IsSetConvertedType() && elem.GetConvertedType() == parq.ConvertedType_UTF8
+}
+
+func parquetDecimalScale(elem *parq.SchemaElement) (int32, bool) {
+	if lt := elem.GetLogicalType(); lt != nil {
+		if lt.IsSetDECIMAL() {
+			return lt.DECIMAL.Scale, true
+		}
+		return 0, false
+	}
+	if elem.IsSetConvertedType() && elem.GetConvertedType() == parq.ConvertedType_DECIMAL {
+		return elem.GetScale(), true
+	}
+	return 0, false
+}
+
+// parquetTimestampUnit returns the unit of the timestamp column.
+func parquetTimestampUnit(elem *parq.SchemaElement) (time.Duration, bool) {
+	if lt := elem.GetLogicalType(); lt != nil {
+		if !lt.IsSetTIMESTAMP() {
+			return 0, false
+		}
+		switch {
+		case lt.TIMESTAMP.Unit.IsSetMILLIS():
+			return time.Millisecond, true
+		case lt.TIMESTAMP.Unit.IsSetMICROS():
+			return time.Microsecond, true
+		default:
+			return time.Nanosecond, true
+		}
+	}
+	if elem.IsSetConvertedType() {
+		switch elem.GetConvertedType() {
+		case parq.ConvertedType_TIMESTAMP_MILLIS:
+			return time.Millisecond, true
+		case parq.ConvertedType_TIMESTAMP_MICROS:
+			return time.Microsecond, true
+		}
+	}
+	return 0, false
+}
+
+func parquetIntBitWidth(elem *parq.SchemaElement) int8 {
+	if lt := elem.GetLogicalType(); lt != nil {
+		if lt.IsSetINTEGER() {
+			return lt.INTEGER.BitWidth
+		}
+		return 0
+	}
+	if elem.IsSetConvertedType() {
+		switch elem.GetConvertedType() {
+		case parq.ConvertedType_INT_8:
+			return 8
+		case parq.ConvertedType_INT_16:
+			return 16
+		}
+	}
+	return 0
+}
+
+// The layouts of the dates and timestamps in the json statistics and the partition values.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999", "2006-01-02"}
+
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, layout := range timeLayouts {
+			if res, err := time.Parse(layout, t); err == nil {
+				return res, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toInt64(v any) (int64, bool) {
+	switch i := v.(type) {
+	case json.Number:
+		res, err := i.Int64()
+		return res, err == nil
+	case string:
+		res, err := strconv.ParseInt(i, 10, 64)
+		return res, err == nil
+	case int:
+		return int64(i), true
+	case int8:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch f := v.(type) {
+	case json.Number:
+		res, err := f.Float64()
+		return res, err == nil
+	case string:
+		res, err := strconv.ParseFloat(f, 64)
+		return res, err == nil
+	case float32:
+		return float64(f), true
+	case float64:
+		return f, true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// toUnscaled returns the unscaled value of the decimal with the scale, false if it has more fractional digits than the scale.
+func toUnscaled(v any, scale int32) (*big.Int, bool) {
+	var d decimal.Decimal
+	switch n := v.(type) {
+	case decimal.Decimal:
+		d = n
+	case json.Number:
+		var err error
+		if d, err = decimal.NewFromString(n.String()); err != nil {
+			return nil, false
+		}
+	case string:
+		var err error
+		if d, err = decimal.NewFromString(n); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	shifted := d.Shift(scale)
+	if !shifted.Equal(shifted.Truncate(0)) {
+		return nil, false
+	}
+	return shifted.BigInt(), true
+}
+
+// toTwosComplement returns the big endian two's complement of the integer in n bytes.
+func toTwosComplement(i *big.Int, n int) ([]byte, bool) {
+	if i.Sign() >= 0 {
+		b := i.Bytes()
+		if len(b) > n || (len(b) == n && b[0]&0x80 != 0) {
+			return nil, false
+		}
+		return append(make([]byte, n-len(b)), b...), true
+	}
+
+	// 2^(8n) + i
+	c := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(8*n)), i)
+	b := c.Bytes()
+	if len(b) != n || b[0]&0x80 == 0 {
+		return nil, false
+	}
+	return b, true
+}
+
+func fromTwosComplement(b []byte) *big.Int {
+	i := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return i
+}
@@ -0,0 +1,131 @@
+package deltago
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/types"
+)
+
+func TestCheckpoint_write_stats_as_struct(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	schemaString := `{"type":"struct","fields":[` +
+		`{"name":"id","type":"integer","nullable":true,"metadata":{}},` +
+		`{"name":"name","type":"string","nullable":true,"metadata":{}},` +
+		`{"name":"d","type":"date","nullable":true,"metadata":{}},` +
+		`{"name":"ts","type":"timestamp","nullable":true,"metadata":{}},` +
+		`{"name":"amount","type":"decimal(10,2)","nullable":true,"metadata":{}},` +
+		`{"name":"big","type":"decimal(25,3)","nullable":true,"metadata":{}},` +
+		`{"name":"flag","type":"boolean","nullable":true,"metadata":{}},` +
+		`{"name":"nested","type":{"type":"struct","fields":[{"name":"s","type":"smallint","nullable":true,"metadata":{}}]},"nullable":true,"metadata":{}},` +
+		`{"name":"part","type":"string","nullable":true,"metadata":{}}]}`
+	metadata := &action.Metadata{
+		SchemaString:     schemaString,
+		PartitionColumns: []string{"part"},
+		Configuration:    map[string]string{DeltaConfigCheckpointWriteStatsAsStruct.Key: "true"},
+	}
+
+	stats := `{"numRecords":3,` +
+		`"minValues":{"id":1,"name":"a","d":"2023-01-01","ts":"2023-01-01T10:00:00.123456Z","amount":-1.5,"big":-12345678901234567890.123,"nested":{"s":2}},` +
+		`"maxValues":{"id":9,"name":"z","d":"2023-01-31","ts":"2023-01-31T10:00:00.000Z","amount":99.99,"big":12345678901234567890.123,"nested":{"s":7}},` +
+		`"nullCount":{"id":0,"name":1,"flag":2,"nested":{"s":0}}}`
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{metadata,
+		&action.AddFile{Path: "a", PartitionValues: map[string]string{"part": "p1"}, Size: 1, ModificationTime: 1, DataChange: true, Stats: stats},
+		&action.AddFile{Path: "b", PartitionValues: map[string]string{"part": ""}, Size: 1, ModificationTime: 1, DataChange: true},
+	}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Empty(t, s.(*snapshotImp).logSegment.Deltas)
+
+	files, err := s.AllFiles()
+	assert.NoError(t, err)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	assert.Len(t, files, 2)
+
+	a := files[0]
+	assert.Equal(t, stats, a.Stats)
+	assert.Equal(t, map[string]any{"part": "p1"}, a.PartitionValuesParsed)
+	assert.NotNil(t, a.StatsParsed)
+	assert.Equal(t, int64(3), *a.StatsParsed.NumRecords)
+	assert.Nil(t, a.StatsParsed.TightBounds)
+
+	min := a.StatsParsed.MinValues
+	assert.Equal(t, int32(1), min["id"])
+	assert.Equal(t, "a", min["name"])
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), min["d"])
+	assert.Equal(t, time.Date(2023, 1, 1, 10, 0, 0, 123456000, time.UTC), min["ts"])
+	assert.True(t, decimal.RequireFromString("-1.5").Equal(min["amount"].(decimal.Decimal)))
+	assert.True(t, decimal.RequireFromString("-12345678901234567890.123").Equal(min["big"].(decimal.Decimal)))
+	assert.Equal(t, map[string]any{"s": int16(2)}, min["nested"])
+	assert.NotContains(t, min, "flag")
+	assert.True(t, decimal.RequireFromString("12345678901234567890.123").Equal(a.StatsParsed.MaxValues["big"].(decimal.Decimal)))
+	assert.Equal(t, map[string]any{"id": int64(0), "name": int64(1), "flag": int64(2), "nested": map[string]any{"s": int64(0)}},
+		a.StatsParsed.NullCount)
+
+	// the file without stats has no parsed stats, its null partition value is absent
+	b := files[1]
+	assert.Nil(t, b.StatsParsed)
+	assert.Empty(t, b.PartitionValuesParsed)
+
+	// data skipping uses the parsed stats
+	schema, err := metadata.Schema()
+	assert.NoError(t, err)
+	id := schema.Column("id")
+	for _, c := range []struct {
+		filter   types.Expression
+		expected []string
+	}{
+		{types.NewEqualTo(id, types.LiteralInt(5)), []string{"a", "b"}},
+		{types.NewGreaterThan(id, types.LiteralInt(9)), []string{"b"}},
+	} {
+		scan, err := s.Scan(c.filter)
+		assert.NoError(t, err)
+		fIter, err := scan.Files()
+		assert.NoError(t, err)
+		addFiles, err := iter.ToSlice(fIter)
+		assert.NoError(t, err)
+		var paths []string
+		for _, f := range addFiles {
+			paths = append(paths, f.Path)
+		}
+		sort.Strings(paths)
+		assert.Equal(t, c.expected, paths)
+	}
+}
+
+func TestCheckpointSchemaDefinition(t *testing.T) {
+	metadata := getTestMetedata()
+	sd, err := checkpointSchemaDefinition(metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, actionSchemaDefinitionString, sd)
+
+	metadata.Configuration = map[string]string{DeltaConfigCheckpointWriteStatsAsStruct.Key: "true"}
+	sd, err = checkpointSchemaDefinition(metadata)
+	assert.NoError(t, err)
+	assert.Contains(t, sd, addStatsParsed)
+	assert.NotContains(t, sd, addPartitionValuesParsed)
+}
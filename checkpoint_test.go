@@ -1,6 +1,7 @@
 package deltago_test
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"testing"
@@ -29,7 +30,7 @@ func TestFindLastCompleteCheckpoint(t *testing.T) {
 			return true, callFailure
 		}
 
-		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(s, delta.MaxInstance)
+		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(context.Background(), s, delta.MaxInstance)
 
 		assert.Equal(t, lastCheckpoint, mo.None[*delta.CheckpointInstance]())
 		assert.Equal(t, err.Error(), eris.Wrap(callFailure, "").Error())
@@ -50,7 +51,7 @@ func TestFindLastCompleteCheckpoint(t *testing.T) {
 			return false, nil
 		}
 
-		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(s, delta.MaxInstance)
+		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(context.Background(), s, delta.MaxInstance)
 
 		assert.Equal(t, lastCheckpoint, mo.None[*delta.CheckpointInstance]())
 		assert.Equal(t, err.Error(), eris.Wrap(callFailure, "").Error())
@@ -69,7 +70,7 @@ func TestFindLastCompleteCheckpoint(t *testing.T) {
 			return false, nil
 		}
 
-		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(s, delta.MaxInstance)
+		lastCheckpoint, err := delta.FindLastCompleteCheckpoint(context.Background(), s, delta.MaxInstance)
 
 		assert.Equal(t, lastCheckpoint, mo.None[*delta.CheckpointInstance]())
 		assert.Equal(t, err, nil)
@@ -93,13 +94,13 @@ func (s *memLogStore) Root() string {
 	return ""
 }
 
-func (s *memLogStore) Read(path string) (iter.Iter[string], error) {
+func (s *memLogStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
 var failIf func() (bool, error)
 
-func (s *memLogStore) ListFrom(path string) (iter.Iter[*store.FileMeta], error) {
+func (s *memLogStore) ListFrom(ctx context.Context, path string) (iter.Iter[*store.FileMeta], error) {
 	return &memIter{
 		mustFail: failIf,
 	}, nil
@@ -121,7 +122,7 @@ func (i *memIter) Close() error {
 	return fmt.Errorf("not implemented")
 }
 
-func (s *memLogStore) Write(path string, actions iter.Iter[string], overwrite bool) error {
+func (s *memLogStore) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
 	return fmt.Errorf("not implemented")
 }
 
@@ -133,9 +134,13 @@ func (s *memLogStore) IsPartialWriteVisible(path string) bool {
 	return false
 }
 
-func (s *memLogStore) Exists(path string) (bool, error) {
+func (s *memLogStore) Exists(ctx context.Context, path string) (bool, error) {
 	return false, fmt.Errorf("not implemented")
 }
-func (s *memLogStore) Create(path string) error {
+func (s *memLogStore) Create(ctx context.Context, path string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (s *memLogStore) Delete(ctx context.Context, path string) error {
 	return fmt.Errorf("not implemented")
 }
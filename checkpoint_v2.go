@@ -0,0 +1,237 @@
+package deltago
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/store"
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+)
+
+// readCheckpointFile reads the actions of a checkpoint file, either a part of a classic checkpoint
+// or the top level file of a V2 checkpoint in json or parquet.
+// The sidecar actions of a V2 checkpoint are replaced by the actions of the sidecar files,
+// which are read after all the actions of the top level file.
+func readCheckpointFile(ctx context.Context, logStore store.Store, cr checkpointReader, p string) (iter.Iter[action.Action], error) {
+	top, err := readCheckpointTopLevel(ctx, logStore, cr, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkpointFileIterator{
+		ctx: ctx,
+		cr:  cr,
+		dir: p[:strings.LastIndex(p, "/")+1],
+		top: top,
+	}, nil
+}
+
+// readCheckpointTopLevel reads the actions of a checkpoint file in json or parquet, the sidecar actions are not resolved.
+func readCheckpointTopLevel(ctx context.Context, logStore store.Store, cr checkpointReader, p string) (iter.Iter[action.Action], error) {
+	if strings.HasSuffix(p, ".json") {
+		lines, err := logStore.Read(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return &iter.MapIter[string, action.Action]{
+			It:     lines,
+			Mapper: action.FromJson,
+		}, nil
+	}
+	return cr.Read(ctx, p)
+}
+
+type checkpointFileIterator struct {
+	ctx context.Context
+	cr  checkpointReader
+	// dir is the log directory where the checkpoint file is
+	dir      string
+	top      iter.Iter[action.Action]
+	sidecars []*action.Sidecar
+	current  iter.Iter[action.Action]
+}
+
+var _ iter.Iter[action.Action] = &checkpointFileIterator{}
+
+func (c *checkpointFileIterator) Next() (action.Action, error) {
+	for c.top != nil {
+		a, err := c.top.Next()
+		if err == io.EOF {
+			if err := c.top.Close(); err != nil {
+				return nil, err
+			}
+			c.top = nil
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sidecar, ok := a.(*action.Sidecar); ok {
+			c.sidecars = append(c.sidecars, sidecar)
+			continue
+		}
+		return a, nil
+	}
+
+	for {
+		if c.current == nil {
+			if len(c.sidecars) == 0 {
+				return nil, io.EOF
+			}
+			it, err := c.openSidecar(c.sidecars[0])
+			if err != nil {
+				return nil, err
+			}
+			c.sidecars = c.sidecars[1:]
+			c.current = it
+		}
+
+		a, err := c.current.Next()
+		if err == io.EOF {
+			if err := c.current.Close(); err != nil {
+				return nil, err
+			}
+			c.current = nil
+			continue
+		}
+		return a, err
+	}
+}
+
+func (c *checkpointFileIterator) openSidecar(sidecar *action.Sidecar) (iter.Iter[action.Action], error) {
+	// only the sidecar files in the _delta_log/_sidecars directory are supported
+	u, err := url.Parse(sidecar.Path)
+	if err != nil || u.Scheme != "" || strings.HasPrefix(sidecar.Path, "/") {
+		return nil, errno.UnsupportedSidecarPath(sidecar.Path)
+	}
+	return c.cr.Read(c.ctx, filenames.SidecarFile(c.dir, sidecar.Path))
+}
+
+func (c *checkpointFileIterator) Close() error {
+	var err error
+	if c.top != nil {
+		err = c.top.Close()
+		c.top = nil
+	}
+	if c.current != nil {
+		if cerr := c.current.Close(); err == nil {
+			err = cerr
+		}
+		c.current = nil
+	}
+	return err
+}
+
+// useV2Checkpoint checks if the checkpoint of the table should be written as a V2 checkpoint.
+func useV2Checkpoint(protocol *action.Protocol, metadata *action.Metadata) bool {
+	return DeltaConfigCheckpointPolicy.fromMetadata(metadata) == CheckpointPolicyV2 &&
+		protocol.IsFeatureSupported(action.FeatureV2Checkpoint)
+}
+
+// writeV2 writes a V2 checkpoint of the snapshot. The file actions are written into the parquet sidecar files,
+// a new sidecar file is started after every partSize actions if partSize is positive.
+// The top level file has the checkpoint metadata, the protocol, the metadata, the transactions and the sidecar actions.
+func (c *checkpointWriter) writeV2(ctx context.Context, snapshot *snapshotImp, logStore store.Store, format string) (*CheckpointMetaDataJSON, error) {
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "parquet" {
+		return nil, eris.Wrapf(errno.ErrIllegalArgument, "unsupported format %s of the V2 checkpoint", format)
+	}
+
+	actions := newCheckpointActionIterator(ctx, snapshot)
+	defer actions.Close()
+
+	top := []*action.SingleAction{(&action.CheckpointMetadata{Version: snapshot.version}).Wrap()}
+	var sidecarNames []string
+	checkpointSize := int64(0)
+	n := int64(0)
+	for {
+		a, err := actions.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if a.Add == nil && a.Remove == nil {
+			top = append(top, a)
+			continue
+		}
+
+		if len(sidecarNames) == 0 || (c.partSize > 0 && n >= c.partSize) {
+			if len(sidecarNames) > 0 {
+				if err := c.pw.Close(); err != nil {
+					return nil, err
+				}
+			}
+			name := uuid.NewString() + ".parquet"
+			if err := c.pw.Open(ctx, filenames.SidecarFile(snapshot.path, name), c.schemaText); err != nil {
+				return nil, err
+			}
+			sidecarNames = append(sidecarNames, name)
+			n = 0
+		}
+		if err := c.pw.Write(a); err != nil {
+			return nil, err
+		}
+		n++
+		checkpointSize++
+	}
+	if len(sidecarNames) > 0 {
+		if err := c.pw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sidecarNames {
+		attrs, err := c.pw.Stat(ctx, filenames.SidecarFile(snapshot.path, name))
+		if err != nil {
+			return nil, err
+		}
+		sidecar := &action.Sidecar{
+			Path:             name,
+			SizeInBytes:      attrs.Size,
+			ModificationTime: attrs.ModTime.UnixMilli(),
+		}
+		top = append(top, sidecar.Wrap())
+	}
+	checkpointSize += int64(len(top))
+
+	path := filenames.V2CheckpointFile(snapshot.path, snapshot.version, uuid.NewString(), format)
+	if format == "json" {
+		lines := make([]string, len(top))
+		for i, a := range top {
+			b, err := json.Marshal(a)
+			if err != nil {
+				return nil, errno.JsonMarshalError(err)
+			}
+			lines[i] = string(b)
+		}
+		if err := logStore.Write(ctx, path, iter.FromSlice(lines), false); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := c.pw.Open(ctx, path, v2CheckpointSchemaDefinitionString); err != nil {
+			return nil, err
+		}
+		for _, a := range top {
+			if err := c.pw.Write(a); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.pw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CheckpointMetaDataJSON{Version: snapshot.version, Size: checkpointSize}, nil
+}
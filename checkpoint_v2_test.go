@@ -0,0 +1,252 @@
+package deltago
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestCheckpoint_v2_with_sidecars(t *testing.T) {
+	for _, format := range []string{"json", "parquet"} {
+		t.Run(format, func(t *testing.T) {
+			tt := newTestLogCases("file")[0]
+			tt.config.CheckpointPartSize = 2
+			tt.config.V2CheckpointFormat = format
+			defer tt.clean()
+
+			log, err := tt.getTempLog()
+			assert.NoError(t, err)
+
+			now := time.Now().UnixMilli()
+
+			metadata := getTestMetedata()
+			metadata.Configuration = map[string]string{DeltaConfigCheckpointPolicy.Key: CheckpointPolicyV2}
+			commitTestActions(t, log, metadata, testAddFile("a"), testAddFile("b"), testAddFile("c"))
+
+			trx, err := log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.UpgradeProtocol([]string{action.FeatureV2Checkpoint}, getTestEngineInfo())
+			assert.NoError(t, err)
+
+			commitTestActions(t, log, &action.SetTransaction{AppId: "app", Version: 1}, testAddFile("d"), &action.RemoveFile{Path: "a", DeletionTimestamp: &now, DataChange: true})
+
+			s, err := log.Update()
+			assert.NoError(t, err)
+			l := log.(*logImpl)
+			assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+			// b, c, d and the tombstone of a are written into 2 sidecar files
+			sidecars, err := os.ReadDir(filepath.Join(strings.TrimPrefix(l.logPath, "file://"), "_sidecars"))
+			assert.NoError(t, err)
+			assert.Len(t, sidecars, 2)
+
+			reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+			assert.NoError(t, err)
+			s, err = reloaded.Snapshot()
+			assert.NoError(t, err)
+			names := checkpointNames(s)
+			assert.Len(t, names, 1)
+			assert.True(t, filenames.IsV2CheckpointFile(names[0]))
+			assert.True(t, strings.HasSuffix(names[0], "."+format))
+			assert.Empty(t, s.(*snapshotImp).logSegment.Deltas)
+
+			files, err := s.AllFiles()
+			assert.NoError(t, err)
+			var paths []string
+			for _, f := range files {
+				paths = append(paths, f.Path)
+			}
+			sort.Strings(paths)
+			assert.Equal(t, []string{"b", "c", "d"}, paths)
+
+			tombstones, err := s.(*snapshotImp).tombstones(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, tombstones, 1)
+
+			protocol, err := s.(*snapshotImp).Protocol()
+			assert.NoError(t, err)
+			assert.True(t, protocol.IsFeatureSupported(action.FeatureV2Checkpoint))
+
+			trx, err = reloaded.StartTransaction()
+			assert.NoError(t, err)
+			v, err := trx.TxnVersion("app")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), v)
+
+			// the sidecar actions are resolved when reading the checkpoint as a version log
+			changes, err := reloaded.Changes(2, false)
+			assert.NoError(t, err)
+			vl, err := changes.Next()
+			assert.NoError(t, err)
+			actions, err := vl.Actions()
+			assert.NoError(t, err)
+			assert.IsType(t, &action.CheckpointMetadata{}, actions[0])
+			var numFiles int
+			for _, a := range actions {
+				assert.NotNil(t, a)
+				switch a.(type) {
+				case *action.AddFile, *action.RemoveFile:
+					numFiles++
+				case *action.Sidecar:
+					assert.Fail(t, "sidecar action is not resolved")
+				}
+			}
+			assert.Equal(t, 4, numFiles)
+		})
+	}
+}
+
+func TestCheckpoint_classic_without_v2_feature(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	metadata := getTestMetedata()
+	metadata.Configuration = map[string]string{DeltaConfigCheckpointPolicy.Key: CheckpointPolicyV2}
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{metadata}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filenames.CheckpointFileSingular("", 0)}, checkpointNames(s))
+}
+
+func TestCheckpoint_v2_clean_up_sidecars(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	tt.config.CheckpointPartSize = 2
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	logDir := strings.TrimPrefix(l.logPath, "file://")
+	sidecarDir := filepath.Join(logDir, "_sidecars")
+
+	sidecarNames := func() []string {
+		entries, err := os.ReadDir(sidecarDir)
+		assert.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	writeCheckpoint := func() {
+		s, err := log.Update()
+		assert.NoError(t, err)
+		assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+	}
+
+	metadata := getTestMetedata()
+	metadata.Configuration = map[string]string{DeltaConfigCheckpointPolicy.Key: CheckpointPolicyV2}
+	commitTestActions(t, log, metadata, testAddFile("a"), testAddFile("b"), testAddFile("c"))
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.UpgradeProtocol([]string{action.FeatureV2Checkpoint}, getTestEngineInfo())
+	assert.NoError(t, err)
+	writeCheckpoint()
+	expired := sidecarNames()
+	assert.Len(t, expired, 2)
+
+	// the sidecar actions describe the sidecar files just written
+	checkpoints, err := filepath.Glob(filepath.Join(logDir, "00000000000000000001.checkpoint.*.json"))
+	assert.NoError(t, err)
+	assert.Len(t, checkpoints, 1)
+	it, err := readCheckpointTopLevel(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader, "file://"+checkpoints[0])
+	assert.NoError(t, err)
+	actions, err := iter.ToSlice(it)
+	assert.NoError(t, err)
+	var numSidecars int
+	for _, a := range actions {
+		if sidecar, ok := a.(*action.Sidecar); ok {
+			info, err := os.Stat(filepath.Join(sidecarDir, sidecar.Path))
+			assert.NoError(t, err)
+			assert.Equal(t, info.Size(), sidecar.SizeInBytes)
+			assert.Equal(t, info.ModTime().UnixMilli(), sidecar.ModificationTime)
+			numSidecars++
+		}
+	}
+	assert.Equal(t, 2, numSidecars)
+
+	commitTestActions(t, log, testAddFile("d"))
+	writeCheckpoint()
+
+	// the versions before the checkpoint 2 and all the sidecar files are expired
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	expiredFiles := append([]string{filenames.DeltaFile(logDir, 0), filenames.DeltaFile(logDir, 1)}, checkpoints...)
+	for _, name := range sidecarNames() {
+		expiredFiles = append(expiredFiles, filepath.Join(sidecarDir, name))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(sidecarDir, "orphan.parquet"), []byte("orphan"), 0644))
+	expiredFiles = append(expiredFiles, filepath.Join(sidecarDir, "orphan.parquet"))
+	for _, p := range expiredFiles {
+		assert.NoError(t, os.Chtimes(p, old, old))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(sidecarDir, "new.parquet"), []byte("new"), 0644))
+	before := sidecarNames()
+
+	assert.NoError(t, cleanUpExpiredLogs(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader,
+		DeltaConfigLogRetention.fromMetadata(&action.Metadata{}), l.clock))
+
+	// the sidecars of the checkpoint 2 and the new sidecar file are kept
+	var kept []string
+	for _, name := range before {
+		if name != "orphan.parquet" && name != expired[0] && name != expired[1] {
+			kept = append(kept, name)
+		}
+	}
+	assert.Len(t, kept, 3)
+	assert.Equal(t, kept, sidecarNames())
+	_, err = os.Stat(checkpoints[0])
+	assert.True(t, os.IsNotExist(err))
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err := reloaded.Snapshot()
+	assert.NoError(t, err)
+	files, err := s.AllFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 4)
+}
+
+func TestCheckpointInstance_v2(t *testing.T) {
+	v2Path := filenames.V2CheckpointFile("", 10, "3a0d65cd-4056-49b8-937b-95f9e3ee90e5", "json")
+	assert.True(t, filenames.IsCheckpointFile(v2Path))
+	assert.True(t, filenames.IsV2CheckpointFile(v2Path))
+	assert.False(t, filenames.IsDeltaFile(v2Path))
+	assert.False(t, filenames.IsCheckpointFile("."+filenames.CheckpointFileSingular("", 10)+".crc"))
+
+	v2 := FromPath(v2Path)
+	assert.Equal(t, int64(10), v2.Version)
+	assert.True(t, v2.NumParts.IsAbsent())
+	assert.Equal(t, []string{v2Path}, v2.GetCorrespondingFiles(""))
+
+	classic := FromPath(filenames.CheckpointFileSingular("", 10))
+	assert.True(t, v2.Compare(*classic) > 0)
+	assert.True(t, classic.Compare(*v2) < 0)
+
+	latest := GetLatestCompleteCheckpointFromList([]*CheckpointInstance{classic, v2, FromPath(filenames.CheckpointFileSingular("", 5))}, MaxInstance)
+	assert.Equal(t, mo.Some(v2Path), latest.MustGet().V2Name)
+}
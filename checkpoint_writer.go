@@ -1,94 +1,234 @@
 package deltago
 
 import (
+	"context"
+	"io"
 	"log"
 
+	"github.com/barweiss/go-tuple"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util/filenames"
-	"github.com/rotisserie/eris"
+	"github.com/csimplestring/delta-go/internal/util/path"
+	"github.com/csimplestring/delta-go/iter"
 )
 
 type checkpointWriter struct {
 	schemaText string
 	pw         parquetActionWriter
+	// partSize is the maximum number of actions in a checkpoint part,
+	// a single file checkpoint is written if it is not positive or the checkpoint is small enough.
+	partSize int64
 }
 
 // The scala version passed the DeltaLog instance to infer if we need to use 'rename'
 // but here I decide to pass some Config struct in future to instantiate writer.
-func (c *checkpointWriter) write(snapshot *snapshotImp) (*CheckpointMetaDataJSON, error) {
-	checkpointSize := int64(0)
-	numOfFiles := int64(0)
-
-	path := filenames.CheckpointFileSingular(snapshot.path, snapshot.version)
-
+// The actions are streamed from the log replay into the parts. As the number of parts is only known at the end,
+// the parts are written under temp names first, a new part is started after every partSize actions,
+// then they are renamed to the names with the number of parts.
+func (c *checkpointWriter) write(ctx context.Context, snapshot *snapshotImp) (*CheckpointMetaDataJSON, error) {
 	// exclude CommitInfo and CDC
-	actions, err := c.extractActions(snapshot)
-	if err != nil {
-		return nil, err
-	}
+	actions := newCheckpointActionIterator(ctx, snapshot)
+	defer actions.Close()
 
-	if err := c.pw.Open(path, c.schemaText); err != nil {
-		return nil, err
+	if c.partSize <= 0 {
+		n, err := c.writePart(ctx, filenames.CheckpointFileSingular(snapshot.path, snapshot.version), actions, -1)
+		if err != nil {
+			return nil, err
+		}
+		return checkpointMetadata(snapshot.version, n, 1), nil
 	}
 
-	for _, action := range actions {
-		if err := c.pw.Write(action); err != nil {
+	var tempPaths []string
+	checkpointSize := int64(0)
+	for {
+		tempPath := filenames.CheckpointFileSingular(snapshot.path, snapshot.version) + "." + uuid.NewString() + ".tmp"
+		tempPaths = append(tempPaths, tempPath)
+		n, err := c.writePart(ctx, tempPath, actions, c.partSize)
+		if err != nil {
+			c.deleteTempParts(ctx, tempPaths)
 			return nil, err
 		}
-		checkpointSize++
-		if action.Add != nil {
-			numOfFiles++
+		checkpointSize += n
+		if n < c.partSize {
+			break
+		}
+		// the next part is only started if there are more actions
+		more, err := actions.hasNext()
+		if err != nil {
+			c.deleteTempParts(ctx, tempPaths)
+			return nil, err
+		}
+		if !more {
+			break
 		}
-	}
-	if err := c.pw.Close(); err != nil {
-		return nil, err
 	}
 
-	if n, err := snapshot.numOfFiles(); err == nil {
-		if n != numOfFiles {
-			return nil, errno.IllegalStateError("State of the checkpoint doesn't match that of the snapshot.")
+	numParts := len(tempPaths)
+	var paths []string
+	if numParts > 1 {
+		paths = filenames.CheckpointFileWithParts(snapshot.path, snapshot.version, numParts)
+	} else {
+		paths = []string{filenames.CheckpointFileSingular(snapshot.path, snapshot.version)}
+	}
+	for i, path := range paths {
+		if err := c.pw.Rename(ctx, tempPaths[i], path); err != nil {
+			c.deleteTempParts(ctx, tempPaths[i:])
+			return nil, err
 		}
 	}
+
+	return checkpointMetadata(snapshot.version, checkpointSize, numParts), nil
+}
+
+func checkpointMetadata(version int64, checkpointSize int64, numParts int) *CheckpointMetaDataJSON {
 	if checkpointSize == 0 {
 		log.Println("Attempted to write an empty checkpoint without any actions. ")
 	}
 
-	return &CheckpointMetaDataJSON{Version: snapshot.version, Size: checkpointSize}, nil
+	res := &CheckpointMetaDataJSON{Version: version, Size: checkpointSize}
+	if numParts > 1 {
+		res.Parts = &numParts
+	}
+	return res
 }
 
-func (c *checkpointWriter) extractActions(snapshot *snapshotImp) ([]*action.SingleAction, error) {
-	var actions []*action.SingleAction
+// deleteTempParts deletes the temp parts of a failed checkpoint, an incomplete checkpoint is never used by the readers.
+func (c *checkpointWriter) deleteTempParts(ctx context.Context, tempPaths []string) {
+	for _, p := range tempPaths {
+		if err := c.pw.Delete(ctx, p); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+			log.Println("Failed to delete the temp checkpoint part " + p + ". " + err.Error())
+		}
+	}
+}
 
-	// protocol and metadata
-	protocolAndMetadata, err := snapshot.protocolAndMetadata.Get()
-	if err != nil {
-		return nil, eris.Wrap(err, "")
+// writePart writes at most limit actions into the file at path, all the remaining actions are written if limit is negative.
+func (c *checkpointWriter) writePart(ctx context.Context, path string, actions iter.Iter[*action.SingleAction], limit int64) (int64, error) {
+	if err := c.pw.Open(ctx, path, c.schemaText); err != nil {
+		return 0, err
 	}
-	actions = append(actions, protocolAndMetadata.V2.Wrap(), protocolAndMetadata.V1.Wrap())
 
-	// transaction
-	for _, trx := range snapshot.setTransactions() {
-		actions = append(actions, trx.Wrap())
+	n := int64(0)
+	for limit < 0 || n < limit {
+		a, err := actions.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := c.pw.Write(a); err != nil {
+			return 0, err
+		}
+		n++
 	}
 
-	// addFile
-	addFiles, err := snapshot.AllFiles()
-	if err != nil {
-		return nil, err
+	if err := c.pw.Close(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// checkpointActionIterator streams the actions of the snapshot to checkpoint: the protocol, the metadata,
+// the latest transaction of each app, the active files and the unexpired tombstones.
+// The log is replayed in reverse, only the keys of the files already seen are kept in memory.
+type checkpointActionIterator struct {
+	replay                    iter.Iter[*replayTuple]
+	head                      []*action.SingleAction
+	storeType                 string
+	minFileRetentionTimestamp int64
+	// files are keyed by (canonical path, deletion vector unique id)
+	seenFiles mapset.Set[tuple.T2[string, string]]
+	seenApps  mapset.Set[string]
+}
+
+var _ iter.Iter[*action.SingleAction] = &checkpointActionIterator{}
+
+func newCheckpointActionIterator(ctx context.Context, snapshot *snapshotImp) *checkpointActionIterator {
+	return &checkpointActionIterator{
+		replay:                    snapshot.memoryOptimizedLogReplay.GetReverseIterator(ctx),
+		head:                      []*action.SingleAction{snapshot.protocolAndMetadata.V2.Wrap(), snapshot.protocolAndMetadata.V1.Wrap()},
+		storeType:                 snapshot.config.StoreType,
+		minFileRetentionTimestamp: snapshot.minFileRetentionTimestamp,
+		seenFiles:                 mapset.NewThreadUnsafeSet[tuple.T2[string, string]](),
+		seenApps:                  mapset.NewThreadUnsafeSet[string](),
+	}
+}
+
+func (c *checkpointActionIterator) Next() (*action.SingleAction, error) {
+	if len(c.head) > 0 {
+		a := c.head[0]
+		c.head = c.head[1:]
+		return a, nil
 	}
-	for _, f := range addFiles {
-		actions = append(actions, f.Wrap())
+
+	for {
+		rt, err := c.replay.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch a := rt.act.(type) {
+		case *action.SetTransaction:
+			if !c.seenApps.Contains(a.AppId) {
+				c.seenApps.Add(a.AppId)
+				return a.Wrap(), nil
+			}
+
+		case *action.AddFile:
+			canonicalPath, latest, err := c.seeFile(a.Path, a.DeletionVectorUniqueId())
+			if err != nil {
+				return nil, err
+			}
+			if latest {
+				return a.Copy(false, canonicalPath).Wrap(), nil
+			}
+
+		case *action.RemoveFile:
+			canonicalPath, latest, err := c.seeFile(a.Path, a.DeletionVectorUniqueId())
+			if err != nil {
+				return nil, err
+			}
+			// an expired tombstone is not written, but it still hides the older actions of the file
+			if latest && a.DelTimestamp() > c.minFileRetentionTimestamp {
+				return a.Copy(false, canonicalPath).Wrap(), nil
+			}
+		}
 	}
+}
 
-	// removeFile
-	removeFiles, err := snapshot.tombstones()
+// seeFile returns the canonical path of the file and true if it is the first time the file is seen in the reverse replay,
+// i.e. the action is the latest one of the file.
+func (c *checkpointActionIterator) seeFile(p string, dvID string) (string, bool, error) {
+	canonicalPath, err := path.Canonicalize(p, c.storeType)
 	if err != nil {
-		return nil, err
+		return "", false, err
 	}
-	for _, f := range removeFiles {
-		actions = append(actions, f.Wrap())
+	key := tuple.New2(canonicalPath, dvID)
+	if c.seenFiles.Contains(key) {
+		return canonicalPath, false, nil
 	}
+	c.seenFiles.Add(key)
+	return canonicalPath, true, nil
+}
+
+// hasNext returns whether there are more actions, the next action is kept to be returned by Next.
+func (c *checkpointActionIterator) hasNext() (bool, error) {
+	a, err := c.Next()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c.head = append([]*action.SingleAction{a}, c.head...)
+	return true, nil
+}
 
-	return actions, nil
+func (c *checkpointActionIterator) Close() error {
+	return c.replay.Close()
 }
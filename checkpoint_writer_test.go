@@ -0,0 +1,122 @@
+package deltago
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestCheckpoint_multi_part(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	tt.config.CheckpointPartSize = 3
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	now := time.Now().UnixMilli()
+	expired := time.Now().Add(-30 * 24 * time.Hour).UnixMilli()
+
+	commitTestActions(t, log, getTestMetedata(), testAddFile("a"), testAddFile("b"), testAddFile("c"), testAddFile("d"), testAddFile("x"))
+	commitTestActions(t, log, &action.SetTransaction{AppId: "app", Version: 1}, testAddFile("e"),
+		&action.RemoveFile{Path: "a", DeletionTimestamp: &now, DataChange: true},
+		&action.RemoveFile{Path: "x", DeletionTimestamp: &expired, DataChange: true})
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	// metadata, protocol, txn, b, c, d, e and the tombstone of a, the tombstone of x is expired
+	lc, err := LastCheckpoint(context.Background(), l.store)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lc.MustGet().Version)
+	assert.Equal(t, int64(8), lc.MustGet().Size)
+	assert.Equal(t, 3, *lc.MustGet().Parts)
+	assertNoTempCheckpointParts(t, l)
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, filenames.CheckpointFileWithParts("", 1, 3), checkpointNames(s))
+	assert.Empty(t, s.(*snapshotImp).logSegment.Deltas)
+
+	files, err := s.AllFiles()
+	assert.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		assert.False(t, f.DataChange)
+	}
+	sort.Strings(paths)
+	assert.Equal(t, []string{"b", "c", "d", "e"}, paths)
+
+	tombstones, err := s.(*snapshotImp).tombstones(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, tombstones, 1)
+	assert.Equal(t, "a", tombstones[0].Path)
+
+	trx, err := reloaded.StartTransaction()
+	assert.NoError(t, err)
+	v, err := trx.TxnVersion("app")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+}
+
+func TestCheckpoint_single_part_when_small_enough(t *testing.T) {
+	// the checkpoint has 3 actions
+	for _, partSize := range []int64{100, 3} {
+		testCheckpointSinglePart(t, partSize)
+	}
+}
+
+func testCheckpointSinglePart(t *testing.T, partSize int64) {
+	tt := newTestLogCases("file")[0]
+	tt.config.CheckpointPartSize = partSize
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{getTestMetedata(),
+		&action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true}}),
+		getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	lc, err := LastCheckpoint(context.Background(), l.store)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), lc.MustGet().Size)
+	assert.Nil(t, lc.MustGet().Parts)
+	assertNoTempCheckpointParts(t, l)
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filenames.CheckpointFileSingular("", 0)}, checkpointNames(s))
+}
+
+func assertNoTempCheckpointParts(t *testing.T, l *logImpl) {
+	entries, err := os.ReadDir(strings.TrimPrefix(l.logPath, "file://"))
+	assert.NoError(t, err)
+	for _, e := range entries {
+		assert.False(t, strings.HasSuffix(e.Name(), ".tmp"), e.Name())
+	}
+}
@@ -7,12 +7,39 @@ import (
 
 	"github.com/barweiss/go-tuple"
 	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/store"
+	"github.com/samber/mo"
 	duration "github.com/xhit/go-str2duration/v2"
 )
 
 type Config struct {
 	//StorageConfig StorageConfig
 	StoreType string
+	// DisableRetentionDurationCheck allows Vacuum to run with a retention shorter than the table's deletedFileRetentionDuration.
+	// Use with care, files still needed by concurrent readers or writers may be deleted.
+	DisableRetentionDurationCheck bool
+	// CommitLockStore enables concurrent writers from multiple processes on S3,
+	// all the writers of a table must use the same CommitLockStore.
+	CommitLockStore store.CommitLockStore
+	// S3ConditionalWrite enables concurrent writers from multiple processes on S3 by conditional writes (If-None-Match),
+	// the S3 (compatible) store must support it. It is ignored if CommitLockStore is set.
+	S3ConditionalWrite bool
+	// CheckpointPartSize is the maximum number of actions in a checkpoint file. If a checkpoint has more actions,
+	// it is split into multiple parts. A single file checkpoint is always written if it is 0.
+	CheckpointPartSize int64
+	// V2CheckpointFormat is the format of the top level file of V2 checkpoints, either "json" (the default) or "parquet".
+	// V2 checkpoints are only written if the table supports the v2Checkpoint feature and its delta.checkpointPolicy is "v2",
+	// the sidecar files are split by CheckpointPartSize as well.
+	V2CheckpointFormat string
+	// ReplayConcurrency is the number of the delta files and checkpoint parts which are fetched and decoded in parallel
+	// when loading a snapshot. The actions are still replayed in the order of the versions.
+	// The files are read one after another if it is 0 or 1. Up to ReplayConcurrency decoded files are held in memory at once,
+	// except by the memory optimized replay of scans and checkpoints, which only reads the delta files in parallel
+	// and streams the checkpoint parts.
+	ReplayConcurrency int
+	// RetryPolicy controls the retries of the commits after concurrent commits win, DefaultRetryPolicy() if it is absent.
+	// It can be overridden on a transaction by SetRetryPolicy.
+	RetryPolicy mo.Option[RetryPolicy]
 }
 
 // DeltaConfig
@@ -52,7 +79,13 @@ func parseDuration(s string) time.Duration {
 		panic("this is not a valid duration starting with " + fields[0])
 	}
 
-	d, err := duration.ParseDuration(fields[1] + timeDurationUnits[fields[2]])
+	// both singular and plural units are allowed, e.g. "interval 1 day" and "interval 30 days"
+	unit, ok := timeDurationUnits[strings.TrimSuffix(fields[2], "s")]
+	if !ok {
+		panic("unsupported duration unit " + fields[2])
+	}
+
+	d, err := duration.ParseDuration(fields[1] + unit)
 	if err != nil {
 		panic(err)
 	}
@@ -97,6 +130,34 @@ var DeltaConfigIsAppendOnly = &TableConfig[bool]{
 	},
 }
 
+var DeltaConfigEnableChangeDataFeed = &TableConfig[bool]{
+	Key:          "delta.enableChangeDataFeed",
+	DefaultValue: "false",
+	FromString: func(s string) bool {
+		return strings.ToLower(s) == "true"
+	},
+}
+
+var DeltaConfigCheckpointWriteStatsAsStruct = &TableConfig[bool]{
+	Key:          "delta.checkpoint.writeStatsAsStruct",
+	DefaultValue: "false",
+	FromString: func(s string) bool {
+		return strings.ToLower(s) == "true"
+	},
+}
+
+// The values of DeltaConfigCheckpointPolicy.
+const (
+	CheckpointPolicyClassic = "classic"
+	CheckpointPolicyV2      = "v2"
+)
+
+var DeltaConfigCheckpointPolicy = &TableConfig[string]{
+	Key:          "delta.checkpointPolicy",
+	DefaultValue: CheckpointPolicyClassic,
+	FromString:   strings.ToLower,
+}
+
 type tableConfigurations []*tuple.T2[string, string]
 
 func mergeGlobalTableConfigurations(confs tableConfigurations, tableConf map[string]string) map[string]string {
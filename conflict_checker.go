@@ -1,6 +1,11 @@
 package deltago
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util"
@@ -9,6 +14,7 @@ import (
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/csimplestring/delta-go/store"
 	"github.com/csimplestring/delta-go/types"
+
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/repeale/fp-go"
 	"github.com/samber/mo"
@@ -74,13 +80,13 @@ type conflictChecker struct {
 	winningCommitSummary   *winningCommitSummary
 }
 
-func newConflictChecker(currentTransactionInfo *currentTransactionInfo, winningCommitVersion int64, isolationLevel isolation.Level) (*conflictChecker, error) {
+func newConflictChecker(ctx context.Context, currentTransactionInfo *currentTransactionInfo, winningCommitVersion int64, isolationLevel isolation.Level) (*conflictChecker, error) {
 	c := &conflictChecker{
 		currentTransactionInfo: currentTransactionInfo,
 		winningCommitVersion:   winningCommitVersion,
 		isolationLevel:         isolationLevel,
 	}
-	w, err := c.createWinningCommitSummary()
+	w, err := c.createWinningCommitSummary(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -89,9 +95,9 @@ func newConflictChecker(currentTransactionInfo *currentTransactionInfo, winningC
 }
 
 // must call it when crreating conflictChecker
-func (c *conflictChecker) createWinningCommitSummary() (*winningCommitSummary, error) {
+func (c *conflictChecker) createWinningCommitSummary(ctx context.Context) (*winningCommitSummary, error) {
 	trx := c.currentTransactionInfo
-	siter, err := trx.logStore.Read(filenames.DeltaFile(trx.logPath, c.winningCommitVersion))
+	siter, err := trx.logStore.Read(ctx, filenames.DeltaFile(trx.logPath, c.winningCommitVersion))
 	if err != nil {
 		return nil, err
 	}
@@ -137,7 +143,7 @@ func (c *conflictChecker) checkProtocolCompatibility() error {
 	}
 	for _, a := range c.currentTransactionInfo.actions {
 		if _, ok := a.(*action.Protocol); ok {
-			return errno.ProtocolChangedException("")
+			return &errno.ProtocolChangedError{Conflict: c.conflict()}
 		}
 	}
 	return nil
@@ -145,16 +151,17 @@ func (c *conflictChecker) checkProtocolCompatibility() error {
 
 func (c *conflictChecker) checkNoMetadataUpdates() error {
 	if len(c.winningCommitSummary.metadataUpdates) != 0 {
-		return errno.MetadataChangedError()
+		return &errno.MetadataChangedError{Conflict: c.conflict()}
 	}
 	return nil
 }
 
-func filterFileList(paritionSchema *types.StructType, files []*action.AddFile, filter types.Expression) ([]*action.AddFile, error) {
+func filterFileList(paritionSchema *types.StructType, physicalNames map[string]string,
+	files []*action.AddFile, filter types.Expression) ([]*action.AddFile, error) {
 
 	var res []*action.AddFile
 	for _, file := range files {
-		r := &PartitionRowRecord{partitionSchema: paritionSchema, partitionValues: file.PartitionValues}
+		r := &PartitionRowRecord{partitionSchema: paritionSchema, partitionValues: file.PartitionValues, physicalNames: physicalNames}
 		v, err := filter.Eval(r)
 		if err != nil {
 			return nil, err
@@ -176,14 +183,24 @@ func (c *conflictChecker) checkForAddedFilesThatShouldHaveBeenReadByCurrentTxn()
 	if err != nil {
 		return err
 	}
+	physicalNames, err := columnMappingPhysicalNames(c.currentTransactionInfo.metadata)
+	if err != nil {
+		return err
+	}
 
 	for _, p := range c.currentTransactionInfo.readPredicates {
-		files, err := filterFileList(mSchema, addedFilesToCheckForConflicts, p)
+		files, err := filterFileList(mSchema, physicalNames, addedFilesToCheckForConflicts, p)
 		if err != nil {
 			return err
 		}
 		if len(files) != 0 {
-			return errno.ConcurrentAppend("")
+			return &errno.ConcurrentAppendError{
+				Conflict:  c.conflict(),
+				Partition: prettyPartition(c.currentTransactionInfo.metadata, physicalNames, files[0].PartitionValues),
+				Files: fp.Map(func(t *action.AddFile) string {
+					return t.Path
+				})(files),
+			}
 		}
 	}
 
@@ -196,18 +213,20 @@ func (c *conflictChecker) checkForDeletedFilesAgainstCurrentTxnReadFiles() error
 		readFilePaths[f.Path] = f.PartitionValues
 	}
 
-	var deleteReadOverlap *action.RemoveFile
+	var deleteReadOverlap []string
 	for _, f := range c.winningCommitSummary.removedFiles {
 		if _, ok := readFilePaths[f.Path]; ok {
-			deleteReadOverlap = f
+			deleteReadOverlap = append(deleteReadOverlap, f.Path)
 		}
 	}
 
-	if deleteReadOverlap != nil {
-		return errno.ConcurrentDeleteRead(deleteReadOverlap.Path)
+	if len(deleteReadOverlap) == 0 && c.currentTransactionInfo.readWholeTable {
+		deleteReadOverlap = fp.Map(func(t *action.RemoveFile) string {
+			return t.Path
+		})(c.winningCommitSummary.removedFiles)
 	}
-	if len(c.winningCommitSummary.removedFiles) != 0 && c.currentTransactionInfo.readWholeTable {
-		return errno.ConcurrentDeleteRead(c.winningCommitSummary.removedFiles[0].Path)
+	if len(deleteReadOverlap) != 0 {
+		return &errno.ConcurrentDeleteReadError{Conflict: c.conflict(), Files: deleteReadOverlap}
 	}
 	return nil
 }
@@ -225,8 +244,9 @@ func (c *conflictChecker) checkForDeletedFilesAgainstCurrentTxnDeletedFiles() er
 
 	deleteOverlap := winningRemovedPaths.Intersect(txnDeletes)
 	if deleteOverlap.Cardinality() != 0 {
-		file, _ := deleteOverlap.Pop()
-		return errno.ConcurrentDeleteDelete(file)
+		files := deleteOverlap.ToSlice()
+		sort.Strings(files)
+		return &errno.ConcurrentDeleteDeleteError{Conflict: c.conflict(), Files: files}
 	}
 	return nil
 }
@@ -236,22 +256,80 @@ func (c *conflictChecker) checkForUpdatedApplicationTransactionIdsThatCurrentTxn
 		return t.AppId
 	})(c.winningCommitSummary.appLevelTransactions)
 
-	if mapset.NewSet(appIds...).Intersect(c.currentTransactionInfo.readAppIds).Cardinality() != 0 {
-		return &errno.ConcurrentTransactionError{}
+	appIdsOverlap := mapset.NewSet(appIds...).Intersect(c.currentTransactionInfo.readAppIds)
+	if appIdsOverlap.Cardinality() != 0 {
+		ids := appIdsOverlap.ToSlice()
+		sort.Strings(ids)
+		return &errno.ConcurrentTransactionError{Conflict: c.conflict(), AppIds: ids}
 	}
 	return nil
 }
 
+// conflict describes the winning commit in the conflict errors.
+func (c *conflictChecker) conflict() errno.Conflict {
+	conflict := errno.Conflict{WinningVersion: c.winningCommitVersion}
+	if commitInfo, ok := c.winningCommitSummary.commitInfo.Get(); ok {
+		conflict.WinningCommitInfo = &errno.CommitInfo{
+			Version:             c.winningCommitVersion,
+			Timestamp:           commitInfo.Timestamp,
+			Operation:           commitInfo.Operation,
+			OperationParameters: commitInfo.OperationParameters,
+			ReadVersion:         commitInfo.ReadVersion,
+			IsolationLevel:      commitInfo.IsolationLevel,
+			IsBlindAppend:       commitInfo.IsBlindAppend,
+		}
+	}
+	return conflict
+}
+
+// prettyPartition describes the partition of the partitionValues in the conflict errors.
+func prettyPartition(metadata *action.Metadata, physicalNames map[string]string, partitionValues map[string]string) string {
+	if len(metadata.PartitionColumns) == 0 {
+		return "the root of the table"
+	}
+	values := make([]string, 0, len(metadata.PartitionColumns))
+	for _, column := range metadata.PartitionColumns {
+		key := column
+		if name, ok := physicalNames[column]; ok {
+			key = name
+		}
+		value, ok := partitionValues[key]
+		if !ok {
+			value = "null"
+		}
+		values = append(values, fmt.Sprintf("%s=%s", column, value))
+	}
+	return "partition [" + strings.Join(values, ", ") + "]"
+}
+
+// assertProtocolRead checks that all the reader features of the protocol can be read.
 func assertProtocolRead(protocol *action.Protocol) error {
-	if protocol != nil && action.ReaderVersion < protocol.MinReaderVersion {
+	if protocol == nil {
+		return nil
+	}
+	if protocol.MinReaderVersion > action.TableFeaturesReaderVersion {
 		return errno.InvalidProtocolVersionError()
 	}
+	for _, name := range protocol.ReaderFeatureNames() {
+		if f, ok := action.GetTableFeature(name); !ok || !f.ReadSupported {
+			return errno.UnsupportedReaderFeatureError(name)
+		}
+	}
 	return nil
 }
 
+// assertProtocolWrite checks that all the writer features of the protocol can be written.
 func assertProtocolWrite(protocol *action.Protocol) error {
-	if protocol != nil && action.WriterVersion < protocol.MinWriterVersion {
+	if protocol == nil {
+		return nil
+	}
+	if protocol.MinWriterVersion > action.TableFeaturesWriterVersion {
 		return errno.InvalidProtocolVersionError()
 	}
+	for _, name := range protocol.WriterFeatureNames() {
+		if f, ok := action.GetTableFeature(name); !ok || !f.WriteSupported {
+			return errno.UnsupportedWriterFeatureError(name)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,140 @@
+package deltago
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rotisserie/eris"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestConflictChecker_conflict_errors(t *testing.T) {
+	f := newTrxTestFixture()
+
+	// conflict commits the setup actions one by one, then the winning actions after the transaction reads,
+	// and returns the error of the transaction
+	conflict := func(t *testing.T, setup []action.Action, read func(OptimisticTransaction), winning []action.Action, actions []action.Action) error {
+		tt := newTestLogCases("file")[0]
+		defer tt.clean()
+
+		log, err := tt.getTempLog()
+		assert.NoError(t, err)
+		setUpTestTrxLog(setup, log, f, t)
+
+		trx, err := log.StartTransaction()
+		assert.NoError(t, err)
+		read(trx)
+
+		winner, err := log.StartTransaction()
+		assert.NoError(t, err)
+		_, err = winner.Commit(iter.FromSlice(winning), f.op, f.engineInfo)
+		assert.NoError(t, err)
+
+		_, err = trx.Commit(iter.FromSlice(actions), f.op, f.engineInfo)
+		assert.True(t, eris.Is(err, errno.ErrConcurrentModification))
+		return err
+	}
+	assertWinner := func(t *testing.T, c errno.Conflict, version int64) {
+		assert.Equal(t, version, c.WinningVersion)
+		assert.NotNil(t, c.WinningCommitInfo)
+		assert.Equal(t, version, c.WinningCommitInfo.Version)
+		assert.Equal(t, f.op.Name.String(), c.WinningCommitInfo.Operation)
+		assert.NotZero(t, c.WinningCommitInfo.Timestamp)
+		assert.NotNil(t, c.WinningCommitInfo.ReadVersion)
+	}
+	readX1 := func(trx OptimisticTransaction) {
+		_, err := trx.MarkFilesAsRead(f.colXEq1Filter)
+		assert.NoError(t, err)
+	}
+
+	t.Run("concurrent append", func(t *testing.T) {
+		err := conflict(t, []action.Action{f.metadata_partX}, readX1, []action.Action{f.addA_partX1}, []action.Action{f.addB_partX1})
+		var e *errno.ConcurrentAppendError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 1)
+		assert.Equal(t, "partition [x=1]", e.Partition)
+		assert.Equal(t, []string{"a"}, e.Files)
+		assert.Contains(t, err.Error(), "Files were added to partition [x=1] by a concurrent update.")
+		assert.Contains(t, err.Error(), "Conflicting commit: {")
+	})
+
+	t.Run("concurrent delete read", func(t *testing.T) {
+		err := conflict(t, []action.Action{f.metadata_partX, f.addA_partX1}, readX1, []action.Action{f.removeA}, []action.Action{})
+		var e *errno.ConcurrentDeleteReadError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 2)
+		assert.Equal(t, []string{"a"}, e.Files)
+		assert.Contains(t, err.Error(), "attempted to read one or more files that were deleted (for example a)")
+	})
+
+	t.Run("concurrent delete delete", func(t *testing.T) {
+		err := conflict(t, []action.Action{f.metadata_colXY, f.addA}, func(trx OptimisticTransaction) {},
+			[]action.Action{f.removeA}, []action.Action{f.removeA_time5})
+		var e *errno.ConcurrentDeleteDeleteError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 2)
+		assert.Equal(t, []string{"a"}, e.Files)
+		assert.Contains(t, err.Error(), "attempted to delete one or more files that were deleted (for example a)")
+	})
+
+	t.Run("metadata changed", func(t *testing.T) {
+		err := conflict(t, []action.Action{f.metadata_colXY}, func(trx OptimisticTransaction) {},
+			[]action.Action{f.metadata_partX}, []action.Action{})
+		var e *errno.MetadataChangedError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 1)
+	})
+
+	t.Run("protocol changed", func(t *testing.T) {
+		protocol := &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 2}
+		err := conflict(t, []action.Action{f.metadata_colXY, protocol}, func(trx OptimisticTransaction) {},
+			[]action.Action{protocol}, []action.Action{protocol})
+		var e *errno.ProtocolChangedError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 2)
+		assert.Contains(t, err.Error(), "The protocol version of the Delta table has been changed by a concurrent update.")
+	})
+
+	t.Run("concurrent transaction", func(t *testing.T) {
+		err := conflict(t, []action.Action{f.metadata_colXY}, func(trx OptimisticTransaction) {
+			_, err := trx.TxnVersion("t1")
+			assert.NoError(t, err)
+		}, []action.Action{&action.SetTransaction{AppId: "t1", LastUpdated: util.PtrOf[int64](1234)}}, []action.Action{})
+		var e *errno.ConcurrentTransactionError
+		assert.True(t, errors.As(err, &e))
+		assertWinner(t, e.Conflict, 1)
+		assert.Equal(t, []string{"t1"}, e.AppIds)
+	})
+}
+
+func TestConflictChecker_messages(t *testing.T) {
+	f := newTrxTestFixture()
+	assert.Equal(t, "the root of the table", prettyPartition(f.metadata_colXY, nil, map[string]string{}))
+	assert.Equal(t, "partition [x=1]", prettyPartition(f.metadata_partX, nil, map[string]string{"x": "1"}))
+	assert.Equal(t, "partition [x=2]", prettyPartition(f.metadata_partX, map[string]string{"x": "col-1"}, map[string]string{"col-1": "2"}))
+	assert.Equal(t, "partition [x=null]", prettyPartition(f.metadata_partX, nil, map[string]string{}))
+
+	err := error(&errno.MetadataChangedError{})
+	assert.Equal(t, "The metadata of the Delta table has been changed by a concurrent update. Please try the operation again.", err.Error())
+	assert.True(t, errors.Is(err, errno.ErrConcurrentModification))
+	assert.Contains(t, (&errno.ProtocolChangedError{}).Error(), "multiple writers are writing to an empty directory")
+
+	// the deprecated constructors return the typed errors
+	var appendErr *errno.ConcurrentAppendError
+	assert.True(t, errors.As(errno.ConcurrentAppend("partition [x=1]"), &appendErr))
+	assert.Equal(t, "partition [x=1]", appendErr.Partition)
+	var deleteErr *errno.ConcurrentDeleteReadError
+	assert.True(t, errors.As(errno.ConcurrentDeleteRead("a"), &deleteErr))
+	assert.Equal(t, []string{"a"}, deleteErr.Files)
+	var protocolErr *errno.ProtocolChangedError
+	err = errno.ProtocolChangedException("protocol changed")
+	assert.True(t, errors.As(err, &protocolErr))
+	assert.True(t, eris.Is(err, errno.ErrConcurrentModification))
+	assert.True(t, errors.Is(errno.ConcurrentDeleteDelete("a"), errno.ErrConcurrentModification))
+	assert.True(t, errors.Is(errno.ConcurrentTransaction(), errno.ErrConcurrentModification))
+}
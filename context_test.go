@@ -0,0 +1,65 @@
+package deltago
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestLog_context_canceled(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{getTestMetedata(), testAddFile("a"), testAddFile("b")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assertCanceled := func(err error) {
+		assert.ErrorIs(t, err, errno.ErrCanceled)
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+
+	_, err = log.UpdateContext(canceled)
+	assertCanceled(err)
+
+	_, err = log.ChangesContext(canceled, 0, false)
+	assertCanceled(err)
+
+	_, err = log.CommitInfoAtContext(canceled, 0)
+	assertCanceled(err)
+
+	// the cancellation is not cached, the snapshot can be used with another context
+	s, err := log.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), s.Version())
+	_, err = s.AllFilesContext(canceled)
+	assertCanceled(err)
+	files, err := s.AllFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// nothing is committed if the context is canceled before the commit
+	trx, err = log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.CommitContext(canceled, iter.FromSlice([]action.Action{testAddFile("c")}), getTestManualUpdate(), getTestEngineInfo())
+	assertCanceled(err)
+
+	s, err = log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), s.Version())
+
+	_, err = log.StartTransactionContext(canceled)
+	assertCanceled(err)
+}
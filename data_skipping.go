@@ -0,0 +1,474 @@
+package deltago
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util"
+	expr "github.com/csimplestring/delta-go/types"
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+	"github.com/shopspring/decimal"
+)
+
+// The field names of the column statistics in AddFile.Stats.
+const (
+	statsNumRecords  = "numRecords"
+	statsMinValues   = "minValues"
+	statsMaxValues   = "maxValues"
+	statsNullCount   = "nullCount"
+	statsTightBounds = "tightBounds"
+)
+
+// fileStats is the parsed form of AddFile.Stats, e.g.
+// {"numRecords":10,"minValues":{"a":1},"maxValues":{"a":5},"nullCount":{"a":0}}
+type fileStats struct {
+	NumRecords *int64         `json:"numRecords,omitempty"`
+	MinValues  map[string]any `json:"minValues,omitempty"`
+	MaxValues  map[string]any `json:"maxValues,omitempty"`
+	NullCount  map[string]any `json:"nullCount,omitempty"`
+	// TightBounds is false if the min and max values might be wider than the actual values, e.g. after deleting rows by deletion vectors
+	TightBounds *bool `json:"tightBounds,omitempty"`
+}
+
+func parseFileStats(s string) (*fileStats, error) {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+
+	stats := &fileStats{}
+	if err := d.Decode(stats); err != nil {
+		return nil, errno.JsonUnmarshalError(err)
+	}
+	return stats, nil
+}
+
+// addFileStats returns the statistics of the file. The typed statistics read from a checkpoint are preferred,
+// so that the json statistics are not parsed. It returns false if the file has no valid statistics.
+func addFileStats(addFile *action.AddFile) (*fileStats, bool) {
+	if p := addFile.StatsParsed; p != nil {
+		return &fileStats{
+			NumRecords:  p.NumRecords,
+			MinValues:   p.MinValues,
+			MaxValues:   p.MaxValues,
+			NullCount:   p.NullCount,
+			TightBounds: p.TightBounds,
+		}, true
+	}
+	if len(addFile.Stats) == 0 {
+		return nil, false
+	}
+	stats, err := parseFileStats(addFile.Stats)
+	if err != nil {
+		return nil, false
+	}
+	return stats, true
+}
+
+// dataSkippingFilter is built from the data predicates of a scan. The predicates are rewritten into
+// predicates on the column statistics, which are false only if no row of a file can satisfy the original predicates.
+type dataSkippingFilter struct {
+	// the conjuncts of the data predicate which are used for skipping
+	used mo.Option[expr.Expression]
+	// the rewritten predicate on the column statistics
+	statsPredicate mo.Option[expr.Expression]
+	schema         *expr.StructType
+	// the physical names of the columns which are the keys of the statistics in column mapping mode
+	physicalNames map[string]string
+}
+
+func newDataSkippingFilter(dataConjunction mo.Option[expr.Expression], schema *expr.StructType, physicalNames map[string]string) *dataSkippingFilter {
+	f := &dataSkippingFilter{
+		used:           mo.None[expr.Expression](),
+		statsPredicate: mo.None[expr.Expression](),
+		schema:         schema,
+		physicalNames:  physicalNames,
+	}
+	if dataConjunction.IsAbsent() {
+		return f
+	}
+
+	var used, rewritten []expr.Expression
+	for _, p := range util.SplitConjunctivePredicates(dataConjunction.MustGet()) {
+		if r, ok := f.rewrite(p).Get(); ok {
+			used = append(used, p)
+			rewritten = append(rewritten, r)
+		}
+	}
+	if len(used) != 0 {
+		f.used = mo.Some(conjunction(used))
+		f.statsPredicate = mo.Some(conjunction(rewritten))
+	}
+	return f
+}
+
+// mightMatch returns false only if the file surely has no row satisfying the data predicate.
+// A file without (valid) statistics might always match.
+func (f *dataSkippingFilter) mightMatch(addFile *action.AddFile) bool {
+	if f.statsPredicate.IsAbsent() {
+		return true
+	}
+	stats, ok := addFileStats(addFile)
+	if !ok {
+		return true
+	}
+	return evalStatsPredicate(f.statsPredicate.MustGet(), &statsRowRecord{schema: f.schema, stats: stats, physicalNames: f.physicalNames})
+}
+
+// rewrite converts a data predicate into a predicate on the column statistics.
+// It returns None if (part of) the predicate is not supported.
+func (f *dataSkippingFilter) rewrite(e expr.Expression) mo.Option[expr.Expression] {
+	switch v := e.(type) {
+	case *expr.And:
+		return f.rewriteBoth(v.Left, v.Right, func(l, r expr.Expression) expr.Expression { return expr.NewAnd(l, r) })
+	case *expr.Or:
+		return f.rewriteBoth(v.Left, v.Right, func(l, r expr.Expression) expr.Expression { return expr.NewOr(l, r) })
+	case *expr.Not:
+		return f.rewriteNot(v.Child)
+	case *expr.IsNull:
+		return f.rewriteNullCheck(v.Child, true)
+	case *expr.IsNotNull:
+		return f.rewriteNullCheck(v.Child, false)
+	case *expr.EqualTo:
+		return f.rewriteComparison(v.Left, v.Right, "=")
+	case *expr.Lt:
+		return f.rewriteComparison(v.Left, v.Right, "<")
+	case *expr.Lte:
+		return f.rewriteComparison(v.Left, v.Right, "<=")
+	case *expr.Gt:
+		return f.rewriteComparison(v.Left, v.Right, ">")
+	case *expr.Gte:
+		return f.rewriteComparison(v.Left, v.Right, ">=")
+	}
+	return mo.None[expr.Expression]()
+}
+
+func (f *dataSkippingFilter) rewriteBoth(l expr.Expression, r expr.Expression,
+	combine func(l, r expr.Expression) expr.Expression) mo.Option[expr.Expression] {
+
+	left, ok := f.rewrite(l).Get()
+	if !ok {
+		return mo.None[expr.Expression]()
+	}
+	right, ok := f.rewrite(r).Get()
+	if !ok {
+		return mo.None[expr.Expression]()
+	}
+	return mo.Some(combine(left, right))
+}
+
+// rewriteNot pushes the negation down to the leaves.
+func (f *dataSkippingFilter) rewriteNot(e expr.Expression) mo.Option[expr.Expression] {
+	switch v := e.(type) {
+	case *expr.Not:
+		return f.rewrite(v.Child)
+	case *expr.And:
+		return f.rewrite(expr.NewOr(expr.NewNot(v.Left), expr.NewNot(v.Right)))
+	case *expr.Or:
+		return f.rewrite(expr.NewAnd(expr.NewNot(v.Left), expr.NewNot(v.Right)))
+	case *expr.IsNull:
+		return f.rewriteNullCheck(v.Child, false)
+	case *expr.IsNotNull:
+		return f.rewriteNullCheck(v.Child, true)
+	case *expr.EqualTo:
+		return f.rewriteComparison(v.Left, v.Right, "!=")
+	case *expr.Lt:
+		return f.rewriteComparison(v.Left, v.Right, ">=")
+	case *expr.Lte:
+		return f.rewriteComparison(v.Left, v.Right, ">")
+	case *expr.Gt:
+		return f.rewriteComparison(v.Left, v.Right, "<=")
+	case *expr.Gte:
+		return f.rewriteComparison(v.Left, v.Right, "<")
+	}
+	return mo.None[expr.Expression]()
+}
+
+func (f *dataSkippingFilter) rewriteNullCheck(e expr.Expression, isNull bool) mo.Option[expr.Expression] {
+	col, ok := e.(*expr.Column)
+	if !ok || !f.isSkippingEligible(col) {
+		return mo.None[expr.Expression]()
+	}
+
+	nullCount := expr.NewColumn(statsNullCount+"."+col.Name, &expr.LongType{})
+	if isNull {
+		return mo.Some[expr.Expression](expr.NewGreaterThan(nullCount, expr.LiteralLong(0)))
+	}
+	return mo.Some[expr.Expression](expr.NewLessThan(nullCount, expr.NewColumn(statsNumRecords, &expr.LongType{})))
+}
+
+func (f *dataSkippingFilter) rewriteComparison(l expr.Expression, r expr.Expression, op string) mo.Option[expr.Expression] {
+	col, isCol := l.(*expr.Column)
+	lit, isLit := r.(*expr.Literal)
+	if !isCol || !isLit {
+		// literal on the left side, e.g. 5 < col is col > 5
+		col, isCol = r.(*expr.Column)
+		lit, isLit = l.(*expr.Literal)
+		if !isCol || !isLit {
+			return mo.None[expr.Expression]()
+		}
+		op = map[string]string{"=": "=", "!=": "!=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}[op]
+	}
+	if !f.isSkippingEligible(col) || lit.Value == nil || !sameDataType(lit.Type, col.Type) {
+		return mo.None[expr.Expression]()
+	}
+
+	min := expr.NewColumn(statsMinValues+"."+col.Name, col.Type)
+	max := expr.NewColumn(statsMaxValues+"."+col.Name, col.Type)
+
+	var res expr.Expression
+	switch op {
+	case "=":
+		res = expr.NewAnd(expr.NewLessThanOrEq(min, lit), expr.NewGreaterThanOrEq(max, lit))
+	case "!=":
+		res = expr.NewOr(expr.NewLessThan(min, lit), expr.NewGreaterThan(max, lit))
+	case "<":
+		res = expr.NewLessThan(min, lit)
+	case "<=":
+		res = expr.NewLessThanOrEq(min, lit)
+	case ">":
+		res = expr.NewGreaterThan(max, lit)
+	case ">=":
+		res = expr.NewGreaterThanOrEq(max, lit)
+	}
+	return mo.Some(res)
+}
+
+// isSkippingEligible checks if the statistics of the column can be compared.
+func (f *dataSkippingFilter) isSkippingEligible(col *expr.Column) bool {
+	field, err := f.schema.Get(col.Name)
+	if err != nil || field == nil || !sameDataType(field.DataType, col.Type) {
+		return false
+	}
+	switch col.Type.(type) {
+	case *expr.IntegerType, *expr.LongType, *expr.FloatType, *expr.DoubleType, *expr.StringType,
+		*expr.DecimalType, *expr.DateType, *expr.TimestampType:
+		return true
+	}
+	return false
+}
+
+// evalStatsPredicate evaluates the predicate on the statistics, an unknown result might match.
+func evalStatsPredicate(e expr.Expression, r *statsRowRecord) bool {
+	switch v := e.(type) {
+	case *expr.And:
+		return evalStatsPredicate(v.Left, r) && evalStatsPredicate(v.Right, r)
+	case *expr.Or:
+		return evalStatsPredicate(v.Left, r) || evalStatsPredicate(v.Right, r)
+	}
+
+	res, err := e.Eval(r)
+	if err != nil || res == nil {
+		return true
+	}
+	return res.(bool)
+}
+
+// sameDataType checks if the values of both types are represented by the same go type, e.g. decimals of any precision.
+func sameDataType(a expr.DataType, b expr.DataType) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func conjunction(exps []expr.Expression) expr.Expression {
+	res := exps[0]
+	for _, e := range exps[1:] {
+		res = expr.NewAnd(res, e)
+	}
+	return res
+}
+
+// statsRowRecord exposes the statistics of a file as a RowRecord, the fields are named as
+// numRecords, minValues.<column>, maxValues.<column> and nullCount.<column>.
+// A missing statistic is null.
+type statsRowRecord struct {
+	schema *expr.StructType
+	stats  *fileStats
+	// physicalNames maps the logical column names to the keys of the statistics in column mapping mode
+	physicalNames map[string]string
+}
+
+func (s *statsRowRecord) value(fieldName string) (any, bool) {
+	if fieldName == statsNumRecords {
+		if s.stats.NumRecords == nil {
+			return nil, false
+		}
+		return json.Number(strconv.FormatInt(*s.stats.NumRecords, 10)), true
+	}
+
+	group, column, found := strings.Cut(fieldName, ".")
+	if !found {
+		return nil, false
+	}
+	var values map[string]any
+	switch group {
+	case statsMinValues:
+		values = s.stats.MinValues
+	case statsMaxValues:
+		values = s.stats.MaxValues
+	case statsNullCount:
+		values = s.stats.NullCount
+	}
+	if name, ok := s.physicalNames[column]; ok {
+		column = name
+	}
+	v, ok := values[column]
+	if !ok || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// number returns the numeric statistic, either a json number or a typed value read from a checkpoint.
+func (s *statsRowRecord) number(fieldName string) (json.Number, error) {
+	v, _ := s.value(fieldName)
+	switch n := v.(type) {
+	case json.Number:
+		return n, nil
+	case int8, int16, int32, int64:
+		return json.Number(fmt.Sprint(n)), nil
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(n), 'g', -1, 32)), nil
+	case float64:
+		return json.Number(strconv.FormatFloat(n, 'g', -1, 64)), nil
+	case decimal.Decimal:
+		return json.Number(n.String()), nil
+	}
+	return "", eris.Wrap(errno.ErrClassCast, "statistic "+fieldName+" is not a number")
+}
+
+func (s *statsRowRecord) str(fieldName string) (string, error) {
+	v, _ := s.value(fieldName)
+	str, ok := v.(string)
+	if !ok {
+		return "", eris.Wrap(errno.ErrClassCast, "statistic "+fieldName+" is not a string")
+	}
+	return str, nil
+}
+
+// time returns the typed date or timestamp statistic read from a checkpoint, false if it is not typed.
+func (s *statsRowRecord) time(fieldName string) (time.Time, bool) {
+	v, _ := s.value(fieldName)
+	t, ok := v.(time.Time)
+	return t, ok
+}
+
+func (s *statsRowRecord) unsupported(fieldName string) error {
+	return eris.Wrap(errno.ErrUnsupportedOperation, "statistic "+fieldName+" is not supported for data skipping")
+}
+
+func (s *statsRowRecord) Schema() expr.StructType {
+	return *s.schema
+}
+
+func (s *statsRowRecord) Length() int {
+	return len(s.schema.Fields)
+}
+
+func (s *statsRowRecord) IsNullAt(fieldName string) (bool, error) {
+	_, ok := s.value(fieldName)
+	return !ok, nil
+}
+
+func (s *statsRowRecord) GetInt(fieldName string) (int, error) {
+	n, err := s.GetInt64(fieldName)
+	return int(n), err
+}
+
+func (s *statsRowRecord) GetInt64(fieldName string) (int64, error) {
+	n, err := s.number(fieldName)
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64()
+}
+
+func (s *statsRowRecord) GetByte(fieldName string) (int8, error) {
+	return 0, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetShort(fieldName string) (int16, error) {
+	return 0, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetBoolean(fieldName string) (bool, error) {
+	return false, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetFloat(fieldName string) (float32, error) {
+	v, err := s.GetDouble(fieldName)
+	return float32(v), err
+}
+
+func (s *statsRowRecord) GetDouble(fieldName string) (float64, error) {
+	n, err := s.number(fieldName)
+	if err != nil {
+		return 0, err
+	}
+	return n.Float64()
+}
+
+func (s *statsRowRecord) GetString(fieldName string) (string, error) {
+	return s.str(fieldName)
+}
+
+func (s *statsRowRecord) GetBinary(fieldName string) ([]byte, error) {
+	return nil, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetBigDecimal(fieldName string) (decimal.Decimal, error) {
+	n, err := s.number(fieldName)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return decimal.NewFromString(n.String())
+}
+
+func (s *statsRowRecord) GetTimestamp(fieldName string) (time.Time, error) {
+	t, ok := s.time(fieldName)
+	if !ok {
+		str, err := s.str(fieldName)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t, err = time.Parse(time.RFC3339Nano, str); err != nil {
+			return time.Time{}, eris.Wrap(err, "statistic "+fieldName)
+		}
+	}
+	// timestamps are truncated to milliseconds in the statistics
+	if strings.HasPrefix(fieldName, statsMaxValues+".") {
+		t = t.Add(time.Millisecond)
+	}
+	return t, nil
+}
+
+func (s *statsRowRecord) GetDate(fieldName string) (time.Time, error) {
+	if t, ok := s.time(fieldName); ok {
+		return t, nil
+	}
+	str, err := s.str(fieldName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		return time.Time{}, eris.Wrap(err, "statistic "+fieldName)
+	}
+	return t, nil
+}
+
+func (s *statsRowRecord) GetRecord(fieldName string) (expr.RowRecord, error) {
+	return nil, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetList(fieldName string) ([]any, error) {
+	return nil, s.unsupported(fieldName)
+}
+
+func (s *statsRowRecord) GetMap(fieldName string) (map[any]any, error) {
+	return nil, s.unsupported(fieldName)
+}
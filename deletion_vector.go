@@ -0,0 +1,96 @@
+package deltago
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"gocloud.dev/blob"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/deletionvector"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/path"
+)
+
+// LoadDeletionVector loads the deletion vector of a data file, i.e. the indexes of the deleted rows.
+// The deletion vector is either inline in the descriptor, or stored in a file in the format:
+// <version: 1 byte> (<size: int32 big endian> <RoaringBitmapArray> <crc32 checksum: int32 big endian>)*
+// where the offset of the descriptor points to the size.
+func (l *logImpl) LoadDeletionVector(dv *action.DeletionVectorDescriptor) (*deletionvector.RoaringBitmapArray, error) {
+	return l.LoadDeletionVectorContext(context.Background(), dv)
+}
+
+// LoadDeletionVectorContext is LoadDeletionVector with a context to cancel the reading of the deletion vector file.
+func (l *logImpl) LoadDeletionVectorContext(ctx context.Context, dv *action.DeletionVectorDescriptor) (*deletionvector.RoaringBitmapArray, error) {
+	if dv.IsInline() {
+		data, err := dv.InlineData()
+		if err != nil {
+			return nil, err
+		}
+		return deletionvector.Deserialize(data)
+	}
+
+	p, err := dv.AbsolutePath(l.dataPath)
+	if err != nil {
+		return nil, err
+	}
+	offset := int64(1)
+	if dv.Offset != nil {
+		offset = int64(*dv.Offset)
+	}
+
+	// size, data and checksum
+	b, err := l.readRange(ctx, p, offset, 4+int64(dv.SizeInBytes)+4)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	size := binary.BigEndian.Uint32(b[:4])
+	if size != uint32(dv.SizeInBytes) {
+		return nil, eris.Wrapf(errno.ErrIllegalState,
+			"deletion vector size %d in %s does not match the size %d in the log", size, p, dv.SizeInBytes)
+	}
+	data := b[4 : 4+size]
+	checksum := binary.BigEndian.Uint32(b[4+size:])
+	if crc32.ChecksumIEEE(data) != checksum {
+		return nil, eris.Wrap(errno.ErrIllegalState, "checksum mismatch of the deletion vector in "+p)
+	}
+
+	return deletionvector.Deserialize(data)
+}
+
+// readRange reads length bytes from the offset of the file at the fully qualified path p.
+func (l *logImpl) readRange(ctx context.Context, p string, offset int64, length int64) ([]byte, error) {
+	i := strings.LastIndex(p, "/")
+	dir, name := p[:i+1], p[i+1:]
+
+	blobURL, err := path.ConvertToBlobURL(dir)
+	if err != nil {
+		return nil, err
+	}
+	var bucket *blob.Bucket
+	if l.mux == nil {
+		bucket, err = blob.OpenBucket(ctx, blobURL)
+	} else {
+		bucket, err = l.mux.OpenBucket(ctx, blobURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer bucket.Close()
+
+	r, err := bucket.NewRangeReader(ctx, name, offset, length, nil)
+	if err != nil {
+		return nil, eris.Wrap(err, "reading deletion vector "+p)
+	}
+	defer r.Close()
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, eris.Wrap(err, "reading deletion vector "+p)
+	}
+	return b, nil
+}
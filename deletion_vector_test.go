@@ -0,0 +1,163 @@
+package deltago
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/internal/util/z85"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// serializeTestDeletionVector serializes the row indexes (< 65536) as a RoaringBitmapArray in the portable format.
+func serializeTestDeletionVector(rows []uint16) []byte {
+	buf := &bytes.Buffer{}
+	w := func(v any) { binary.Write(buf, binary.LittleEndian, v) }
+	w(int32(1681511377)) // magic number
+	w(int64(1))          // number of bitmaps
+	w(uint32(0))         // key of the bitmap
+	w(uint32(12346))     // cookie without run containers
+	w(uint32(1))         // number of containers
+	w(uint16(0))         // key of the container
+	w(uint16(len(rows) - 1))
+	w(uint32(0)) // offset
+	w(rows)
+	return buf.Bytes()
+}
+
+func TestDeletionVector_replay_keyed_on_path_and_deletion_vector(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	inline := func(rows ...uint16) *action.DeletionVectorDescriptor {
+		data := serializeTestDeletionVector(rows)
+		return &action.DeletionVectorDescriptor{
+			StorageType:    action.DeletionVectorStorageInline,
+			PathOrInlineDv: z85.Encode(data),
+			SizeInBytes:    int32(len(data)),
+			Cardinality:    int64(len(rows)),
+		}
+	}
+	dv1, dv2 := inline(1), inline(1, 5)
+	add1 := &action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true, DeletionVector: dv1}
+	add2 := &action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true, DeletionVector: dv2}
+	ts := int64(1)
+	remove1 := &action.RemoveFile{Path: "a", DeletionTimestamp: &ts, DataChange: true, DeletionVector: dv1}
+
+	commitTestActions(t, log, getTestMetedata(), add1)
+	commitTestActions(t, log, remove1, add2)
+
+	assertActive := func(s Snapshot) {
+		files, err := s.AllFiles()
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, dv2.UniqueId(), files[0].DeletionVectorUniqueId())
+
+		scan, err := s.Scan(nil)
+		assert.NoError(t, err)
+		it, err := scan.Files()
+		assert.NoError(t, err)
+		scanned, err := iter.ToSlice(it)
+		assert.NoError(t, err)
+		assert.Len(t, scanned, 1)
+		assert.Equal(t, dv2.UniqueId(), scanned[0].DeletionVectorUniqueId())
+
+		rows, err := log.LoadDeletionVector(files[0].DeletionVector)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{1, 5}, rows.ToSlice())
+	}
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	assertActive(s)
+
+	// the deletion vectors are kept in checkpoints
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), getTestFileConfig(), &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filenames.CheckpointFileSingular("", 1)}, checkpointNames(s))
+	assertActive(s)
+}
+
+func checkpointNames(s Snapshot) []string {
+	var res []string
+	for _, f := range s.(*snapshotImp).logSegment.Checkpoints {
+		res = append(res, filepath.Base(f.Path()))
+	}
+	return res
+}
+
+func TestDeletionVector_load_from_file(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(tt.urlstr)
+	assert.NoError(t, err)
+	tableDir := u.Path + "/" + tt.tempDir
+
+	uuid := []byte{0xd2, 0xc6, 0x39, 0xaa, 0x88, 0x16, 0x43, 0x1a, 0xaa, 0xf6, 0xd3, 0xfe, 0x25, 0x12, 0xff, 0x61}
+	data := serializeTestDeletionVector([]uint16{0, 2, 65535})
+
+	// a file with another deletion vector before the one to load
+	buf := &bytes.Buffer{}
+	buf.WriteByte(1)
+	for _, d := range [][]byte{[]byte("other"), data} {
+		binary.Write(buf, binary.BigEndian, uint32(len(d)))
+		buf.Write(d)
+		binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(d))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(tableDir, "ab"), 0755))
+	p := filepath.Join(tableDir, "ab", "deletion_vector_d2c639aa-8816-431a-aaf6-d3fe2512ff61.bin")
+	assert.NoError(t, os.WriteFile(p, buf.Bytes(), 0644))
+
+	offset := int32(1 + 4 + 5 + 4)
+	dv := &action.DeletionVectorDescriptor{
+		StorageType:    action.DeletionVectorStorageUUIDRelative,
+		PathOrInlineDv: "ab" + z85.Encode(uuid),
+		Offset:         &offset,
+		SizeInBytes:    int32(len(data)),
+		Cardinality:    3,
+	}
+	rows, err := log.LoadDeletionVector(dv)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0, 2, 65535}, rows.ToSlice())
+	assert.True(t, rows.Contains(2))
+	assert.False(t, rows.Contains(1))
+
+	absolute := &action.DeletionVectorDescriptor{
+		StorageType:    action.DeletionVectorStorageAbsolutePath,
+		PathOrInlineDv: "file://" + p,
+		Offset:         &offset,
+		SizeInBytes:    int32(len(data)),
+		Cardinality:    3,
+	}
+	rows, err = log.LoadDeletionVector(absolute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), rows.Cardinality())
+
+	// corrupted file
+	b := buf.Bytes()
+	b[len(b)-1] ^= 0xff
+	assert.NoError(t, os.WriteFile(p, b, 0644))
+	_, err = log.LoadDeletionVector(dv)
+	assert.Error(t, err)
+}
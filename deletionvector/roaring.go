@@ -0,0 +1,279 @@
+// Package deletionvector decodes the deletion vectors of Delta tables.
+// A deletion vector is a RoaringBitmapArray of the indexes of the deleted rows in a data file.
+package deletionvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/rotisserie/eris"
+)
+
+// The magic numbers at the beginning of a serialized RoaringBitmapArray.
+const (
+	portableSerializationFormatMagicNumber = 1681511377
+	nativeSerializationFormatMagicNumber   = 1681511376
+)
+
+// The cookies of the portable 32-bit roaring bitmap format,
+// see https://github.com/RoaringBitmap/RoaringFormatSpec
+const (
+	serialCookieNoRunContainer = 12346
+	serialCookie               = 12347
+	noOffsetThreshold          = 4
+	maxArrayContainerSize      = 4096
+)
+
+// RoaringBitmapArray is a set of 64-bit row indexes, stored as 32-bit roaring bitmaps keyed by the high 32 bits.
+type RoaringBitmapArray struct {
+	// the bitmaps are sorted by key
+	bitmaps []*bitmap32
+}
+
+// Deserialize decodes a RoaringBitmapArray in the portable serialization format.
+func Deserialize(data []byte) (*RoaringBitmapArray, error) {
+	r := bytes.NewReader(data)
+
+	var magic int32
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil {
+		return nil, invalidBitmap(err)
+	}
+	if magic == nativeSerializationFormatMagicNumber {
+		return nil, eris.Wrap(errno.ErrUnsupportedOperation, "native serialization format of deletion vectors is not supported")
+	}
+	if magic != portableSerializationFormatMagicNumber {
+		return nil, eris.Wrapf(errno.ErrIllegalArgument, "unexpected magic number %d of deletion vector", magic)
+	}
+
+	var numBitmaps int64
+	if err := binary.Read(r, binary.LittleEndian, &numBitmaps); err != nil {
+		return nil, invalidBitmap(err)
+	}
+	if numBitmaps < 0 || numBitmaps > int64(r.Len()) {
+		return nil, eris.Wrapf(errno.ErrIllegalArgument, "invalid number of bitmaps %d in deletion vector", numBitmaps)
+	}
+
+	res := &RoaringBitmapArray{}
+	lastKey := int64(-1)
+	for i := int64(0); i < numBitmaps; i++ {
+		var key uint32
+		if err := binary.Read(r, binary.LittleEndian, &key); err != nil {
+			return nil, invalidBitmap(err)
+		}
+		if int64(key) <= lastKey {
+			return nil, eris.Wrap(errno.ErrIllegalArgument, "keys of deletion vector bitmaps are not increasing")
+		}
+		lastKey = int64(key)
+
+		b, err := readBitmap32(r)
+		if err != nil {
+			return nil, err
+		}
+		b.key = key
+		res.bitmaps = append(res.bitmaps, b)
+	}
+	return res, nil
+}
+
+// Contains checks if the row index is in the set.
+func (a *RoaringBitmapArray) Contains(value uint64) bool {
+	high := uint32(value >> 32)
+	i := sort.Search(len(a.bitmaps), func(i int) bool { return a.bitmaps[i].key >= high })
+	if i == len(a.bitmaps) || a.bitmaps[i].key != high {
+		return false
+	}
+	return a.bitmaps[i].contains(uint32(value))
+}
+
+// Cardinality returns the number of row indexes in the set.
+func (a *RoaringBitmapArray) Cardinality() int64 {
+	var n int64
+	for _, b := range a.bitmaps {
+		for _, c := range b.containers {
+			n += int64(c.cardinality())
+		}
+	}
+	return n
+}
+
+// ToSlice returns the row indexes in increasing order.
+func (a *RoaringBitmapArray) ToSlice() []uint64 {
+	res := make([]uint64, 0, a.Cardinality())
+	for _, b := range a.bitmaps {
+		for i, c := range b.containers {
+			high := uint64(b.key)<<32 | uint64(b.keys[i])<<16
+			res = c.appendTo(high, res)
+		}
+	}
+	return res
+}
+
+// bitmap32 is a 32-bit roaring bitmap, the containers are sorted by their keys (the high 16 bits).
+type bitmap32 struct {
+	key        uint32
+	keys       []uint16
+	containers []container
+}
+
+func (b *bitmap32) contains(value uint32) bool {
+	high := uint16(value >> 16)
+	i := sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= high })
+	if i == len(b.keys) || b.keys[i] != high {
+		return false
+	}
+	return b.containers[i].contains(uint16(value))
+}
+
+type container interface {
+	contains(low uint16) bool
+	cardinality() int
+	appendTo(high uint64, res []uint64) []uint64
+}
+
+type arrayContainer []uint16
+
+func (c arrayContainer) contains(low uint16) bool {
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= low })
+	return i < len(c) && c[i] == low
+}
+
+func (c arrayContainer) cardinality() int {
+	return len(c)
+}
+
+func (c arrayContainer) appendTo(high uint64, res []uint64) []uint64 {
+	for _, v := range c {
+		res = append(res, high|uint64(v))
+	}
+	return res
+}
+
+type bitmapContainer []uint64
+
+func (c bitmapContainer) contains(low uint16) bool {
+	return c[low/64]&(1<<(low%64)) != 0
+}
+
+func (c bitmapContainer) cardinality() int {
+	n := 0
+	for _, w := range c {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+func (c bitmapContainer) appendTo(high uint64, res []uint64) []uint64 {
+	for i, w := range c {
+		for w != 0 {
+			t := bits.TrailingZeros64(w)
+			res = append(res, high|uint64(i*64+t))
+			w &= w - 1
+		}
+	}
+	return res
+}
+
+// runContainer stores [start, start+length] intervals.
+type runContainer [][2]uint16
+
+func (c runContainer) contains(low uint16) bool {
+	i := sort.Search(len(c), func(i int) bool { return uint32(c[i][0])+uint32(c[i][1]) >= uint32(low) })
+	return i < len(c) && c[i][0] <= low
+}
+
+func (c runContainer) cardinality() int {
+	n := 0
+	for _, r := range c {
+		n += int(r[1]) + 1
+	}
+	return n
+}
+
+func (c runContainer) appendTo(high uint64, res []uint64) []uint64 {
+	for _, r := range c {
+		for v := uint32(r[0]); v <= uint32(r[0])+uint32(r[1]); v++ {
+			res = append(res, high|uint64(v))
+		}
+	}
+	return res
+}
+
+func readBitmap32(r *bytes.Reader) (*bitmap32, error) {
+	var cookie uint32
+	if err := binary.Read(r, binary.LittleEndian, &cookie); err != nil {
+		return nil, invalidBitmap(err)
+	}
+
+	var size int
+	var runFlags []byte
+	hasRun := cookie&0xFFFF == serialCookie
+	if hasRun {
+		size = int(cookie>>16) + 1
+		runFlags = make([]byte, (size+7)/8)
+		if _, err := io.ReadFull(r, runFlags); err != nil {
+			return nil, invalidBitmap(err)
+		}
+	} else if cookie == serialCookieNoRunContainer {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, invalidBitmap(err)
+		}
+		size = int(n)
+	} else {
+		return nil, eris.Wrapf(errno.ErrIllegalArgument, "unexpected cookie %d of roaring bitmap", cookie)
+	}
+	if size > 1<<16 {
+		return nil, eris.Wrapf(errno.ErrIllegalArgument, "invalid number of containers %d in roaring bitmap", size)
+	}
+
+	header := make([]uint16, 2*size)
+	if err := binary.Read(r, binary.LittleEndian, header); err != nil {
+		return nil, invalidBitmap(err)
+	}
+	// the offsets are not needed when reading the containers sequentially
+	if !hasRun || size >= noOffsetThreshold {
+		if _, err := r.Seek(int64(4*size), io.SeekCurrent); err != nil {
+			return nil, invalidBitmap(err)
+		}
+	}
+
+	b := &bitmap32{keys: make([]uint16, size), containers: make([]container, size)}
+	for i := 0; i < size; i++ {
+		b.keys[i] = header[2*i]
+		card := int(header[2*i+1]) + 1
+
+		switch {
+		case hasRun && runFlags[i/8]&(1<<(i%8)) != 0:
+			var n uint16
+			if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+				return nil, invalidBitmap(err)
+			}
+			runs := make(runContainer, n)
+			if err := binary.Read(r, binary.LittleEndian, runs); err != nil {
+				return nil, invalidBitmap(err)
+			}
+			b.containers[i] = runs
+		case card <= maxArrayContainerSize:
+			values := make(arrayContainer, card)
+			if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+				return nil, invalidBitmap(err)
+			}
+			b.containers[i] = values
+		default:
+			words := make(bitmapContainer, 1024)
+			if err := binary.Read(r, binary.LittleEndian, words); err != nil {
+				return nil, invalidBitmap(err)
+			}
+			b.containers[i] = words
+		}
+	}
+	return b, nil
+}
+
+func invalidBitmap(err error) error {
+	return eris.Wrap(errno.ErrIllegalArgument, "invalid deletion vector: "+err.Error())
+}
@@ -0,0 +1,143 @@
+package deletionvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContainer struct {
+	key    uint16
+	values []uint16
+	runs   [][2]uint16
+	bitmap bool
+}
+
+func (c *testContainer) cardinality() int {
+	if c.runs == nil {
+		return len(c.values)
+	}
+	n := 0
+	for _, r := range c.runs {
+		n += int(r[1]) + 1
+	}
+	return n
+}
+
+// serializeBitmap32 writes the containers in the portable roaring format.
+func serializeBitmap32(buf *bytes.Buffer, containers []*testContainer) {
+	w := func(v any) { binary.Write(buf, binary.LittleEndian, v) }
+
+	hasRun := false
+	for _, c := range containers {
+		hasRun = hasRun || c.runs != nil
+	}
+	if hasRun {
+		w(uint32(serialCookie) | uint32(len(containers)-1)<<16)
+		flags := make([]byte, (len(containers)+7)/8)
+		for i, c := range containers {
+			if c.runs != nil {
+				flags[i/8] |= 1 << (i % 8)
+			}
+		}
+		w(flags)
+	} else {
+		w(uint32(serialCookieNoRunContainer))
+		w(uint32(len(containers)))
+	}
+	for _, c := range containers {
+		w(c.key)
+		w(uint16(c.cardinality() - 1))
+	}
+	if !hasRun || len(containers) >= noOffsetThreshold {
+		// offsets are skipped by the reader
+		w(make([]uint32, len(containers)))
+	}
+	for _, c := range containers {
+		switch {
+		case c.runs != nil:
+			w(uint16(len(c.runs)))
+			w(c.runs)
+		case c.bitmap:
+			words := make([]uint64, 1024)
+			for _, v := range c.values {
+				words[v/64] |= 1 << (v % 64)
+			}
+			w(words)
+		default:
+			w(c.values)
+		}
+	}
+}
+
+func serializeArray(bitmaps map[uint32][]*testContainer, keys []uint32) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, int32(portableSerializationFormatMagicNumber))
+	binary.Write(buf, binary.LittleEndian, int64(len(keys)))
+	for _, k := range keys {
+		binary.Write(buf, binary.LittleEndian, k)
+		serializeBitmap32(buf, bitmaps[k])
+	}
+	return buf.Bytes()
+}
+
+func TestDeserialize_array_containers(t *testing.T) {
+	data := serializeArray(map[uint32][]*testContainer{
+		0: {{key: 0, values: []uint16{3, 4, 7, 11, 18, 29}}},
+	}, []uint32{0})
+
+	a, err := Deserialize(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 4, 7, 11, 18, 29}, a.ToSlice())
+	assert.Equal(t, int64(6), a.Cardinality())
+	assert.True(t, a.Contains(7))
+	assert.False(t, a.Contains(8))
+	assert.False(t, a.Contains(1<<32|7))
+}
+
+func TestDeserialize_all_container_types_and_high_keys(t *testing.T) {
+	var dense []uint16
+	for i := 0; i < 5000; i++ {
+		dense = append(dense, uint16(i*2))
+	}
+	data := serializeArray(map[uint32][]*testContainer{
+		0: {
+			{key: 0, values: []uint16{1, 2}},
+			{key: 1, runs: [][2]uint16{{10, 4}, {100, 0}}},
+			{key: 2, values: dense, bitmap: true},
+			{key: 5, values: []uint16{65535}},
+		},
+		7: {{key: 0, runs: [][2]uint16{{0, 2}}}},
+	}, []uint32{0, 7})
+
+	a, err := Deserialize(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2+6+5000+1+3), a.Cardinality())
+
+	assert.True(t, a.Contains(2))
+	assert.True(t, a.Contains(1<<16|14))
+	assert.False(t, a.Contains(1<<16|15))
+	assert.True(t, a.Contains(1<<16|100))
+	assert.True(t, a.Contains(2<<16|9998))
+	assert.False(t, a.Contains(2<<16|9999))
+	assert.True(t, a.Contains(5<<16|65535))
+	assert.True(t, a.Contains(7<<32|2))
+	assert.False(t, a.Contains(7<<32|3))
+
+	values := a.ToSlice()
+	assert.Len(t, values, int(a.Cardinality()))
+	assert.Equal(t, []uint64{1, 2, 1<<16 | 10}, values[:3])
+	assert.Equal(t, uint64(7<<32|2), values[len(values)-1])
+}
+
+func TestDeserialize_invalid_data(t *testing.T) {
+	_, err := Deserialize([]byte{1, 2, 3, 4})
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+
+	data := serializeArray(map[uint32][]*testContainer{0: {{key: 0, values: []uint16{1}}}}, []uint32{0})
+	_, err = Deserialize(data[:len(data)-1])
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+}
@@ -0,0 +1,276 @@
+package deltago
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+)
+
+// TableDiff is the difference between the states of a table at two versions.
+type TableDiff struct {
+	FromVersion int64
+	ToVersion   int64
+	// Added are the files of ToVersion which are not in FromVersion, sorted by path.
+	Added []*action.AddFile
+	// Removed are the files of FromVersion which are not in ToVersion, sorted by path.
+	Removed []*action.RemoveFile
+	// Metadata is the metadata of ToVersion, present only if the metadata is changed after FromVersion.
+	Metadata mo.Option[*action.Metadata]
+	// Protocol is the protocol of ToVersion, present only if the protocol is changed after FromVersion.
+	Protocol mo.Option[*action.Protocol]
+}
+
+// Diff returns the files added and removed, and the metadata and protocol changed from fromVersion to toVersion.
+// If the delta files of the versions after fromVersion are still in the log, the diff is computed from the commits,
+// where the files added and removed in the range are left out, and a metadata or protocol committed in the range
+// is compared with the one of fromVersion, so fromVersion must then be recreatable. Otherwise the files of the two snapshots are compared,
+// so fromVersion must still be recreatable.
+// A file is identified by its path and its deletion vector, e.g. updating the deletion vector of a file removes it and adds it back.
+func (l *logImpl) Diff(fromVersion int64, toVersion int64) (*TableDiff, error) {
+	return l.DiffContext(context.Background(), fromVersion, toVersion)
+}
+
+// DiffContext is Diff with a context to cancel the reading of the log.
+func (l *logImpl) DiffContext(ctx context.Context, fromVersion int64, toVersion int64) (*TableDiff, error) {
+	res, err := l.diff(ctx, fromVersion, toVersion)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) diff(ctx context.Context, fromVersion int64, toVersion int64) (*TableDiff, error) {
+	s, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fromVersion < 0 || fromVersion > toVersion || toVersion > s.Version() {
+		return nil, errno.InvalidVersionRangeError(fromVersion, toVersion, s.Version())
+	}
+
+	res := &TableDiff{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Metadata:    mo.None[*action.Metadata](),
+		Protocol:    mo.None[*action.Protocol](),
+	}
+	if fromVersion == toVersion {
+		return res, nil
+	}
+
+	earliest, err := l.history.getEarliestDeltaFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if earliest <= fromVersion+1 {
+		err = l.diffCommits(ctx, res)
+	} else {
+		err = l.diffSnapshots(ctx, res)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res.Added, func(i, j int) bool {
+		return res.Added[i].Path < res.Added[j].Path
+	})
+	sort.Slice(res.Removed, func(i, j int) bool {
+		return res.Removed[i].Path < res.Removed[j].Path
+	})
+	return res, nil
+}
+
+// diffCommits replays the actions of the commits after res.FromVersion up to res.ToVersion,
+// the last metadata and protocol of the range are compared with the ones of res.FromVersion.
+// A file removed first in the range is in res.FromVersion, while a file added first in the range
+// is looked up in the files of res.FromVersion, as it may be added back, e.g. to rewrite its statistics.
+func (l *logImpl) diffCommits(ctx context.Context, res *TableDiff) error {
+	versionLogs, err := l.changes(ctx, res.FromVersion+1, true)
+	if err != nil {
+		return err
+	}
+	defer versionLogs.Close()
+
+	// the first and the last action of each file in the range
+	first := make(map[string]action.FileAction)
+	last := make(map[string]action.FileAction)
+	metadata := mo.None[*action.Metadata]()
+	protocol := mo.None[*action.Protocol]()
+	for {
+		v, err := versionLogs.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if _, ok := v.(*MemOptimizedCheckpoint); ok {
+			continue
+		}
+		if v.Version() > res.ToVersion {
+			break
+		}
+
+		actions, err := v.Actions()
+		if err != nil {
+			return err
+		}
+		for _, a := range actions {
+			switch t := a.(type) {
+			case *action.AddFile:
+				key := t.Path + "#" + t.DeletionVectorUniqueId()
+				if _, ok := first[key]; !ok {
+					first[key] = t
+				}
+				last[key] = t
+			case *action.RemoveFile:
+				key := t.Path + "#" + t.DeletionVectorUniqueId()
+				if _, ok := first[key]; !ok {
+					first[key] = t
+				}
+				last[key] = t
+			case *action.Metadata:
+				metadata = mo.Some(t)
+			case *action.Protocol:
+				protocol = mo.Some(t)
+			}
+		}
+	}
+
+	var from *snapshotImp
+	fromSnapshot := func() (*snapshotImp, error) {
+		if from == nil {
+			s, err := l.snapshotReader.getSnapshotForVersionAsOf(ctx, res.FromVersion)
+			if err != nil {
+				return nil, err
+			}
+			from = s
+		}
+		return from, nil
+	}
+
+	// a metadata or protocol committed in the range is reported only if it differs from the one of res.FromVersion
+	if metadata.IsPresent() || protocol.IsPresent() {
+		from, err := fromSnapshot()
+		if err != nil {
+			return err
+		}
+		if m, ok := metadata.Get(); ok {
+			fromMetadata, err := from.Metadata()
+			if err != nil {
+				return err
+			}
+			if !fromMetadata.Equals(m) {
+				res.Metadata = metadata
+			}
+		}
+		if p, ok := protocol.Get(); ok {
+			fromProtocol, err := from.Protocol()
+			if err != nil {
+				return err
+			}
+			if !fromProtocol.Equals(p) {
+				res.Protocol = protocol
+			}
+		}
+	}
+
+	var fromKeys map[string]struct{}
+	for key, a := range last {
+		_, inFrom := first[key].(*action.RemoveFile)
+		if !inFrom {
+			if fromKeys == nil {
+				from, err := fromSnapshot()
+				if err != nil {
+					return err
+				}
+				files, err := from.AllFilesContext(ctx)
+				if err != nil {
+					return err
+				}
+				fromKeys = make(map[string]struct{}, len(files))
+				for _, f := range files {
+					fromKeys[f.Path+"#"+f.DeletionVectorUniqueId()] = struct{}{}
+				}
+			}
+			_, inFrom = fromKeys[key]
+		}
+
+		switch t := a.(type) {
+		case *action.AddFile:
+			if !inFrom {
+				res.Added = append(res.Added, t)
+			}
+		case *action.RemoveFile:
+			if inFrom {
+				res.Removed = append(res.Removed, t)
+			}
+		}
+	}
+	return nil
+}
+
+// diffSnapshots compares the snapshots of res.FromVersion and res.ToVersion.
+func (l *logImpl) diffSnapshots(ctx context.Context, res *TableDiff) error {
+	from, err := l.snapshotReader.getSnapshotForVersionAsOf(ctx, res.FromVersion)
+	if err != nil {
+		return err
+	}
+	to, err := l.snapshotReader.getSnapshotForVersionAsOf(ctx, res.ToVersion)
+	if err != nil {
+		return err
+	}
+
+	fromFiles, err := from.AllFilesContext(ctx)
+	if err != nil {
+		return err
+	}
+	toFiles, err := to.AllFilesContext(ctx)
+	if err != nil {
+		return err
+	}
+	removed, added := diffFiles(fromFiles, toFiles)
+	res.Added = added
+	for _, f := range removed {
+		size := f.Size
+		res.Removed = append(res.Removed, &action.RemoveFile{
+			Path:                 f.Path,
+			DataChange:           true,
+			ExtendedFileMetadata: true,
+			PartitionValues:      f.PartitionValues,
+			Size:                 &size,
+			Tags:                 f.Tags,
+			DeletionVector:       f.DeletionVector,
+		})
+	}
+
+	fromMetadata, err := from.Metadata()
+	if err != nil {
+		return err
+	}
+	toMetadata, err := to.Metadata()
+	if err != nil {
+		return err
+	}
+	if !fromMetadata.Equals(toMetadata) {
+		res.Metadata = mo.Some(toMetadata)
+	}
+
+	fromProtocol, err := from.Protocol()
+	if err != nil {
+		return err
+	}
+	toProtocol, err := to.Protocol()
+	if err != nil {
+		return err
+	}
+	if !fromProtocol.Equals(toProtocol) {
+		res.Protocol = mo.Some(toProtocol)
+	}
+	return nil
+}
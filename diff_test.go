@@ -0,0 +1,111 @@
+package deltago
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rotisserie/eris"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+)
+
+func TestLog_diff(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+
+	paths := func(d *TableDiff) ([]string, []string) {
+		var added, removed []string
+		for _, f := range d.Added {
+			added = append(added, f.Path)
+		}
+		for _, f := range d.Removed {
+			removed = append(removed, f.Path)
+		}
+		return added, removed
+	}
+
+	commitTestActions(t, log, getTestMetedata(), testAddFile("a"), testAddFile("b"))
+	commitTestActions(t, log, testAddFile("c"), testRemoveFile("a"))
+	s, err := log.Update()
+	assert.NoError(t, err)
+	commitTestActions(t, log, testAddFile("d"), testRemoveFile("c"))
+	updated := getTestMetedata()
+	updated.Configuration = map[string]string{"delta.appendOnly": "false"}
+	commitTestActions(t, log, updated, testRemoveFile("b"), testAddFile("a"))
+
+	// the files added and removed, or removed and added back in the range are collapsed
+	d, err := log.Diff(0, 3)
+	assert.NoError(t, err)
+	added, removed := paths(d)
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"b"}, removed)
+	assert.True(t, d.Metadata.IsPresent())
+	assert.True(t, d.Protocol.IsAbsent())
+
+	d, err = log.Diff(1, 2)
+	assert.NoError(t, err)
+	added, removed = paths(d)
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"c"}, removed)
+	assert.True(t, d.Metadata.IsAbsent())
+
+	d, err = log.Diff(2, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, d.Added)
+	assert.Empty(t, d.Removed)
+
+	fromCommits, err := log.Diff(1, 3)
+	assert.NoError(t, err)
+	added, removed = paths(fromCommits)
+	assert.Equal(t, []string{"a", "d"}, added)
+	assert.Equal(t, []string{"b", "c"}, removed)
+
+	// fromVersion is recreated from the checkpoint when the commits before it are cleaned up
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+	logDir := strings.TrimPrefix(l.logPath, "file://")
+	assert.NoError(t, os.Remove(filepath.Join(logDir, filenames.DeltaFile("", 0))))
+	fromCheckpoint, err := log.Diff(1, 3)
+	assert.NoError(t, err)
+	added, removed = paths(fromCheckpoint)
+	assert.Equal(t, []string{"a", "d"}, added)
+	assert.Equal(t, []string{"b", "c"}, removed)
+	assert.Equal(t, fromCommits.Metadata, fromCheckpoint.Metadata)
+	assert.True(t, fromCheckpoint.Protocol.IsAbsent())
+
+	// a metadata or protocol committed again unchanged is not reported
+	commitTestActions(t, log, updated, &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 2})
+	d, err = log.Diff(3, 4)
+	assert.NoError(t, err)
+	assert.True(t, d.Metadata.IsAbsent())
+	assert.True(t, d.Protocol.IsAbsent())
+
+	// a file added back to rewrite its statistics is not changed, and is removed if it is removed later
+	rewritten := testAddFile("d")
+	rewritten.DataChange = false
+	rewritten.Stats = `{"numRecords":1}`
+	commitTestActions(t, log, rewritten, testAddFile("e"))
+	d, err = log.Diff(3, 5)
+	assert.NoError(t, err)
+	added, removed = paths(d)
+	assert.Equal(t, []string{"e"}, added)
+	assert.Empty(t, removed)
+	commitTestActions(t, log, testRemoveFile("d"))
+	d, err = log.Diff(4, 6)
+	assert.NoError(t, err)
+	added, removed = paths(d)
+	assert.Equal(t, []string{"e"}, added)
+	assert.Equal(t, []string{"d"}, removed)
+
+	_, err = log.Diff(6, 7)
+	assert.True(t, eris.Is(err, errno.ErrIllegalArgument))
+}
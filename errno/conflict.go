@@ -0,0 +1,162 @@
+package errno
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rotisserie/eris"
+)
+
+// Conflict describes the commit which won the version a transaction attempted to commit and conflicts with it.
+// It is embedded in the conflict errors, which match ErrConcurrentModification and can be found by errors.As.
+type Conflict struct {
+	// WinningVersion is the version of the winning commit.
+	WinningVersion int64
+	// WinningCommitInfo describes the winning commit from its CommitInfo, nil if the commit has no CommitInfo.
+	WinningCommitInfo *CommitInfo
+}
+
+// CommitInfo holds the fields of the CommitInfo action of a winning commit which describe it in the conflict errors.
+type CommitInfo struct {
+	Version             int64          `json:"version"`
+	Timestamp           int64          `json:"timestamp,omitempty"`
+	Operation           string         `json:"operation,omitempty"`
+	OperationParameters map[string]any `json:"operationParameters,omitempty"`
+	ReadVersion         *int64         `json:"readVersion,omitempty"`
+	IsolationLevel      *string        `json:"isolationLevel,omitempty"`
+	IsBlindAppend       *bool          `json:"isBlindAppend,omitempty"`
+}
+
+func (c Conflict) Is(target error) bool {
+	return target == ErrConcurrentModification
+}
+
+func (c Conflict) message(msg string) string {
+	if c.WinningCommitInfo == nil {
+		return msg
+	}
+	b, err := json.Marshal(c.WinningCommitInfo)
+	if err != nil {
+		return msg
+	}
+	return msg + "\nConflicting commit: " + string(b)
+}
+
+// ConcurrentAppendError Thrown when files are added by a concurrent commit to the partition the transaction read.
+type ConcurrentAppendError struct {
+	Conflict
+	// Partition describes the partition of the added files, e.g. "partition [date=2023-01-01]" or "the root of the table".
+	Partition string
+	// Files are the paths of the added files.
+	Files []string
+}
+
+func (e *ConcurrentAppendError) Error() string {
+	return e.message(fmt.Sprintf("Files were added to %s by a concurrent update. "+
+		"Please try the operation again.", e.Partition))
+}
+
+// ConcurrentDeleteReadError Thrown when files the transaction read are deleted by a concurrent commit.
+type ConcurrentDeleteReadError struct {
+	Conflict
+	// Files are the paths of the deleted files.
+	Files []string
+}
+
+func (e *ConcurrentDeleteReadError) Error() string {
+	return e.message(fmt.Sprintf("This transaction attempted to read one or more files that were deleted"+
+		" (for example %s) by a concurrent update. Please try the operation again.", firstFile(e.Files)))
+}
+
+// ConcurrentDeleteDeleteError Thrown when files the transaction deletes are deleted by a concurrent commit too.
+type ConcurrentDeleteDeleteError struct {
+	Conflict
+	// Files are the paths of the files deleted by both.
+	Files []string
+}
+
+func (e *ConcurrentDeleteDeleteError) Error() string {
+	return e.message(fmt.Sprintf("This transaction attempted to delete one or more files that were deleted "+
+		"(for example %s) by a concurrent update. Please try the operation again.", firstFile(e.Files)))
+}
+
+// MetadataChangedError Thrown when the metadata of the Delta table is changed by a concurrent commit.
+type MetadataChangedError struct {
+	Conflict
+}
+
+func (e *MetadataChangedError) Error() string {
+	return e.message("The metadata of the Delta table has been changed by a concurrent update. " +
+		"Please try the operation again.")
+}
+
+// ProtocolChangedError Thrown when the protocol of the Delta table is changed by a concurrent commit
+// and the transaction changes it too.
+type ProtocolChangedError struct {
+	Conflict
+}
+
+func (e *ProtocolChangedError) Error() string {
+	additionalInfo := ""
+	if e.WinningVersion == 0 {
+		additionalInfo = "This happens when multiple writers are writing to an empty directory. " +
+			"Creating the table ahead of time will avoid this conflict. "
+	}
+	return e.message("The protocol version of the Delta table has been changed by a concurrent update. " +
+		additionalInfo + "Please try the operation again.")
+}
+
+// ConcurrentTransactionError Thrown when concurrent transaction both attempt to update the same idempotent transaction.
+type ConcurrentTransactionError struct {
+	Conflict
+	// AppIds are the application ids of the transactions updated by both.
+	AppIds []string
+}
+
+func (e *ConcurrentTransactionError) Error() string {
+	return e.message("This error occurs when multiple streaming queries are using the same checkpoint to write " +
+		"into this table. Did you run multiple instances of the same streaming query" +
+		" at the same time?")
+}
+
+// ConcurrentAppend returns a ConcurrentAppendError for the partition.
+//
+// Deprecated: use ConcurrentAppendError, which also describes the winning commit and the added files.
+func ConcurrentAppend(partition string) error {
+	return &ConcurrentAppendError{Partition: partition}
+}
+
+// ConcurrentDeleteRead returns a ConcurrentDeleteReadError for the file.
+//
+// Deprecated: use ConcurrentDeleteReadError, which also describes the winning commit.
+func ConcurrentDeleteRead(file string) error {
+	return &ConcurrentDeleteReadError{Files: []string{file}}
+}
+
+// ConcurrentDeleteDelete returns a ConcurrentDeleteDeleteError for the file.
+//
+// Deprecated: use ConcurrentDeleteDeleteError, which also describes the winning commit.
+func ConcurrentDeleteDelete(file string) error {
+	return &ConcurrentDeleteDeleteError{Files: []string{file}}
+}
+
+// ConcurrentTransaction returns a ConcurrentTransactionError.
+//
+// Deprecated: use ConcurrentTransactionError, which also describes the winning commit and the application ids.
+func ConcurrentTransaction() error {
+	return &ConcurrentTransactionError{}
+}
+
+// ProtocolChangedException returns a ProtocolChangedError wrapped with msg.
+//
+// Deprecated: use ProtocolChangedError, which also describes the winning commit.
+func ProtocolChangedException(msg string) error {
+	return eris.Wrap(&ProtocolChangedError{}, msg)
+}
+
+func firstFile(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	return files[0]
+}
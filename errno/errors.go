@@ -1,8 +1,11 @@
 package errno
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
@@ -20,6 +23,7 @@ var ErrFileNotFound = errors.New("file not found")
 var ErrConcurrentModification = errors.New("concurrent modification")
 var ErrJSONUnmarshal = errors.New("json unmarshal error")
 var ErrJSONMarshal = errors.New("json marshal error")
+var ErrCanceled = errors.New("operation canceled")
 
 func ActionNotFound(action string, version int64) error {
 	return eris.Wrap(ErrIllegalState,
@@ -39,14 +43,6 @@ func JsonMarshalError(err error) error {
 	return eris.Wrap(ErrJSONMarshal, err.Error())
 }
 
-func MetadataChangedError() error {
-	return eris.Wrap(ErrConcurrentModification, "metadata changed")
-}
-
-func ProtocolChangedException(msg string) error {
-	return eris.Wrap(ErrConcurrentModification, msg)
-}
-
 func InvalidProtocolVersionError() error {
 	return eris.New("invalid protocol version")
 }
@@ -172,56 +168,47 @@ func (d *ConcurrentModificationError) Error() string {
 	return d.Msg
 }
 
-func ConcurrentDeleteDelete(file string) error {
-	msg := fmt.Sprintf("This transaction attempted to delete one or more files that were deleted "+
-		"(for example %s) by a concurrent update. Please try the operation again.", file)
-	return eris.Wrap(ErrConcurrentModification, msg)
+// DeltaStandaloneError Thrown when a query fails, usually because the query itself is invalid.
+type DeltaStandaloneError struct {
+	Msg string
 }
 
-func ConcurrentDeleteRead(file string) error {
-	msg := fmt.Sprintf("This transaction attempted to read one or more files that were deleted"+
-		" (for example %s) by a concurrent update. Please try the operation again.", file)
-	return eris.Wrap(ErrConcurrentModification, msg)
+func (c *DeltaStandaloneError) Error() string {
+	return c.Msg
 }
 
-func ConcurrentAppend(partition string) error {
-	msg := fmt.Sprintf("Files were added to %s by a concurrent update. "+
-		"Please try the operation again.", partition)
-	return eris.Wrap(ErrConcurrentModification, msg)
+// CanceledError Thrown when an operation is stopped because its context is canceled or its deadline is exceeded.
+// It matches ErrCanceled and unwraps to the error of the context, i.e. context.Canceled or context.DeadlineExceeded.
+type CanceledError struct {
+	Err error
 }
 
-func ConcurrentTransaction() error {
-	msg := "This error occurs when multiple streaming queries are using the same checkpoint to write " +
-		"into this table. Did you run multiple instances of the same streaming query" +
-		" at the same time?"
-	return eris.Wrap(ErrConcurrentModification, msg)
+func (c *CanceledError) Error() string {
+	return ErrCanceled.Error() + ": " + c.Err.Error()
 }
 
-// ConcurrentTransactionError Thrown when concurrent transaction both attempt to update the same idempotent transaction.
-type ConcurrentTransactionError struct {
-	Msg string
+func (c *CanceledError) Unwrap() error {
+	return c.Err
 }
 
-func (c *ConcurrentTransactionError) Error() string {
-	return c.Msg
+func (c *CanceledError) Is(target error) bool {
+	return target == ErrCanceled
 }
 
-// DeltaStandaloneError Thrown when a query fails, usually because the query itself is invalid.
-type DeltaStandaloneError struct {
-	Msg string
-}
-
-func (c *DeltaStandaloneError) Error() string {
-	return c.Msg
-}
-
-// MetadataChangeError Thrown when the metadata of the Delta table has changed between the time of read and the time of commit.
-type MetadataChangeError struct {
-	Msg string
+// CheckContext returns a CanceledError if the ctx is done.
+func CheckContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Err: err}
+	}
+	return nil
 }
 
-func (c *MetadataChangeError) Error() string {
-	return c.Msg
+// ContextError returns a CanceledError instead of err if the ctx is done, as the operation failed because of the cancellation.
+func ContextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ErrCanceled) {
+		return err
+	}
+	return &CanceledError{Err: ctx.Err()}
 }
 
 type IllegalArgError struct {
@@ -247,3 +234,72 @@ func NullValueFoundForNonNullSchemaField(name string, schema string) error {
 func FileNotFound(msg string) error {
 	return eris.Wrap(ErrFileNotFound, msg)
 }
+
+func VacuumRetentionTooShortError(retention time.Duration, configured time.Duration) error {
+	return eris.Wrap(ErrIllegalArgument, fmt.Sprintf("Are you sure you would like to vacuum files with such a low retention period (%s)? "+
+		"If you have writers that are currently writing to this table, there is a risk that you may corrupt the state of your Delta table. "+
+		"The retention must not be shorter than deletedFileRetentionDuration (%s), "+
+		"set Config.DisableRetentionDurationCheck to turn off this check.", retention, configured))
+}
+
+func UnsupportedReaderFeatureError(feature string) error {
+	return eris.Wrap(ErrUnsupportedOperation, fmt.Sprintf("unsupported reader feature %s of the table", feature))
+}
+
+func UnsupportedWriterFeatureError(feature string) error {
+	return eris.Wrap(ErrUnsupportedOperation, fmt.Sprintf("unsupported writer feature %s of the table", feature))
+}
+
+func UnknownTableFeatureError(feature string) error {
+	return eris.Wrap(ErrIllegalArgument, fmt.Sprintf("unknown table feature %s", feature))
+}
+
+func UnsupportedColumnMappingModeError(mode string) error {
+	return eris.Wrap(ErrIllegalArgument, fmt.Sprintf("unsupported column mapping mode %s", mode))
+}
+
+func ChangeDataFeedNotEnabledError(version int64) error {
+	return eris.Wrap(ErrIllegalState, fmt.Sprintf("change data feed is not enabled on the table at version %d", version))
+}
+
+func InvalidVersionRangeError(start int64, end int64, latest int64) error {
+	return eris.Wrap(ErrIllegalArgument, fmt.Sprintf("invalid version range [%d, %d], the latest version is %d", start, end, latest))
+}
+
+func RestoreMissingFilesError(version int64, files []string) error {
+	return eris.Wrap(ErrFileNotFound, fmt.Sprintf("cannot restore the table to version %d, %d files referenced by the version are missing, "+
+		"they may have been deleted by VACUUM: %s", version, len(files), strings.Join(files, ", ")))
+}
+
+func StreamSourceDeleteError(version int64, path string) error {
+	return eris.Wrap(ErrUnsupportedOperation, fmt.Sprintf("Detected deleted data (for example %s) from streaming source at version %d. "+
+		"This is currently not supported. If you'd like to ignore deletes, set the option IgnoreDeletes.", path, version))
+}
+
+func StreamSourceChangeError(version int64, path string) error {
+	return eris.Wrap(ErrUnsupportedOperation, fmt.Sprintf("Detected a data update (for example %s) in the source table at version %d. "+
+		"This is currently not supported. If you'd like to ignore updates, set the option IgnoreChanges.", path, version))
+}
+
+func StreamSourceSchemaChangeError(version int64) error {
+	return eris.Wrap(ErrIllegalState, fmt.Sprintf("Detected schema change in the source table at version %d, "+
+		"the streaming source must be restarted to read the new schema.", version))
+}
+
+func StreamSourceTableIdMismatchError(offsetTableId string, tableId string) error {
+	return eris.Wrap(ErrIllegalArgument, fmt.Sprintf("the offset of table %s cannot be used for the table %s, "+
+		"the table may have been replaced", offsetTableId, tableId))
+}
+
+func WatchDataLossError(version int64) error {
+	return eris.Wrap(ErrIllegalState, fmt.Sprintf("the delta file of version %d is missing before it is delivered, "+
+		"it may have been deleted by the metadata cleanup", version))
+}
+
+func PostCommitHookError(hook string, version int64, err error) error {
+	return eris.Wrap(err, fmt.Sprintf("committing to the Delta table version %d succeeded but the post-commit hook %s failed", version, hook))
+}
+
+func UnsupportedSidecarPath(path string) error {
+	return eris.Wrap(ErrUnsupportedOperation, fmt.Sprintf("sidecar file %s outside of the _delta_log/_sidecars directory is not supported", path))
+}
@@ -0,0 +1,166 @@
+package deltago
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/op"
+)
+
+// HistoryOptions selects the commits returned by History, the zero value selects all the commits in one page.
+type HistoryOptions struct {
+	// Limit is the maximum number of the commits in a page, all the selected commits are returned if it is not positive.
+	Limit int
+	// StartVersion and EndVersion are the range of the versions (both inclusive).
+	StartVersion mo.Option[int64]
+	EndVersion   mo.Option[int64]
+	// StartTimestamp and EndTimestamp are the range of the commit timestamps in milliseconds (both inclusive).
+	StartTimestamp mo.Option[int64]
+	EndTimestamp   mo.Option[int64]
+	// Operations selects the commits of these operations only, if not empty.
+	Operations []op.Name
+	// EngineInfo selects the commits whose engine info contains it only, if not empty.
+	EngineInfo string
+
+	// commits are the commits up to EndVersion with the monotonized timestamps, which are carried from a page to the next page,
+	// so that the log is only listed for the first page.
+	commits []*commit
+}
+
+// HistoryPage is a page of the history of a table.
+type HistoryPage struct {
+	// Commits are in reverse version order. Their timestamps are the commit timestamps, i.e. the modification times
+	// of the delta files made strictly increasing, as returned by DESCRIBE HISTORY and used by time travel.
+	Commits []*action.CommitInfo
+	// Next are the options of the next page, absent if all the selected commits are returned.
+	Next mo.Option[HistoryOptions]
+}
+
+// History returns the commits of the table selected by the options in reverse version order.
+// Only the delta files which may be selected are read, up to Config.ReplayConcurrency files in parallel.
+func (l *logImpl) History(opts HistoryOptions) (*HistoryPage, error) {
+	return l.HistoryContext(context.Background(), opts)
+}
+
+// HistoryContext is History with a context to cancel the reading of the log.
+func (l *logImpl) HistoryContext(ctx context.Context, opts HistoryOptions) (*HistoryPage, error) {
+	res, err := l.getHistory(ctx, opts)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) getHistory(ctx context.Context, opts HistoryOptions) (*HistoryPage, error) {
+	s, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	earliest, err := l.history.getEarliestDeltaFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := opts.StartVersion.OrElse(earliest)
+	if start < earliest {
+		start = earliest
+	}
+	end := opts.EndVersion.OrElse(s.Version())
+	if end > s.Version() {
+		end = s.Version()
+	}
+	if start > end {
+		return &HistoryPage{Next: mo.None[HistoryOptions]()}, nil
+	}
+
+	// the timestamps are monotonized from the earliest commit, so that they do not depend on the range
+	commits := opts.commits
+	if commits == nil {
+		commits, err = l.history.getCommits(ctx, l.store, l.store.Root(), earliest, end+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var paths []string
+	timestamps := make(map[int64]int64)
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		if c.version < start {
+			break
+		}
+		if c.version > end {
+			continue
+		}
+		if c.timestamp < opts.StartTimestamp.OrElse(c.timestamp) || c.timestamp > opts.EndTimestamp.OrElse(c.timestamp) {
+			continue
+		}
+		paths = append(paths, filenames.DeltaFile(l.store.Root(), c.version))
+		timestamps[c.version] = c.timestamp
+	}
+
+	infos := readFilesInOrder(ctx, paths, l.config.ReplayConcurrency, func(ctx context.Context, path string) ([]*action.CommitInfo, error) {
+		ci, err := l.history.getCommitInfo(ctx, filenames.DeltaVersion(path))
+		if err != nil {
+			return nil, err
+		}
+		return []*action.CommitInfo{ci}, nil
+	})
+	defer infos.Close()
+
+	res := &HistoryPage{Next: mo.None[HistoryOptions]()}
+	read := 0
+	for opts.Limit <= 0 || len(res.Commits) < opts.Limit {
+		ci, err := infos.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		read++
+
+		if !historySelects(opts, ci[0]) {
+			continue
+		}
+		ci[0].Timestamp = timestamps[ci[0].GetVersion()]
+		res.Commits = append(res.Commits, ci[0])
+	}
+
+	if read < len(paths) {
+		next := opts
+		nextEnd := res.Commits[len(res.Commits)-1].GetVersion() - 1
+		next.EndVersion = mo.Some(nextEnd)
+		next.commits = commits
+		for len(next.commits) > 0 && next.commits[len(next.commits)-1].version > nextEnd {
+			next.commits = next.commits[:len(next.commits)-1]
+		}
+		res.Next = mo.Some(next)
+	}
+	return res, nil
+}
+
+func historySelects(opts HistoryOptions, ci *action.CommitInfo) bool {
+	if len(opts.Operations) > 0 {
+		selected := false
+		for _, name := range opts.Operations {
+			if ci.Operation == name.String() {
+				selected = true
+				break
+			}
+		}
+		if !selected {
+			return false
+		}
+	}
+	if len(opts.EngineInfo) > 0 && (ci.EngineInfo == nil || !strings.Contains(*ci.EngineInfo, opts.EngineInfo)) {
+		return false
+	}
+	return true
+}
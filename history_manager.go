@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"io"
 	"math"
 
@@ -17,15 +18,16 @@ type historyManager struct {
 	logStore store.Store
 }
 
-func (h *historyManager) getCommitInfo(version int64) (*action.CommitInfo, error) {
-	iter, err := h.logStore.Read(filenames.DeltaFile(h.logStore.Root(), version))
+func (h *historyManager) getCommitInfo(ctx context.Context, version int64) (*action.CommitInfo, error) {
+	iter, err := h.logStore.Read(ctx, filenames.DeltaFile(h.logStore.Root(), version))
 	if err != nil {
 		return nil, err
 	}
 	defer iter.Close()
 
 	var c *action.CommitInfo
-	for v, err := iter.Next(); err == nil; v, err = iter.Next() {
+	var v string
+	for v, err = iter.Next(); err == nil; v, err = iter.Next() {
 		action, err := action.FromJson(v)
 		if err != nil {
 			return nil, err
@@ -47,13 +49,13 @@ func (h *historyManager) getCommitInfo(version int64) (*action.CommitInfo, error
 	}
 }
 
-func (h *historyManager) checkVersionExists(versionToCkeck int64, sr *SnapshotReader) error {
-	earliestVersion, err := h.getEarliestReproducibleCommitVersion()
+func (h *historyManager) checkVersionExists(ctx context.Context, versionToCkeck int64, sr *SnapshotReader) error {
+	earliestVersion, err := h.getEarliestReproducibleCommitVersion(ctx)
 	if err != nil {
 		return err
 	}
 
-	s, err := sr.update()
+	s, err := sr.update(ctx)
 	if err != nil {
 		return err
 	}
@@ -65,28 +67,28 @@ func (h *historyManager) checkVersionExists(versionToCkeck int64, sr *SnapshotRe
 	return nil
 }
 
-func (h *historyManager) getActiveCommitAtTime(sr *SnapshotReader, timestamp int64,
+func (h *historyManager) getActiveCommitAtTime(ctx context.Context, sr *SnapshotReader, timestamp int64,
 	canReturnLastCommit bool, mustBeRecreatable bool, canReturnEarliestCommit bool) (*commit, error) {
 
 	timeInMill := timestamp
 	var earliestVersion int64
 	var err error
 	if mustBeRecreatable {
-		earliestVersion, err = h.getEarliestReproducibleCommitVersion()
+		earliestVersion, err = h.getEarliestReproducibleCommitVersion(ctx)
 	} else {
-		earliestVersion, err = h.getEarliestDeltaFile()
+		earliestVersion, err = h.getEarliestDeltaFile(ctx)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	s, err := sr.update()
+	s, err := sr.update(ctx)
 	if err != nil {
 		return nil, err
 	}
 	latestVersion := s.Version()
 
-	commits, err := h.getCommits(h.logStore, h.logStore.Root(), earliestVersion, latestVersion+1)
+	commits, err := h.getCommits(ctx, h.logStore, h.logStore.Root(), earliestVersion, latestVersion+1)
 	if err != nil {
 		return nil, err
 	}
@@ -103,16 +105,16 @@ func (h *historyManager) getActiveCommitAtTime(sr *SnapshotReader, timestamp int
 	return commit, nil
 }
 
-func (h *historyManager) getEarliestDeltaFile() (int64, error) {
+func (h *historyManager) getEarliestDeltaFile(ctx context.Context) (int64, error) {
 	version0 := filenames.DeltaFile(h.logStore.Root(), 0)
-	iter, err := h.logStore.ListFrom(version0)
+	iter, err := h.logStore.ListFrom(ctx, version0)
 	if err != nil {
 		return 0, err
 	}
 	defer iter.Close()
 
-	var earliestVersionOpt *store.FileMeta
-	for v, err := iter.Next(); err == nil; v, err = iter.Next() {
+	var earliestVersionOpt, v *store.FileMeta
+	for v, err = iter.Next(); err == nil; v, err = iter.Next() {
 		if filenames.IsDeltaFile(v.Path()) {
 			earliestVersionOpt = v
 			break
@@ -127,16 +129,17 @@ func (h *historyManager) getEarliestDeltaFile() (int64, error) {
 	return filenames.DeltaVersion(earliestVersionOpt.Path()), nil
 }
 
-func (h *historyManager) getEarliestReproducibleCommitVersion() (int64, error) {
+func (h *historyManager) getEarliestReproducibleCommitVersion(ctx context.Context) (int64, error) {
 
-	iter, err := h.logStore.ListFrom(filenames.DeltaFile(h.logStore.Root(), 0))
+	iter, err := h.logStore.ListFrom(ctx, filenames.DeltaFile(h.logStore.Root(), 0))
 	if err != nil {
 		return 0, err
 	}
 	defer iter.Close()
 
 	var files []*store.FileMeta
-	for f, err := iter.Next(); err == nil; f, err = iter.Next() {
+	var f *store.FileMeta
+	for f, err = iter.Next(); err == nil; f, err = iter.Next() {
 		if filenames.IsCheckpointFile(f.Path()) || filenames.IsDeltaFile(f.Path()) {
 			files = append(files, f)
 		}
@@ -202,15 +205,16 @@ func (h *historyManager) getLastCommitBeforeTimestamp(commits []*commit, timeInM
 	return mo.Some(commits[i])
 }
 
-func (h *historyManager) getCommits(logStore store.Store, logPath string, start int64, end int64) ([]*commit, error) {
-	iter, err := logStore.ListFrom(filenames.DeltaFile(logPath, start))
+func (h *historyManager) getCommits(ctx context.Context, logStore store.Store, logPath string, start int64, end int64) ([]*commit, error) {
+	iter, err := logStore.ListFrom(ctx, filenames.DeltaFile(logPath, start))
 	if err != nil {
 		return nil, err
 	}
 	defer iter.Close()
 
 	var commits []*commit
-	for f, err := iter.Next(); err == nil; f, err = iter.Next() {
+	var f *store.FileMeta
+	for f, err = iter.Next(); err == nil; f, err = iter.Next() {
 		if filenames.IsDeltaFile(f.Path()) {
 			c := &commit{version: filenames.DeltaVersion(f.Path()), timestamp: f.TimeModified().UnixMilli()}
 			if c.version < end {
@@ -224,24 +228,20 @@ func (h *historyManager) getCommits(logStore store.Store, logPath string, start
 		return nil, err
 	}
 
-	return commits, nil
+	return h.monotonizeCommitTimestamps(commits), nil
 }
 
-// func (h *historyManager) monotonizeCommitTimestamps(commits []action.CommitMarker) []action.CommitMarker {
-// 	i := 0
-// 	length := len(commits)
-// 	for i < length-1 {
-// 		prevTimestamp := commits[i].GetTimestamp()
-// 		if commits[i].GetVersion() > commits[i+1].GetVersion() {
-// 			panic("Unordered commits provided.")
-// 		}
-// 		if prevTimestamp >= commits[i+1].GetTimestamp() {
-// 			commits[i+1] = commits[i+1].WithTimestamp(prevTimestamp + 1)
-// 		}
-// 		i += 1
-// 	}
-// 	return commits
-// }
+// monotonizeCommitTimestamps makes the timestamps of the commits in version order strictly increasing,
+// as the modification times of the delta files may go backwards, e.g. by clock skew or copying the table.
+// A timestamp which is not greater than the previous one is replaced by the previous one plus 1.
+func (h *historyManager) monotonizeCommitTimestamps(commits []*commit) []*commit {
+	for i := 1; i < len(commits); i++ {
+		if commits[i].timestamp <= commits[i-1].timestamp {
+			commits[i] = commits[i].WithTimestamp(commits[i-1].timestamp + 1)
+		}
+	}
+	return commits
+}
 
 type commit struct {
 	version   int64
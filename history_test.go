@@ -0,0 +1,140 @@
+package deltago
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/op"
+	"github.com/csimplestring/delta-go/store"
+)
+
+func TestLog_history(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	tt.config.ReplayConcurrency = 3
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	commit := func(name op.Name, engineInfo string, actions ...action.Action) {
+		trx, err := log.StartTransaction()
+		assert.NoError(t, err)
+		_, err = trx.Commit(iter.FromSlice(actions), &op.Operation{Name: name}, engineInfo)
+		assert.NoError(t, err)
+	}
+
+	commit(op.CREATETABLE, "spark", getTestMetedata())
+	commit(op.WRITE, "spark", testAddFile("a"))
+	commit(op.WRITE, "delta-go/1", testAddFile("b"))
+	commit(op.DELETE, "spark", &action.RemoveFile{Path: "a", DataChange: true})
+	commit(op.WRITE, "delta-go/2", testAddFile("c"))
+
+	// the delta file of version 2 is older than version 1, its timestamp is made greater than the one of version 1
+	base := time.UnixMilli(1_600_000_000_000)
+	logDir := strings.TrimPrefix(log.(*logImpl).logPath, "file://")
+	for v, offset := range []int64{0, 1000, 500, 3000, 4000} {
+		mtime := base.Add(time.Duration(offset) * time.Millisecond)
+		assert.NoError(t, os.Chtimes(filepath.Join(logDir, filenames.DeltaFile("", int64(v))), mtime, mtime))
+	}
+	ts := func(offset int64) int64 {
+		return base.UnixMilli() + offset
+	}
+
+	versionsAndTimestamps := func(page *HistoryPage) ([]int64, []int64) {
+		var versions, timestamps []int64
+		for _, c := range page.Commits {
+			versions = append(versions, c.GetVersion())
+			timestamps = append(timestamps, c.Timestamp)
+		}
+		return versions, timestamps
+	}
+
+	page, err := log.History(HistoryOptions{})
+	assert.NoError(t, err)
+	versions, timestamps := versionsAndTimestamps(page)
+	assert.Equal(t, []int64{4, 3, 2, 1, 0}, versions)
+	assert.Equal(t, []int64{ts(4000), ts(3000), ts(1001), ts(1000), ts(0)}, timestamps)
+	assert.True(t, page.Next.IsAbsent())
+	assert.Equal(t, op.DELETE.String(), page.Commits[1].Operation)
+	assert.Equal(t, "delta-go/2", *page.Commits[0].EngineInfo)
+
+	// the time travel uses the same timestamps
+	v, err := log.VersionBeforeOrAtTimestamp(ts(1001))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), v)
+
+	// paginated, the log is only listed for the first page
+	l := log.(*logImpl)
+	listing := &countingListStore{Store: l.store}
+	l.store = listing
+	var all []int64
+	opts := HistoryOptions{Limit: 2}
+	for {
+		page, err := log.History(opts)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Commits), 2)
+		versions, _ := versionsAndTimestamps(page)
+		all = append(all, versions...)
+
+		next, ok := page.Next.Get()
+		if !ok {
+			break
+		}
+		opts = next
+	}
+	assert.Equal(t, []int64{4, 3, 2, 1, 0}, all)
+	assert.Equal(t, 1, listing.lists)
+	l.store = listing.Store
+
+	cases := []struct {
+		name     string
+		opts     HistoryOptions
+		expected []int64
+	}{
+		{"version range", HistoryOptions{StartVersion: mo.Some[int64](1), EndVersion: mo.Some[int64](3)}, []int64{3, 2, 1}},
+		{"version range out of the table", HistoryOptions{StartVersion: mo.Some[int64](-5), EndVersion: mo.Some[int64](10)}, []int64{4, 3, 2, 1, 0}},
+		{"empty version range", HistoryOptions{StartVersion: mo.Some[int64](3), EndVersion: mo.Some[int64](2)}, nil},
+		{"timestamp range", HistoryOptions{StartTimestamp: mo.Some(ts(1001)), EndTimestamp: mo.Some(ts(3000))}, []int64{3, 2}},
+		{"operations", HistoryOptions{Operations: []op.Name{op.DELETE, op.CREATETABLE}}, []int64{3, 0}},
+		{"engine info", HistoryOptions{EngineInfo: "delta-go"}, []int64{4, 2}},
+		{"limit", HistoryOptions{Limit: 1, Operations: []op.Name{op.WRITE}, EndVersion: mo.Some[int64](3)}, []int64{2}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			page, err := log.History(c.opts)
+			assert.NoError(t, err)
+			versions, _ := versionsAndTimestamps(page)
+			assert.Equal(t, c.expected, versions)
+		})
+	}
+
+	page, err = log.History(HistoryOptions{Limit: 1, Operations: []op.Name{op.WRITE}, EndVersion: mo.Some[int64](3)})
+	assert.NoError(t, err)
+	next, ok := page.Next.Get()
+	assert.True(t, ok)
+	assert.Equal(t, mo.Some[int64](1), next.EndVersion)
+	page, err = log.History(next)
+	assert.NoError(t, err)
+	versions, _ = versionsAndTimestamps(page)
+	assert.Equal(t, []int64{1}, versions)
+}
+
+type countingListStore struct {
+	store.Store
+	lists int
+}
+
+func (c *countingListStore) ListFrom(ctx context.Context, path string) (iter.Iter[*store.FileMeta], error) {
+	c.lists++
+	return c.Store.ListFrom(ctx, path)
+}
@@ -11,8 +11,12 @@ import (
 	"github.com/samber/mo"
 )
 
-var checkpointFilePattern = regexp.MustCompile("\\d+\\.checkpoint(\\.\\d+\\.\\d+)?\\.parquet")
-var deltaFilePattern = regexp.MustCompile("\\d+\\.json")
+var checkpointFilePattern = regexp.MustCompile("^\\d+\\.checkpoint(\\.\\d+\\.\\d+)?\\.parquet$")
+var v2CheckpointFilePattern = regexp.MustCompile("^\\d+\\.checkpoint\\.[0-9a-fA-F-]{36}\\.(json|parquet)$")
+var deltaFilePattern = regexp.MustCompile("^\\d+\\.json$")
+
+// SidecarDir is the directory of the sidecar files of V2 checkpoints, relative to the log directory.
+const SidecarDir = "_sidecars/"
 
 func DeltaFile(path string, version int64) string {
 	return path + fmt.Sprintf("%020d.json", version)
@@ -24,9 +28,15 @@ func CheckpointVersion(path string) int64 {
 	return v
 }
 
+// IsCheckpointFile checks if the path is a classic checkpoint file (single or multi-part) or the top level file of a V2 checkpoint.
 func IsCheckpointFile(path string) bool {
 	path = filepath.Base(path)
-	return checkpointFilePattern.MatchString(path)
+	return checkpointFilePattern.MatchString(path) || v2CheckpointFilePattern.MatchString(path)
+}
+
+// IsV2CheckpointFile checks if the path is the top level file of a V2 checkpoint, named by an UUID.
+func IsV2CheckpointFile(path string) bool {
+	return v2CheckpointFilePattern.MatchString(filepath.Base(path))
 }
 
 func DeltaVersion(path string) int64 {
@@ -77,3 +87,13 @@ func CheckpointFileWithParts(dir string, version int64, numParts int) []string {
 	}
 	return res
 }
+
+// V2CheckpointFile returns the path of the top level file of a V2 checkpoint, the format is either json or parquet.
+func V2CheckpointFile(dir string, version int64, uuid string, format string) string {
+	return dir + fmt.Sprintf("%020d.checkpoint.%s.%s", version, uuid, format)
+}
+
+// SidecarFile returns the path of the sidecar file with the name relative to the sidecar directory.
+func SidecarFile(dir string, name string) string {
+	return dir + SidecarDir + name
+}
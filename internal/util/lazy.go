@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 
@@ -12,17 +13,18 @@ type Lazy[T any] struct {
 	done  uint32
 	value T
 	err   error
-	eval  func() (T, error)
+	eval  func(ctx context.Context) (T, error)
 }
 
-func LazyValue[T any](f func() (T, error)) *Lazy[T] {
+func LazyValue[T any](f func(ctx context.Context) (T, error)) *Lazy[T] {
 	l := &Lazy[T]{eval: f}
 	return l
 }
 
 // Get runs the specified function only once, but all callers gets the same
 // result from that single execution.
-func (o *Lazy[T]) Get() (T, error) {
+// If the function fails because the ctx is done, the result is not kept and the next caller runs it again.
+func (o *Lazy[T]) Get(ctx context.Context) (T, error) {
 	if atomic.LoadUint32(&o.done) == 1 {
 		if o.err != nil {
 			o.err = eris.Wrap(o.err, "")
@@ -33,8 +35,15 @@ func (o *Lazy[T]) Get() (T, error) {
 	o.m.Lock()
 	defer o.m.Unlock()
 	if o.done == 0 {
-		defer atomic.StoreUint32(&o.done, 1)
-		o.value, o.err = o.eval()
+		value, err := o.eval(ctx)
+		if err != nil && ctx.Err() != nil {
+			var zero T
+			return zero, err
+		}
+		o.value, o.err = value, err
+		// release everything captured by the function
+		o.eval = nil
+		atomic.StoreUint32(&o.done, 1)
 	}
 
 	if o.err != nil {
@@ -42,3 +51,12 @@ func (o *Lazy[T]) Get() (T, error) {
 	}
 	return o.value, o.err
 }
+
+// Peek returns the value if it is already evaluated successfully, it never runs the function.
+func (o *Lazy[T]) Peek() (T, bool) {
+	if atomic.LoadUint32(&o.done) == 1 && o.err == nil {
+		return o.value, true
+	}
+	var zero T
+	return zero, false
+}
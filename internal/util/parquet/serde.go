@@ -123,6 +123,12 @@ func MarshalMap(obj interfaces.MarshalObject, fieldName string, m map[string]str
 	if m == nil {
 		return nil
 	}
+	// an empty map is written as a group without key_value entries, otherwise
+	// the parquet writer treats the required key of the repeated group as missing.
+	if len(m) == 0 {
+		obj.AddField(fieldName).Group()
+		return nil
+	}
 	mo := obj.AddField(fieldName).Map()
 	for k, v := range m {
 		elem := mo.Add()
@@ -0,0 +1,63 @@
+// Package z85 implements the Z85 encoding (https://rfc.zeromq.org/spec/32/) used by Delta to
+// encode inline deletion vectors and the UUIDs of deletion vector files.
+package z85
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+
+var decodeTable = func() [256]int {
+	var t [256]int
+	for i := range t {
+		t[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		t[alphabet[i]] = i
+	}
+	return t
+}()
+
+// Encode encodes the data, padding it with zeros to a multiple of 4 bytes.
+func Encode(data []byte) string {
+	padded := make([]byte, (len(data)+3)/4*4)
+	copy(padded, data)
+
+	res := make([]byte, 0, len(padded)/4*5)
+	var chunk [5]byte
+	for i := 0; i < len(padded); i += 4 {
+		v := binary.BigEndian.Uint32(padded[i : i+4])
+		for j := 4; j >= 0; j-- {
+			chunk[j] = alphabet[v%85]
+			v /= 85
+		}
+		res = append(res, chunk[:]...)
+	}
+	return string(res)
+}
+
+// Decode decodes the string, its length must be a multiple of 5.
+func Decode(s string) ([]byte, error) {
+	if len(s)%5 != 0 {
+		return nil, fmt.Errorf("z85: invalid length %d, must be a multiple of 5", len(s))
+	}
+
+	res := make([]byte, len(s)/5*4)
+	for i := 0; i < len(s); i += 5 {
+		var v uint64
+		for j := 0; j < 5; j++ {
+			d := decodeTable[s[i+j]]
+			if d < 0 {
+				return nil, fmt.Errorf("z85: invalid character %q at %d", s[i+j], i+j)
+			}
+			v = v*85 + uint64(d)
+		}
+		if v > 0xFFFFFFFF {
+			return nil, fmt.Errorf("z85: invalid chunk at %d", i)
+		}
+		binary.BigEndian.PutUint32(res[i/5*4:], uint32(v))
+	}
+	return res, nil
+}
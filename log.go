@@ -1,13 +1,17 @@
 package deltago
 
 import (
+	"context"
 	"io"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/samber/mo"
 	"gocloud.dev/blob"
 
 	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/deletionvector"
 	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util/filenames"
 	"github.com/csimplestring/delta-go/iter"
@@ -17,6 +21,9 @@ import (
 
 // Log Represents the transaction logs of a Delta table.
 // It provides APIs to access the states of a Delta table.
+//
+// The methods suffixed with Context accept a context to cancel the I/O of the call,
+// a canceled call returns an error matching errno.ErrCanceled and the context error.
 type Log interface {
 
 	// Snapshot the current Snapshot of the Delta table.
@@ -25,33 +32,112 @@ type Log interface {
 
 	Update() (Snapshot, error)
 
+	UpdateContext(ctx context.Context) (Snapshot, error)
+
 	SnapshotForVersionAsOf(version int64) (Snapshot, error)
 
+	SnapshotForVersionAsOfContext(ctx context.Context, version int64) (Snapshot, error)
+
 	SnapshotForTimestampAsOf(timestamp int64) (Snapshot, error)
 
+	SnapshotForTimestampAsOfContext(ctx context.Context, timestamp int64) (Snapshot, error)
+
 	StartTransaction() (OptimisticTransaction, error)
 
+	StartTransactionContext(ctx context.Context) (OptimisticTransaction, error)
+
 	CommitInfoAt(version int64) (*action.CommitInfo, error)
 
+	CommitInfoAtContext(ctx context.Context, version int64) (*action.CommitInfo, error)
+
+	// History returns the commits of the table selected by the options in reverse version order, one page at a time.
+	// The timestamps of the commits are the commit timestamps used by time travel.
+	History(opts HistoryOptions) (*HistoryPage, error)
+
+	HistoryContext(ctx context.Context, opts HistoryOptions) (*HistoryPage, error)
+
 	Path() string
 
 	// Get all actions starting from startVersion (inclusive) in increasing order of committed version.
 	// If startVersion doesn't exist, return an empty Iterator.
 	Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[VersionLog], error)
 
+	// ChangesContext is Changes with a context, the context is also used to read the actions of the returned VersionLogs.
+	ChangesContext(ctx context.Context, startVersion int64, failOnDataLoss bool) (iter.Iter[VersionLog], error)
+
 	// Returns the latest version that was committed before or at timestamp. If no version exists, returns -1. Specifically:
 	// if a commit version exactly matches the provided timestamp, we return it
 	// else, we return the latest commit version with a timestamp less than the provided one
 	// If the provided timestamp is less than the timestamp of any committed version, we throw an error.
 	VersionBeforeOrAtTimestamp(timestamp int64) (int64, error)
 
+	VersionBeforeOrAtTimestampContext(ctx context.Context, timestamp int64) (int64, error)
+
 	// Returns the latest version that was committed at or after timestamp. If no version exists, returns -1. Specifically:
 	// if a commit version exactly matches the provided timestamp, we return it
 	// else, we return the earliest commit version with a timestamp greater than the provided one
 	// If the provided timestamp is larger than the timestamp of any committed version, we throw an error.
 	VersionAtOrAfterTimestamp(timestamp int64) (int64, error)
 
+	VersionAtOrAfterTimestampContext(ctx context.Context, timestamp int64) (int64, error)
+
 	TableExists() bool
+
+	// Vacuum deletes the files in the table directory which are no longer referenced by the table
+	// and are older than the retention. If retention is absent, the table's deletedFileRetentionDuration is used.
+	// When dryRun is true nothing is deleted, the files which would be deleted are returned instead.
+	Vacuum(retention mo.Option[time.Duration], dryRun bool) (*VacuumResult, error)
+
+	VacuumContext(ctx context.Context, retention mo.Option[time.Duration], dryRun bool) (*VacuumResult, error)
+
+	// Restore restores the table to the version by committing a RESTORE, which removes the files added after the version
+	// and adds back the files removed after it, together with the metadata of the version.
+	// It fails if any data file of the version has been deleted, e.g. by Vacuum.
+	Restore(version int64) (*RestoreResult, error)
+
+	RestoreContext(ctx context.Context, version int64) (*RestoreResult, error)
+
+	// RestoreToTimestamp restores the table to the version active at the timestamp in milliseconds, see Restore.
+	RestoreToTimestamp(timestamp int64) (*RestoreResult, error)
+
+	RestoreToTimestampContext(ctx context.Context, timestamp int64) (*RestoreResult, error)
+
+	// Diff returns the files added and removed, and the metadata and protocol changed from fromVersion to toVersion.
+	// It is computed from the commits in the range if they are still in the log, otherwise from the snapshots of the two versions.
+	Diff(fromVersion int64, toVersion int64) (*TableDiff, error)
+
+	DiffContext(ctx context.Context, fromVersion int64, toVersion int64) (*TableDiff, error)
+
+	// StreamSource creates a source which returns the files added to the table batch by batch,
+	// with offsets to resume it, like Spark's Delta streaming source.
+	StreamSource(opts StreamSourceOptions) (*StreamSource, error)
+
+	StreamSourceContext(ctx context.Context, opts StreamSourceOptions) (*StreamSource, error)
+
+	// Watch delivers the new versions of the table in order and without gaps until the context is done,
+	// by polling the log with backoff, or by file notifications for file:// tables.
+	Watch(ctx context.Context, opts WatchOptions) (<-chan WatchEvent, error)
+
+	// RegisterPostCommitHook registers a hook which runs after the commits of the transactions started later.
+	// The hooks run after the built-in checkpoint hook in the order of registration, their errors do not fail the commits.
+	RegisterPostCommitHook(hook PostCommitHook)
+
+	// LoadDeletionVector loads the deletion vector of a data file, i.e. the indexes of the deleted rows.
+	LoadDeletionVector(dv *action.DeletionVectorDescriptor) (*deletionvector.RoaringBitmapArray, error)
+
+	LoadDeletionVectorContext(ctx context.Context, dv *action.DeletionVectorDescriptor) (*deletionvector.RoaringBitmapArray, error)
+
+	// TableChanges returns the change data feed between startVersion and endVersion (both inclusive) in increasing order of version.
+	// For each version, the change data files are returned if the commit has any, otherwise the added and removed data files
+	// are returned as inserts and deletes. The change data feed must be enabled for the whole range.
+	TableChanges(startVersion int64, endVersion int64) (iter.Iter[*ChangeFile], error)
+
+	TableChangesContext(ctx context.Context, startVersion int64, endVersion int64) (iter.Iter[*ChangeFile], error)
+
+	// TableChangesForTimestamps returns the change data feed of the versions committed between startTimestamp and endTimestamp (both inclusive).
+	TableChangesForTimestamps(startTimestamp int64, endTimestamp int64) (iter.Iter[*ChangeFile], error)
+
+	TableChangesForTimestampsContext(ctx context.Context, startTimestamp int64, endTimestamp int64) (iter.Iter[*ChangeFile], error)
 }
 
 func getLogPath(dataPath string) string {
@@ -59,6 +145,16 @@ func getLogPath(dataPath string) string {
 	return logPath
 }
 
+func newLogStore(logPath string, config Config, m *blob.URLMux) (store.Store, error) {
+	if config.CommitLockStore != nil {
+		return store.NewWithCommitLock(logPath, m, config.CommitLockStore)
+	}
+	if config.S3ConditionalWrite {
+		return store.NewWithConditionalWrite(logPath, m)
+	}
+	return store.New(logPath, m)
+}
+
 // ForTableWithMux creates a DeltaLog instance representing the table located at the provided path using a given Mux.
 func ForTableWithMux(dataPath string, config Config, clock Clock, m *blob.URLMux) (Log, error) {
 	logPath := getLogPath(dataPath)
@@ -66,7 +162,7 @@ func ForTableWithMux(dataPath string, config Config, clock Clock, m *blob.URLMux
 	deltaLogLock := &sync.Mutex{}
 	var logStore store.Store
 
-	logStore, err := store.New(logPath, m)
+	logStore, err := newLogStore(logPath, config, m)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +173,7 @@ func ForTableWithMux(dataPath string, config Config, clock Clock, m *blob.URLMux
 	}
 
 	historyManager := &historyManager{logStore: logStore}
-	snaptshotManager, err := newSnapshotReader(config, parquetReader, logStore, clock, historyManager, deltaLogLock)
+	snaptshotManager, err := newSnapshotReader(context.Background(), config, parquetReader, logStore, clock, historyManager, deltaLogLock)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +181,8 @@ func ForTableWithMux(dataPath string, config Config, clock Clock, m *blob.URLMux
 	logImpl := &logImpl{
 		dataPath:         dataPath,
 		logPath:          logPath,
+		config:           config,
+		mux:              m,
 		clock:            clock,
 		store:            logStore,
 		deltaLogLock:     deltaLogLock,
@@ -103,7 +201,7 @@ func ForTable(dataPath string, config Config, clock Clock) (Log, error) {
 	deltaLogLock := &sync.Mutex{}
 	var logStore store.Store
 
-	logStore, err := store.New(logPath, nil)
+	logStore, err := newLogStore(logPath, config, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -114,19 +212,21 @@ func ForTable(dataPath string, config Config, clock Clock) (Log, error) {
 	}
 
 	historyManager := &historyManager{logStore: logStore}
-	snaptshotManager, err := newSnapshotReader(config, parquetReader, logStore, clock, historyManager, deltaLogLock)
+	snaptshotManager, err := newSnapshotReader(context.Background(), config, parquetReader, logStore, clock, historyManager, deltaLogLock)
 	if err != nil {
 		return nil, err
 	}
 
 	logImpl := &logImpl{
-		dataPath:       dataPath,
-		logPath:        logPath,
-		clock:          clock,
-		store:          logStore,
-		deltaLogLock:   deltaLogLock,
-		history:        historyManager,
-		snapshotReader: snaptshotManager,
+		dataPath:         dataPath,
+		logPath:          logPath,
+		config:           config,
+		clock:            clock,
+		store:            logStore,
+		deltaLogLock:     deltaLogLock,
+		history:          historyManager,
+		snapshotReader:   snaptshotManager,
+		checkpointReader: &parquetReader,
 	}
 
 	return logImpl, nil
@@ -175,12 +275,17 @@ func ForTable(dataPath string, config Config, clock Clock) (Log, error) {
 type logImpl struct {
 	dataPath         string
 	logPath          string
+	config           Config
+	mux              *blob.URLMux
 	clock            Clock
 	store            store.Store
 	deltaLogLock     *sync.Mutex
 	history          *historyManager
 	snapshotReader   *SnapshotReader
 	checkpointReader *checkpointReader
+
+	hooksLock       sync.RWMutex
+	postCommitHooks []PostCommitHook
 }
 
 // Snapshot the current Snapshot of the Delta table.
@@ -190,33 +295,75 @@ func (l *logImpl) Snapshot() (Snapshot, error) {
 }
 
 func (l *logImpl) Update() (Snapshot, error) {
-	return l.snapshotReader.update()
+	return l.UpdateContext(context.Background())
+}
+
+func (l *logImpl) UpdateContext(ctx context.Context) (Snapshot, error) {
+	s, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return s, nil
 }
 
 func (l *logImpl) SnapshotForVersionAsOf(version int64) (Snapshot, error) {
-	return l.snapshotReader.getSnapshotForVersionAsOf(version)
+	return l.SnapshotForVersionAsOfContext(context.Background(), version)
+}
+
+func (l *logImpl) SnapshotForVersionAsOfContext(ctx context.Context, version int64) (Snapshot, error) {
+	s, err := l.snapshotReader.getSnapshotForVersionAsOf(ctx, version)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return s, nil
 }
 
 func (l *logImpl) SnapshotForTimestampAsOf(timestamp int64) (Snapshot, error) {
-	return l.snapshotReader.getSnapshotForTimestampAsOf(timestamp)
+	return l.SnapshotForTimestampAsOfContext(context.Background(), timestamp)
+}
+
+func (l *logImpl) SnapshotForTimestampAsOfContext(ctx context.Context, timestamp int64) (Snapshot, error) {
+	s, err := l.snapshotReader.getSnapshotForTimestampAsOf(ctx, timestamp)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return s, nil
 }
 
 func (l *logImpl) StartTransaction() (OptimisticTransaction, error) {
-	snapshot, err := l.snapshotReader.update()
+	return l.StartTransactionContext(context.Background())
+}
+
+// StartTransactionContext starts a transaction on the latest snapshot, the context is only used to update the snapshot.
+func (l *logImpl) StartTransactionContext(ctx context.Context) (OptimisticTransaction, error) {
+	snapshot, err := l.snapshotReader.update(ctx)
 	if err != nil {
-		return nil, err
+		return nil, errno.ContextError(ctx, err)
+	}
+	trx := newOptimisticTransaction(snapshot,
+		l.snapshotReader, l.clock, nil, l.deltaLogLock, l.store, l.logPath)
+	trx.postCommitHooks = l.registeredPostCommitHooks()
+	if policy, ok := l.config.RetryPolicy.Get(); ok {
+		trx.retryPolicy = policy
 	}
-	return newOptimisticTransaction(snapshot,
-		l.snapshotReader, l.clock, nil, l.deltaLogLock, l.store, l.logPath), nil
+	return trx, nil
 }
 
 func (l *logImpl) CommitInfoAt(version int64) (*action.CommitInfo, error) {
+	return l.CommitInfoAtContext(context.Background(), version)
+}
 
-	if err := l.history.checkVersionExists(version, l.snapshotReader); err != nil {
-		return nil, err
+func (l *logImpl) CommitInfoAtContext(ctx context.Context, version int64) (*action.CommitInfo, error) {
+
+	if err := l.history.checkVersionExists(ctx, version, l.snapshotReader); err != nil {
+		return nil, errno.ContextError(ctx, err)
 	}
 
-	return l.history.getCommitInfo(version)
+	c, err := l.history.getCommitInfo(ctx, version)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return c, nil
 }
 
 func (l *logImpl) Path() string {
@@ -226,15 +373,28 @@ func (l *logImpl) Path() string {
 // Changes Get all actions starting from startVersion (inclusive) in increasing order of committed version.
 // If startVersion doesn't exist, return an empty Iterator.
 func (l *logImpl) Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[VersionLog], error) {
+	return l.ChangesContext(context.Background(), startVersion, failOnDataLoss)
+}
+
+// ChangesContext is Changes with a context, the context is also used to read the actions of the returned VersionLogs.
+func (l *logImpl) ChangesContext(ctx context.Context, startVersion int64, failOnDataLoss bool) (iter.Iter[VersionLog], error) {
+	res, err := l.changes(ctx, startVersion, failOnDataLoss)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) changes(ctx context.Context, startVersion int64, failOnDataLoss bool) (iter.Iter[VersionLog], error) {
 	if startVersion < 0 {
 		return nil, eris.Wrap(errno.ErrIllegalArgument, "invalid startVersion")
 	}
 	var fs iter.Iter[*store.FileMeta]
 	checkpointIncluded := true
-	fs, err := l.store.ListFrom(filenames.CheckpointFileSingular("", startVersion))
+	fs, err := l.store.ListFrom(ctx, filenames.CheckpointPrefix("", startVersion))
 	if err != nil {
 		checkpointIncluded = false
-		fs, err = l.store.ListFrom(filenames.DeltaFile("", startVersion))
+		fs, err = l.store.ListFrom(ctx, filenames.DeltaFile("", startVersion))
 		if err != nil {
 			return nil, err
 		}
@@ -242,7 +402,8 @@ func (l *logImpl) Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[Ve
 	defer fs.Close()
 
 	var deltaPaths []string
-	for f, err := fs.Next(); err == nil; f, err = fs.Next() {
+	var f *store.FileMeta
+	for f, err = fs.Next(); err == nil; f, err = fs.Next() {
 		p := f.Path()
 		if checkpointIncluded && filenames.IsCheckpointFile(p) {
 			deltaPaths = append(deltaPaths, p)
@@ -261,6 +422,7 @@ func (l *logImpl) Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[Ve
 		if filenames.IsCheckpointFile(deltaPath) {
 			version := filenames.CheckpointVersion(deltaPath)
 			versionLogs[i] = &MemOptimizedCheckpoint{
+				ctx:     ctx,
 				version: version,
 				path:    deltaPath,
 				store:   l.store,
@@ -275,6 +437,7 @@ func (l *logImpl) Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[Ve
 		lastSeenVersion = version
 
 		versionLogs[i] = &MemOptimizedVersionLog{
+			ctx:     ctx,
 			version: version,
 			path:    deltaPath,
 			store:   l.store,
@@ -289,6 +452,10 @@ func (l *logImpl) Changes(startVersion int64, failOnDataLoss bool) (iter.Iter[Ve
 // else, we return the latest commit version with a timestamp less than the provided one
 // If the provided timestamp is less than the timestamp of any committed version, we throw an error.
 func (l *logImpl) VersionBeforeOrAtTimestamp(timestamp int64) (int64, error) {
+	return l.VersionBeforeOrAtTimestampContext(context.Background(), timestamp)
+}
+
+func (l *logImpl) VersionBeforeOrAtTimestampContext(ctx context.Context, timestamp int64) (int64, error) {
 	if !l.TableExists() {
 		return -1, nil
 	}
@@ -297,13 +464,13 @@ func (l *logImpl) VersionBeforeOrAtTimestamp(timestamp int64) (int64, error) {
 	mustBeRecreatable := false
 	canReturnEarliestCommit := false
 
-	c, err := l.history.getActiveCommitAtTime(l.snapshotReader,
+	c, err := l.history.getActiveCommitAtTime(ctx, l.snapshotReader,
 		timestamp,
 		canReturnLastCommit,
 		mustBeRecreatable,
 		canReturnEarliestCommit)
 	if err != nil {
-		return -1, err
+		return -1, errno.ContextError(ctx, err)
 	}
 
 	return c.version, nil
@@ -314,6 +481,10 @@ func (l *logImpl) VersionBeforeOrAtTimestamp(timestamp int64) (int64, error) {
 // else, we return the earliest commit version with a timestamp greater than the provided one
 // If the provided timestamp is larger than the timestamp of any committed version, we throw an error.
 func (l *logImpl) VersionAtOrAfterTimestamp(timestamp int64) (int64, error) {
+	return l.VersionAtOrAfterTimestampContext(context.Background(), timestamp)
+}
+
+func (l *logImpl) VersionAtOrAfterTimestampContext(ctx context.Context, timestamp int64) (int64, error) {
 
 	if !l.TableExists() {
 		return -1, nil
@@ -323,13 +494,13 @@ func (l *logImpl) VersionAtOrAfterTimestamp(timestamp int64) (int64, error) {
 	mustBeRecreatable := false
 	canReturnEarliestCommit := true
 
-	c, err := l.history.getActiveCommitAtTime(l.snapshotReader,
+	c, err := l.history.getActiveCommitAtTime(ctx, l.snapshotReader,
 		timestamp,
 		canReturnLastCommit,
 		mustBeRecreatable,
 		canReturnEarliestCommit)
 	if err != nil {
-		return -1, err
+		return -1, errno.ContextError(ctx, err)
 	}
 
 	if c.timestamp >= timestamp {
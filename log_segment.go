@@ -38,6 +38,30 @@ func (l *LogSegment) equal(other *LogSegment) bool {
 	return true
 }
 
+// newDeltas returns the deltas of other which are not in l, if other has the same checkpoint as l
+// and its deltas only append to the deltas of l. It returns false otherwise.
+func (l *LogSegment) newDeltas(other *LogSegment) ([]*store.FileMeta, bool) {
+	if other == nil || l.Version < 0 || other.Version <= l.Version || l.LogPath != other.LogPath {
+		return nil, false
+	}
+	if l.CheckpointVersion.IsPresent() != other.CheckpointVersion.IsPresent() ||
+		l.CheckpointVersion.OrEmpty() != other.CheckpointVersion.OrEmpty() {
+		return nil, false
+	}
+	if util.MustHash(l.Checkpoints) != util.MustHash(other.Checkpoints) {
+		return nil, false
+	}
+	if len(other.Deltas) <= len(l.Deltas) {
+		return nil, false
+	}
+	for i, d := range l.Deltas {
+		if other.Deltas[i].Path() != d.Path() {
+			return nil, false
+		}
+	}
+	return other.Deltas[len(l.Deltas):], true
+}
+
 func emptyLogSegment(logPath string) *LogSegment {
 	return &LogSegment{
 		LogPath:             logPath,
@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
@@ -245,6 +246,26 @@ func newTestLogCases(names ...string) []*testLogCase {
 	return cases
 }
 
+// commitTestActions commits the actions in a new transaction of the log.
+func commitTestActions(t testing.TB, log Log, actions ...action.Action) {
+	t.Helper()
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice(actions), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+}
+
+// testAddFile returns an AddFile of the unpartitioned file which changes the data.
+func testAddFile(path string) *action.AddFile {
+	return &action.AddFile{Path: path, PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true}
+}
+
+// testRemoveFile returns a RemoveFile of the file deleted now which changes the data.
+func testRemoveFile(path string) *action.RemoveFile {
+	now := time.Now().UnixMilli()
+	return &action.RemoveFile{Path: path, DeletionTimestamp: &now, DataChange: true}
+}
+
 func TestLog_snapshot(t *testing.T) {
 	t.Parallel()
 	getDirDataFiles := func(tablePath string) []string {
@@ -516,13 +537,13 @@ func TestLog_handle_corrupted_last_checkpoint_file(t *testing.T) {
 			assert.NoError(t, err)
 
 			logImpl1 := table.(*logImpl)
-			lc, err := LastCheckpoint(logImpl1.store)
+			lc, err := LastCheckpoint(context.Background(), logImpl1.store)
 			assert.NoError(t, err)
 			assert.True(t, lc.IsPresent())
 
 			lastcheckpoint1 := lc.MustGet()
 
-			err = logImpl1.store.Create(logImpl1.logPath + LastCheckpointPath)
+			err = logImpl1.store.Create(context.Background(), logImpl1.logPath+LastCheckpointPath)
 			assert.NoError(t, err)
 
 			table2, err := ForTable(table.Path(),
@@ -530,7 +551,7 @@ func TestLog_handle_corrupted_last_checkpoint_file(t *testing.T) {
 				&SystemClock{})
 			assert.NoError(t, err)
 
-			lc2, err := LastCheckpoint(table2.(*logImpl).store)
+			lc2, err := LastCheckpoint(context.Background(), table2.(*logImpl).store)
 			lastcheckpoint2 := lc2.MustGet()
 
 			assert.NoError(t, err)
@@ -634,7 +655,7 @@ func TestLog_do_not_relative_path_in_remove_files(t *testing.T) {
 
 			s, err := log.Update()
 			assert.NoError(t, err)
-			commitedRemove, err := s.(*snapshotImp).tombstones()
+			commitedRemove, err := s.(*snapshotImp).tombstones(context.Background())
 			assert.NoError(t, err)
 			assert.Equal(t, path, commitedRemove[0].Path)
 		})
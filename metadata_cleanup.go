@@ -0,0 +1,234 @@
+package deltago
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/store"
+	"github.com/rotisserie/eris"
+)
+
+// doLogCleanup deletes the expired delta and checkpoint files of the log, and the sidecar files no longer referenced,
+// if enableExpiredLogCleanup is set in the table configuration.
+func doLogCleanup(ctx context.Context, logStore store.Store, cr checkpointReader, metadata *action.Metadata, clock Clock) error {
+	if !DeltaConfigEnableExpiredLogCleanup.fromMetadata(metadata) {
+		return nil
+	}
+	return cleanUpExpiredLogs(ctx, logStore, cr, DeltaConfigLogRetention.fromMetadata(metadata), clock)
+}
+
+// cleanUpExpiredLogs deletes the log files older than the retention, the cut-off time is truncated to the day.
+func cleanUpExpiredLogs(ctx context.Context, logStore store.Store, cr checkpointReader, retention time.Duration, clock Clock) error {
+	fileCutOffTime := truncateDay(clock.NowInMillis() - retention.Milliseconds())
+
+	expired, err := listExpiredDeltaLogs(ctx, logStore, fileCutOffTime)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range expired {
+		if err := logStore.Delete(ctx, f.Path()); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+			return eris.Wrap(err, "deleting expired log file "+f.Path())
+		}
+	}
+
+	return cleanUpSidecars(ctx, logStore, cr, fileCutOffTime)
+}
+
+// cleanUpSidecars deletes the sidecar files which are not referenced by the remaining V2 checkpoints,
+// e.g. the sidecars of the expired checkpoints. The sidecar files modified after fileCutOffTime are kept,
+// as they may belong to a V2 checkpoint which is being written.
+func cleanUpSidecars(ctx context.Context, logStore store.Store, cr checkpointReader, fileCutOffTime int64) error {
+	prefix := filenames.SidecarFile(logStore.Root(), "")
+	it, err := logStore.ListFrom(ctx, prefix)
+	if err != nil {
+		if eris.Is(err, errno.ErrFileNotFound) {
+			return nil
+		}
+		return err
+	}
+	defer it.Close()
+
+	var (
+		candidates []*store.FileMeta
+		f          *store.FileMeta
+	)
+	for f, err = it.Next(); err == nil && strings.HasPrefix(f.Path(), prefix); f, err = it.Next() {
+		if f.TimeModified().UnixMilli() <= fileCutOffTime {
+			candidates = append(candidates, f)
+		}
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	referenced, err := referencedSidecars(ctx, logStore, cr)
+	if err != nil {
+		return err
+	}
+	for _, f := range candidates {
+		if referenced.Contains(strings.TrimPrefix(f.Path(), prefix)) {
+			continue
+		}
+		if err := logStore.Delete(ctx, f.Path()); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+			return eris.Wrap(err, "deleting sidecar file "+f.Path())
+		}
+	}
+	return nil
+}
+
+// referencedSidecars returns the names of the sidecar files referenced by the V2 checkpoints in the log.
+func referencedSidecars(ctx context.Context, logStore store.Store, cr checkpointReader) (mapset.Set[string], error) {
+	it, err := logStore.ListFrom(ctx, filenames.CheckpointPrefix(logStore.Root(), 0))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var (
+		checkpoints []string
+		f           *store.FileMeta
+	)
+	for f, err = it.Next(); err == nil; f, err = it.Next() {
+		if filenames.IsV2CheckpointFile(f.Path()) {
+			checkpoints = append(checkpoints, f.Path())
+		}
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	referenced := mapset.NewThreadUnsafeSet[string]()
+	for _, p := range checkpoints {
+		it, err := readCheckpointTopLevel(ctx, logStore, cr, p)
+		if err != nil {
+			return nil, err
+		}
+		actions, err := iter.ToSlice(it)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range actions {
+			if sidecar, ok := a.(*action.Sidecar); ok {
+				referenced.Add(sidecar.Path)
+			}
+		}
+	}
+	return referenced, nil
+}
+
+// listExpiredDeltaLogs returns the delta and checkpoint files which can be safely deleted, in increasing order of version.
+// A version is expired if its (monotonized) commit timestamp is not later than fileCutOffTime.
+// Only the files strictly before a complete checkpoint are returned, so the earliest remaining version can always be
+// reconstructed and time travel to any remaining version still works. Nothing at or after the latest checkpoint is returned.
+func listExpiredDeltaLogs(ctx context.Context, logStore store.Store, fileCutOffTime int64) ([]*store.FileMeta, error) {
+	lastCheckpoint, err := LastCheckpoint(ctx, logStore)
+	if err != nil {
+		return nil, err
+	}
+	if lastCheckpoint.IsAbsent() {
+		return nil, nil
+	}
+	latestCheckpointVersion := lastCheckpoint.MustGet().Version
+
+	it, err := logStore.ListFrom(ctx, filenames.CheckpointPrefix(logStore.Root(), 0))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var (
+		versions    []int64
+		groups      = make(map[int64][]*store.FileMeta)
+		checkpoints []*CheckpointInstance
+		f           *store.FileMeta
+	)
+	for f, err = it.Next(); err == nil; f, err = it.Next() {
+		if !(filenames.IsCheckpointFile(f.Path()) || filenames.IsDeltaFile(f.Path())) {
+			continue
+		}
+		v, err := filenames.GetFileVersion(f.Path())
+		if err != nil {
+			return nil, err
+		}
+		if v > latestCheckpointVersion {
+			break
+		}
+		if filenames.IsCheckpointFile(f.Path()) {
+			checkpoints = append(checkpoints, FromPath(f.Path()))
+		}
+		if v == latestCheckpointVersion {
+			continue
+		}
+		if _, ok := groups[v]; !ok {
+			versions = append(versions, v)
+		}
+		groups[v] = append(groups[v], f)
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	lastExpiredVersion := int64(-1)
+	lastCommitTimestamp := int64(-1)
+	for _, v := range versions {
+		ts := commitTimestampOf(groups[v])
+		// commit timestamps must be monotonic, the modification time of a file may have been skewed
+		if ts <= lastCommitTimestamp {
+			ts = lastCommitTimestamp + 1
+		}
+		lastCommitTimestamp = ts
+
+		if ts > fileCutOffTime {
+			break
+		}
+		lastExpiredVersion = v
+	}
+	if lastExpiredVersion < 0 {
+		return nil, nil
+	}
+
+	protectedCheckpoint := GetLatestCompleteCheckpointFromList(checkpoints, CheckpointInstance{Version: lastExpiredVersion + 1})
+	if protectedCheckpoint.IsAbsent() {
+		return nil, nil
+	}
+
+	var expired []*store.FileMeta
+	for _, v := range versions {
+		if v >= protectedCheckpoint.MustGet().Version {
+			break
+		}
+		expired = append(expired, groups[v]...)
+	}
+	return expired, nil
+}
+
+// commitTimestampOf uses the modification time of the delta file as the commit timestamp of a version,
+// or of the checkpoint files if the delta file is already gone.
+func commitTimestampOf(files []*store.FileMeta) int64 {
+	ts := int64(-1)
+	for _, f := range files {
+		if filenames.IsDeltaFile(f.Path()) {
+			return f.TimeModified().UnixMilli()
+		}
+		if m := f.TimeModified().UnixMilli(); m > ts {
+			ts = m
+		}
+	}
+	return ts
+}
+
+func truncateDay(timeInMillis int64) int64 {
+	return time.UnixMilli(timeInMillis).UTC().Truncate(24 * time.Hour).UnixMilli()
+}
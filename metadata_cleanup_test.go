@@ -0,0 +1,164 @@
+package deltago
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/stretchr/testify/assert"
+)
+
+type cleanupTestFixture struct {
+	tt      *testLogCase
+	log     Log
+	logPath string
+}
+
+func newCleanupTestFixture(t *testing.T, numCommits int) *cleanupTestFixture {
+	tt := newTestLogCases("file")[0]
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	for i := 0; i < numCommits; i++ {
+		trx, err := log.StartTransaction()
+		assert.NoError(t, err)
+		if i == 0 {
+			assert.NoError(t, trx.UpdateMetadata(getTestMetedata()))
+		}
+		files := []action.Action{
+			&action.AddFile{Path: strconv.Itoa(i), PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true},
+		}
+		_, err = trx.Commit(iter.FromSlice(files), getTestManualUpdate(), getTestEngineInfo())
+		assert.NoError(t, err)
+	}
+
+	u, err := url.Parse(tt.urlstr)
+	assert.NoError(t, err)
+
+	return &cleanupTestFixture{
+		tt:      tt,
+		log:     log,
+		logPath: u.Path + "/" + tt.tempDir + "/_delta_log/",
+	}
+}
+
+// expire sets the modification time of all log files up to the given version to daysAgo days ago.
+func (f *cleanupTestFixture) expire(t *testing.T, untilVersion int64, daysAgo int) {
+	base := time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour)
+	for v := int64(0); v <= untilVersion; v++ {
+		ts := base.Add(time.Duration(v) * time.Second)
+		for _, p := range []string{filenames.DeltaFile(f.logPath, v), filenames.CheckpointFileSingular(f.logPath, v)} {
+			if _, err := os.Stat(p); err == nil {
+				assert.NoError(t, os.Chtimes(p, ts, ts))
+			}
+		}
+	}
+}
+
+func (f *cleanupTestFixture) logFiles(t *testing.T) []string {
+	entries, err := os.ReadDir(f.logPath)
+	assert.NoError(t, err)
+
+	var res []string
+	for _, e := range entries {
+		if filenames.IsDeltaFile(e.Name()) || filenames.IsCheckpointFile(e.Name()) {
+			res = append(res, e.Name())
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (f *cleanupTestFixture) store() *logImpl {
+	return f.log.(*logImpl)
+}
+
+func TestMetadataCleanup_delete_expired_logs_before_checkpoint(t *testing.T) {
+	f := newCleanupTestFixture(t, 25)
+	defer f.tt.clean()
+
+	// versions [0, 14] are expired, the latest complete checkpoint not after 15 is 10
+	f.expire(t, 14, 40)
+
+	l := f.store()
+	assert.NoError(t, cleanUpExpiredLogs(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader, DeltaConfigLogRetention.fromMetadata(&action.Metadata{}), l.clock))
+
+	files := f.logFiles(t)
+	assert.Equal(t, filenames.CheckpointFileSingular("", 10), files[0])
+	assert.Equal(t, filenames.DeltaFile("", 10), files[1])
+	assert.Equal(t, filenames.DeltaFile("", 24), files[len(files)-1])
+	for _, name := range files {
+		v, err := filenames.GetFileVersion(name)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, v, int64(10))
+	}
+
+	// all remaining versions can still be reconstructed
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), getTestFileConfig(), &SystemClock{})
+	assert.NoError(t, err)
+	s, err := reloaded.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(24), s.Version())
+
+	s, err = reloaded.SnapshotForVersionAsOf(10)
+	assert.NoError(t, err)
+	allFiles, err := s.AllFiles()
+	assert.NoError(t, err)
+	assert.Len(t, allFiles, 11)
+
+	_, err = reloaded.SnapshotForVersionAsOf(9)
+	assert.Error(t, err)
+}
+
+func TestMetadataCleanup_keep_logs_without_expired_checkpoint(t *testing.T) {
+	f := newCleanupTestFixture(t, 15)
+	defer f.tt.clean()
+
+	// only versions [0, 5] are expired, but there is no checkpoint to reconstruct version 6
+	f.expire(t, 5, 40)
+	before := f.logFiles(t)
+
+	l := f.store()
+	assert.NoError(t, cleanUpExpiredLogs(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader, DeltaConfigLogRetention.fromMetadata(&action.Metadata{}), l.clock))
+
+	assert.Equal(t, before, f.logFiles(t))
+}
+
+func TestMetadataCleanup_never_delete_latest_checkpoint(t *testing.T) {
+	f := newCleanupTestFixture(t, 21)
+	defer f.tt.clean()
+
+	// everything is expired, the latest checkpoint is 20
+	f.expire(t, 20, 40)
+
+	l := f.store()
+	assert.NoError(t, cleanUpExpiredLogs(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader, DeltaConfigLogRetention.fromMetadata(&action.Metadata{}), l.clock))
+
+	assert.Equal(t, []string{
+		filenames.CheckpointFileSingular("", 20),
+		filenames.DeltaFile("", 20),
+	}, f.logFiles(t))
+}
+
+func TestMetadataCleanup_disabled_by_table_config(t *testing.T) {
+	f := newCleanupTestFixture(t, 15)
+	defer f.tt.clean()
+
+	f.expire(t, 14, 40)
+	before := f.logFiles(t)
+
+	l := f.store()
+	metadata := &action.Metadata{Configuration: map[string]string{DeltaConfigEnableExpiredLogCleanup.Key: "false"}}
+	assert.NoError(t, doLogCleanup(context.Background(), l.store, l.snapshotReader.snapshot().checkpointReader, metadata, l.clock))
+
+	assert.Equal(t, before, f.logFiles(t))
+}
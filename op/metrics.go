@@ -0,0 +1,30 @@
+package op
+
+// The names of the operation metrics, the same as the ones written by Spark.
+const (
+	// MetricNumFiles is the number of the files written.
+	MetricNumFiles = "numFiles"
+	// MetricNumOutputBytes is the size of the files written in bytes.
+	MetricNumOutputBytes = "numOutputBytes"
+	// MetricNumOutputRows is the number of the rows written.
+	MetricNumOutputRows = "numOutputRows"
+	// MetricNumAddedFiles is the number of the files added.
+	MetricNumAddedFiles = "numAddedFiles"
+	// MetricNumRemovedFiles is the number of the files removed.
+	MetricNumRemovedFiles = "numRemovedFiles"
+	// MetricNumRemovedBytes is the size of the files removed in bytes.
+	MetricNumRemovedBytes = "numRemovedBytes"
+	// MetricExecutionTimeMs is the time taken to execute the operation in milliseconds.
+	MetricExecutionTimeMs = "executionTimeMs"
+
+	// MetricNumRestoredFiles is the number of the files added back by a RESTORE.
+	MetricNumRestoredFiles = "numRestoredFiles"
+	// MetricRestoredFilesSize is the size of the files added back by a RESTORE in bytes.
+	MetricRestoredFilesSize = "restoredFilesSize"
+	// MetricRemovedFilesSize is the size of the files removed by a RESTORE in bytes.
+	MetricRemovedFilesSize = "removedFilesSize"
+	// MetricNumOfFilesAfterRestore is the number of the files of the table after a RESTORE.
+	MetricNumOfFilesAfterRestore = "numOfFilesAfterRestore"
+	// MetricTableSizeAfterRestore is the size of the table after a RESTORE in bytes.
+	MetricTableSizeAfterRestore = "tableSizeAfterRestore"
+)
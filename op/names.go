@@ -48,6 +48,12 @@ const (
 	UPGRADESCHEMA Name = "UPGRADE_SCHEMA"
 	// MANUALUPDATE is a Name of type MANUAL_UPDATE.
 	MANUALUPDATE Name = "MANUAL_UPDATE"
+	// VACUUMSTART is a Name of type VACUUM START.
+	VACUUMSTART Name = "VACUUM START"
+	// VACUUMEND is a Name of type VACUUM END.
+	VACUUMEND Name = "VACUUM END"
+	// RESTORE is a Name of type RESTORE.
+	RESTORE Name = "RESTORE"
 )
 
 var ErrInvalidName = errors.New("not a valid Name")
@@ -81,6 +87,9 @@ var _NameValue = map[string]Name{
 	"UPGRADE_PROTOCOL":       UPGRADEPROTOCOL,
 	"UPGRADE_SCHEMA":         UPGRADESCHEMA,
 	"MANUAL_UPDATE":          MANUALUPDATE,
+	"VACUUM START":           VACUUMSTART,
+	"VACUUM END":             VACUUMEND,
+	"RESTORE":                RESTORE,
 }
 
 // ParseName attempts to convert a string to a Name.
@@ -2,11 +2,13 @@ package op
 
 import "github.com/samber/mo"
 
-// we do not support metrics here
-
 type Operation struct {
 	Name           Name
 	Parameters     map[string]any
 	UserParameters mo.Option[map[string]string]
 	UserMetadata   mo.Option[string]
+	// Metrics are the metrics of the operation known by the engine only, e.g. MetricNumOutputRows and MetricExecutionTimeMs.
+	// They are written in the commit info together with the file metrics computed from the committed actions,
+	// and override the computed ones with the same names.
+	Metrics map[string]int64
 }
@@ -5,10 +5,13 @@ import (
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/internal/util/parquet"
 	"github.com/fraugster/parquet-go/floor/interfaces"
+	"github.com/fraugster/parquet-go/parquetschema"
 )
 
 type actionMarshaller struct {
 	a *action.SingleAction
+	// schema is the schema of the parquet file, the columns of the add action depend on the table
+	schema *parquetschema.SchemaDefinition
 }
 
 func (p *actionMarshaller) MarshalParquet(obj interfaces.MarshalObject) error {
@@ -17,7 +20,7 @@ func (p *actionMarshaller) MarshalParquet(obj interfaces.MarshalObject) error {
 		return parquetMarshalTxn(p.a.Txn, obj.AddField("txn").Group())
 	}
 	if p.a.Add != nil {
-		return parquetMarshalAdd(p.a.Add, obj.AddField("add").Group())
+		return parquetMarshalAdd(p.a.Add, obj.AddField("add").Group(), p.schema.SubSchema("add"))
 	}
 	if p.a.Remove != nil {
 		return parquetMarshalRemove(p.a.Remove, obj.AddField("remove").Group())
@@ -34,6 +37,12 @@ func (p *actionMarshaller) MarshalParquet(obj interfaces.MarshalObject) error {
 	if p.a.CommitInfo != nil {
 		return parquetMarshalCommitInfo(p.a.CommitInfo, obj.AddField("commitInfo").Group())
 	}
+	if p.a.CheckpointMetadata != nil {
+		return parquetMarshalCheckpointMetadata(p.a.CheckpointMetadata, obj.AddField("checkpointMetadata").Group())
+	}
+	if p.a.Sidecar != nil {
+		return parquetMarshalSidecar(p.a.Sidecar, obj.AddField("sidecar").Group())
+	}
 
 	return nil
 }
@@ -46,7 +55,7 @@ func (p *actionMarshaller) UnmarshalParquet(obj interfaces.UnmarshalObject) erro
 	}
 	if _, ok := data["add"]; ok {
 		p.a.Add = &action.AddFile{}
-		return parquetUnmarshalAdd(p.a.Add, obj)
+		return parquetUnmarshalAdd(p.a.Add, obj, p.schema.SubSchema("add"))
 	}
 	if _, ok := data["remove"]; ok {
 		p.a.Remove = &action.RemoveFile{}
@@ -68,6 +77,14 @@ func (p *actionMarshaller) UnmarshalParquet(obj interfaces.UnmarshalObject) erro
 		p.a.CommitInfo = &action.CommitInfo{}
 		return parquetUnmarshalCommitInfo(p.a.CommitInfo, obj)
 	}
+	if _, ok := data["checkpointMetadata"]; ok {
+		p.a.CheckpointMetadata = &action.CheckpointMetadata{}
+		return parquetUnmarshalCheckpointMetadata(p.a.CheckpointMetadata, obj)
+	}
+	if _, ok := data["sidecar"]; ok {
+		p.a.Sidecar = &action.Sidecar{}
+		return parquetUnmarshalSidecar(p.a.Sidecar, obj)
+	}
 	return nil
 }
 
@@ -103,7 +120,7 @@ func parquetUnmarshalTxn(txn *action.SetTransaction, obj interfaces.UnmarshalObj
 	return nil
 }
 
-func parquetMarshalAdd(add *action.AddFile, obj interfaces.MarshalObject) error {
+func parquetMarshalAdd(add *action.AddFile, obj interfaces.MarshalObject, schema *parquetschema.SchemaDefinition) error {
 	obj.AddField("path").SetByteArray([]byte(add.Path))
 	obj.AddField("dataChange").SetBool(add.DataChange)
 
@@ -118,10 +135,14 @@ func parquetMarshalAdd(add *action.AddFile, obj interfaces.MarshalObject) error
 	if len(add.Tags) > 0 {
 		parquet.MarshalMap(obj, "tags", add.Tags)
 	}
+	if add.DeletionVector != nil {
+		parquetMarshalDeletionVector(add.DeletionVector, obj.AddField("deletionVector").Group())
+	}
+	parquetMarshalAddParsed(add, obj, schema)
 	return nil
 }
 
-func parquetUnmarshalAdd(add *action.AddFile, obj interfaces.UnmarshalObject) error {
+func parquetUnmarshalAdd(add *action.AddFile, obj interfaces.UnmarshalObject, schema *parquetschema.SchemaDefinition) error {
 	g, err := obj.GetField("add").Group()
 	if err != nil {
 		return err
@@ -148,6 +169,10 @@ func parquetUnmarshalAdd(add *action.AddFile, obj interfaces.UnmarshalObject) er
 	if err := parquet.UnmarshalMap(g, "tags", func(m map[string]string) { add.Tags = m }); err != nil {
 		return err
 	}
+	if err := parquetUnmarshalDeletionVector(g, func(dv *action.DeletionVectorDescriptor) { add.DeletionVector = dv }); err != nil {
+		return err
+	}
+	parquetUnmarshalAddParsed(add, g, schema)
 
 	return nil
 }
@@ -164,6 +189,9 @@ func parquetMarshalRemove(rm *action.RemoveFile, obj interfaces.MarshalObject) e
 		obj.AddField("size").SetInt64(*rm.Size)
 	}
 	parquet.MarshalMap(obj, "tags", rm.Tags)
+	if rm.DeletionVector != nil {
+		parquetMarshalDeletionVector(rm.DeletionVector, obj.AddField("deletionVector").Group())
+	}
 	return nil
 }
 
@@ -194,6 +222,54 @@ func parquetUnmarshalRemove(rm *action.RemoveFile, obj interfaces.UnmarshalObjec
 	if err := parquet.UnmarshalMap(g, "tags", func(m map[string]string) { rm.Tags = m }); err != nil {
 		return err
 	}
+	if err := parquetUnmarshalDeletionVector(g, func(dv *action.DeletionVectorDescriptor) { rm.DeletionVector = dv }); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parquetMarshalDeletionVector(dv *action.DeletionVectorDescriptor, obj interfaces.MarshalObject) {
+	obj.AddField("storageType").SetByteArray([]byte(dv.StorageType))
+	obj.AddField("pathOrInlineDv").SetByteArray([]byte(dv.PathOrInlineDv))
+	if dv.Offset != nil {
+		obj.AddField("offset").SetInt32(*dv.Offset)
+	}
+	obj.AddField("sizeInBytes").SetInt32(dv.SizeInBytes)
+	obj.AddField("cardinality").SetInt64(dv.Cardinality)
+	if dv.MaxRowIndex != nil {
+		obj.AddField("maxRowIndex").SetInt64(*dv.MaxRowIndex)
+	}
+}
+
+func parquetUnmarshalDeletionVector(obj interfaces.UnmarshalObject, setter func(*action.DeletionVectorDescriptor)) error {
+	if _, ok := obj.GetData()["deletionVector"]; !ok {
+		return nil
+	}
+	g, err := obj.GetField("deletionVector").Group()
+	if err != nil {
+		return err
+	}
+
+	dv := &action.DeletionVectorDescriptor{}
+	if err := parquet.UnmarshalString(g, "storageType", func(s string) { dv.StorageType = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalString(g, "pathOrInlineDv", func(s string) { dv.PathOrInlineDv = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt32(g, "offset", func(s int32) { dv.Offset = &s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt32(g, "sizeInBytes", func(s int32) { dv.SizeInBytes = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt64(g, "cardinality", func(s int64) { dv.Cardinality = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt64(g, "maxRowIndex", func(s int64) { dv.MaxRowIndex = &s }); err != nil {
+		return err
+	}
+	setter(dv)
 	return nil
 }
 
@@ -476,3 +552,57 @@ func parquetUnmarshalCommitInfo(add *action.CommitInfo, obj interfaces.Unmarshal
 
 	return nil
 }
+
+func parquetMarshalCheckpointMetadata(c *action.CheckpointMetadata, obj interfaces.MarshalObject) error {
+	obj.AddField("version").SetInt64(c.Version)
+	if len(c.Tags) > 0 {
+		parquet.MarshalMap(obj, "tags", c.Tags)
+	}
+	return nil
+}
+
+func parquetUnmarshalCheckpointMetadata(c *action.CheckpointMetadata, obj interfaces.UnmarshalObject) error {
+	g, err := obj.GetField("checkpointMetadata").Group()
+	if err != nil {
+		return err
+	}
+
+	if err := parquet.UnmarshalInt64(g, "version", func(s int64) { c.Version = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalMap(g, "tags", func(m map[string]string) { c.Tags = m }); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parquetMarshalSidecar(sc *action.Sidecar, obj interfaces.MarshalObject) error {
+	obj.AddField("path").SetByteArray([]byte(sc.Path))
+	obj.AddField("sizeInBytes").SetInt64(sc.SizeInBytes)
+	obj.AddField("modificationTime").SetInt64(sc.ModificationTime)
+	if len(sc.Tags) > 0 {
+		parquet.MarshalMap(obj, "tags", sc.Tags)
+	}
+	return nil
+}
+
+func parquetUnmarshalSidecar(sc *action.Sidecar, obj interfaces.UnmarshalObject) error {
+	g, err := obj.GetField("sidecar").Group()
+	if err != nil {
+		return err
+	}
+
+	if err := parquet.UnmarshalString(g, "path", func(s string) { sc.Path = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt64(g, "sizeInBytes", func(s int64) { sc.SizeInBytes = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalInt64(g, "modificationTime", func(s int64) { sc.ModificationTime = s }); err != nil {
+		return err
+	}
+	if err := parquet.UnmarshalMap(g, "tags", func(m map[string]string) { sc.Tags = m }); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,7 +25,7 @@ func TestLocalParquetReadWrite(t *testing.T) {
 	path := "local-test.parquet"
 	defer os.RemoveAll(path)
 
-	err = w.Open(path, actionSchemaDefinitionString)
+	err = w.Open(context.Background(), path, actionSchemaDefinitionString)
 	assert.NoError(t, err)
 
 	expected := []*action.SingleAction{
@@ -190,7 +191,7 @@ func TestLocalParquetReadWrite(t *testing.T) {
 	r, err := newCheckpointReader(fmt.Sprintf("file://%s", dir), nil)
 	assert.NoError(t, err)
 
-	it, err := r.Read(path)
+	it, err := r.Read(context.Background(), path)
 	assert.NoError(t, err)
 	defer it.Close()
 
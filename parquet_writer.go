@@ -16,12 +16,19 @@ import (
 	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
 	_ "gocloud.dev/blob/gcsblob"
+	"gocloud.dev/gcerrors"
 )
 
 type parquetActionWriter interface {
-	Open(path string, schema string) error
+	Open(ctx context.Context, path string, schema string) error
 	Write(a *action.SingleAction) error
 	Close() error
+	// Rename moves the file written at src to dst.
+	Rename(ctx context.Context, src string, dst string) error
+	// Delete deletes the file at path.
+	Delete(ctx context.Context, path string) error
+	// Stat returns the size and the modification time of the file written at path.
+	Stat(ctx context.Context, path string) (*blob.Attributes, error)
 }
 
 func newParquetActionWriter(urlstr string) (parquetActionWriter, error) {
@@ -47,11 +54,11 @@ type defaultParquetActionWriter struct {
 	fw     *pq.FileWriter
 }
 
-func (l *defaultParquetActionWriter) Open(path string, schemaString string) error {
+func (l *defaultParquetActionWriter) Open(ctx context.Context, path string, schemaString string) error {
 
-	exists, err := l.bucket.Exists(context.Background(), path)
+	exists, err := l.bucket.Exists(ctx, path)
 	if err != nil {
-		return err
+		return errno.ContextError(ctx, err)
 	}
 	if exists {
 		return errno.FileAlreadyExists(path)
@@ -62,9 +69,10 @@ func (l *defaultParquetActionWriter) Open(path string, schemaString string) erro
 		return eris.Wrap(err, "parsing schema definition")
 	}
 
-	bw, err := l.bucket.NewWriter(context.Background(), path, nil)
+	// the writer is bound to the ctx until it is closed
+	bw, err := l.bucket.NewWriter(ctx, path, nil)
 	if err != nil {
-		return err
+		return errno.ContextError(ctx, err)
 	}
 
 	fw := pq.NewFileWriter(bw,
@@ -80,7 +88,7 @@ func (l *defaultParquetActionWriter) Open(path string, schemaString string) erro
 
 func (l *defaultParquetActionWriter) Write(a *action.SingleAction) error {
 	obj := interfaces.NewMarshallObjectWithSchema(nil, l.fw.GetSchemaDefinition())
-	am := &actionMarshaller{a: a}
+	am := &actionMarshaller{a: a, schema: l.fw.GetSchemaDefinition()}
 	if err := am.MarshalParquet(obj); err != nil {
 		return err
 	}
@@ -102,3 +110,31 @@ func (l *defaultParquetActionWriter) Close() error {
 
 	return nil
 }
+
+func (l *defaultParquetActionWriter) Rename(ctx context.Context, src string, dst string) error {
+	if err := l.bucket.Copy(ctx, dst, src, nil); err != nil {
+		return errno.ContextError(ctx, eris.Wrap(err, "copying "+src+" to "+dst))
+	}
+	return l.Delete(ctx, src)
+}
+
+func (l *defaultParquetActionWriter) Delete(ctx context.Context, path string) error {
+	if err := l.bucket.Delete(ctx, path); err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return errno.FileNotFound(path)
+		}
+		return errno.ContextError(ctx, eris.Wrap(err, "deleting "+path))
+	}
+	return nil
+}
+
+func (l *defaultParquetActionWriter) Stat(ctx context.Context, path string) (*blob.Attributes, error) {
+	attrs, err := l.bucket.Attributes(ctx, path)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, errno.FileNotFound(path)
+		}
+		return nil, errno.ContextError(ctx, eris.Wrap(err, "reading the attributes of "+path))
+	}
+	return attrs, nil
+}
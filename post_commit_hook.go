@@ -0,0 +1,106 @@
+package deltago
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+)
+
+// PostCommitHook runs after a transaction commits successfully, e.g. to checkpoint the table,
+// to sync a catalog, to invalidate caches or to send notifications.
+type PostCommitHook interface {
+	// Name identifies the hook in the reported errors.
+	Name() string
+	// Run runs the hook on the committed version. An error does not fail the commit,
+	// it is reported in CommitResult.PostCommitHookErrors and the next hooks still run.
+	Run(ctx context.Context, committed *CommittedVersion) error
+}
+
+// CommittedVersion is a version committed by a transaction.
+type CommittedVersion struct {
+	Version int64
+	// Actions are the committed actions except the CommitInfo,
+	// the paths of the AddFiles in the table directory are relative to the table.
+	Actions    []action.Action
+	CommitInfo *action.CommitInfo
+}
+
+// RegisterPostCommitHook registers a hook which runs after the commits of the transactions started later,
+// after the built-in hooks and the hooks registered before.
+func (l *logImpl) RegisterPostCommitHook(hook PostCommitHook) {
+	l.hooksLock.Lock()
+	defer l.hooksLock.Unlock()
+	l.postCommitHooks = append(l.postCommitHooks, hook)
+}
+
+func (l *logImpl) registeredPostCommitHooks() []PostCommitHook {
+	l.hooksLock.RLock()
+	defer l.hooksLock.RUnlock()
+	return append([]PostCommitHook{}, l.postCommitHooks...)
+}
+
+// RegisterPostCommitHook registers a hook which runs after the commit of this transaction, after the hooks of the log.
+func (trx *optimisticTransactionImp) RegisterPostCommitHook(hook PostCommitHook) {
+	trx.postCommitHooks = append(trx.postCommitHooks, hook)
+}
+
+// runPostCommitHooks runs the hooks one after another, and returns the errors of the failed hooks.
+func runPostCommitHooks(ctx context.Context, hooks []PostCommitHook, committed *CommittedVersion) []error {
+	var errs []error
+	for _, hook := range hooks {
+		if err := runPostCommitHook(ctx, hook, committed); err != nil {
+			err = errno.PostCommitHookError(hook.Name(), committed.Version, err)
+			log.Println(err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func runPostCommitHook(ctx context.Context, hook PostCommitHook, committed *CommittedVersion) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.New(fmt.Sprintf("panic: %v", r))
+		}
+	}()
+	return hook.Run(ctx, committed)
+}
+
+// checkpointHook checkpoints the table every checkpointInterval versions.
+type checkpointHook struct {
+	trx *optimisticTransactionImp
+}
+
+func (h *checkpointHook) Name() string {
+	return "checkpoint"
+}
+
+func (h *checkpointHook) Run(ctx context.Context, committed *CommittedVersion) error {
+	trx := h.trx
+	metadata, err := trx.snapshot.Metadata()
+	if err != nil {
+		return err
+	}
+	checkpointInterval := DeltaConfigCheckpointInterval.fromMetadata(metadata)
+	if !trx.shouldCheckpoint(committed.Version, checkpointInterval) {
+		return nil
+	}
+
+	snaptshot, err := trx.snapshotManager.getSnapshotForVersionAsOf(ctx, committed.Version)
+	if err != nil {
+		return err
+	}
+	if err := checkpoint(ctx, trx.logPath, trx.logStore, snaptshot, trx.clock); err != nil {
+		if eris.Is(err, errno.ErrIllegalState) {
+			log.Println("Failed to checkpoint table state." + err.Error())
+		} else {
+			return err
+		}
+	}
+	return nil
+}
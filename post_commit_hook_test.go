@@ -0,0 +1,94 @@
+package deltago
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/op"
+)
+
+type testPostCommitHook struct {
+	name  string
+	calls *[]string
+	run   func(committed *CommittedVersion) error
+}
+
+func (h *testPostCommitHook) Name() string {
+	return h.name
+}
+
+func (h *testPostCommitHook) Run(ctx context.Context, committed *CommittedVersion) error {
+	*h.calls = append(*h.calls, h.name)
+	return h.run(committed)
+}
+
+func TestTrx_post_commit_hooks(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	var calls []string
+	var received []*CommittedVersion
+	log.RegisterPostCommitHook(&testPostCommitHook{name: "record", calls: &calls, run: func(committed *CommittedVersion) error {
+		received = append(received, committed)
+		return nil
+	}})
+	log.RegisterPostCommitHook(&testPostCommitHook{name: "fail", calls: &calls, run: func(committed *CommittedVersion) error {
+		return errors.New("catalog unavailable")
+	}})
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	trx.RegisterPostCommitHook(&testPostCommitHook{name: "panic", calls: &calls, run: func(committed *CommittedVersion) error {
+		panic("invalid cache")
+	}})
+	// the hooks registered after the transaction is started do not run for it
+	log.RegisterPostCommitHook(&testPostCommitHook{name: "later", calls: &calls, run: func(committed *CommittedVersion) error {
+		return nil
+	}})
+
+	add := &action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true}
+	res, err := trx.Commit(iter.FromSlice([]action.Action{getTestMetedata(), add}), &op.Operation{Name: op.WRITE}, getTestEngineInfo())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), res.Version)
+	assert.Equal(t, []string{"record", "fail", "panic"}, calls)
+
+	assert.Len(t, res.PostCommitHookErrors, 2)
+	assert.Contains(t, res.PostCommitHookErrors[0].Error(), "post-commit hook fail failed")
+	assert.Contains(t, res.PostCommitHookErrors[0].Error(), "catalog unavailable")
+	assert.Contains(t, res.PostCommitHookErrors[1].Error(), "post-commit hook panic failed")
+	assert.Contains(t, res.PostCommitHookErrors[1].Error(), "invalid cache")
+
+	assert.Len(t, received, 1)
+	committed := received[0]
+	assert.Equal(t, int64(0), committed.Version)
+	assert.Equal(t, int64(0), committed.CommitInfo.GetVersion())
+	assert.Equal(t, op.WRITE.String(), committed.CommitInfo.Operation)
+	var paths []string
+	for _, a := range committed.Actions {
+		if f, ok := a.(*action.AddFile); ok {
+			paths = append(paths, f.Path)
+		}
+	}
+	assert.Equal(t, []string{"a"}, paths)
+
+	// the commit is not affected by the failed hooks
+	s, err := log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), s.Version())
+
+	calls = nil
+	trx, err = log.StartTransaction()
+	assert.NoError(t, err)
+	res, err = trx.Commit(iter.FromSlice([]action.Action{}), &op.Operation{Name: op.WRITE}, getTestEngineInfo())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"record", "fail", "later"}, calls)
+	assert.Len(t, res.PostCommitHookErrors, 1)
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,17 +27,17 @@ func TestAssertProtocolRead_V1Reader_V2Writer_Passes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestAssertProtocolRead_V2Reader_Rejected(t *testing.T) {
+func TestAssertProtocolRead_V2Reader_ColumnMapping_Passes(t *testing.T) {
 	p := &action.Protocol{
 		MinReaderVersion: 2,
 		MinWriterVersion: 7,
 		WriterFeatures:   []string{"columnMapping"},
 	}
 	err := assertProtocolRead(p)
-	assert.Error(t, err)
+	assert.NoError(t, err)
 }
 
-func TestAssertProtocolRead_V3Reader_Rejected(t *testing.T) {
+func TestAssertProtocolRead_V3Reader_ColumnMapping_Passes(t *testing.T) {
 	p := &action.Protocol{
 		MinReaderVersion: 3,
 		MinWriterVersion: 7,
@@ -44,6 +45,17 @@ func TestAssertProtocolRead_V3Reader_Rejected(t *testing.T) {
 		WriterFeatures:   []string{"columnMapping", "identityColumns"},
 	}
 	err := assertProtocolRead(p)
+	assert.NoError(t, err)
+}
+
+func TestAssertProtocolRead_V3Reader_Rejected(t *testing.T) {
+	p := &action.Protocol{
+		MinReaderVersion: 3,
+		MinWriterVersion: 7,
+		ReaderFeatures:   []string{"columnMapping", "timestampNtz"},
+		WriterFeatures:   []string{"columnMapping", "timestampNtz"},
+	}
+	err := assertProtocolRead(p)
 	assert.Error(t, err)
 }
 
@@ -51,3 +63,55 @@ func TestAssertProtocolRead_NilProtocol_Passes(t *testing.T) {
 	err := assertProtocolRead(nil)
 	assert.NoError(t, err)
 }
+
+func TestAssertProtocolRead_V3Reader_DeletionVectors_Passes(t *testing.T) {
+	p := &action.Protocol{
+		MinReaderVersion: 3,
+		MinWriterVersion: 7,
+		ReaderFeatures:   []string{"deletionVectors"},
+		WriterFeatures:   []string{"deletionVectors"},
+	}
+	err := assertProtocolRead(p)
+	assert.NoError(t, err)
+}
+
+func TestAssertProtocolRead_V3Reader_UnknownFeature_Rejected(t *testing.T) {
+	p := &action.Protocol{
+		MinReaderVersion: 3,
+		MinWriterVersion: 7,
+		ReaderFeatures:   []string{"unknownFeature"},
+		WriterFeatures:   []string{"unknownFeature"},
+	}
+	err := assertProtocolRead(p)
+	assert.ErrorIs(t, err, errno.ErrUnsupportedOperation)
+}
+
+func TestAssertProtocolWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol *action.Protocol
+		ok       bool
+	}{
+		{"nil protocol", nil, true},
+		{"legacy v2 writer", &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 2}, true},
+		{"legacy v3 writer with check constraints", &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 3}, false},
+		{"v7 writer with supported features", &action.Protocol{MinReaderVersion: 3, MinWriterVersion: 7,
+			ReaderFeatures: []string{"deletionVectors"},
+			WriterFeatures: []string{"appendOnly", "changeDataFeed", "deletionVectors"}}, true},
+		{"v7 writer with unsupported feature", &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 7,
+			WriterFeatures: []string{"appendOnly", "generatedColumns"}}, false},
+		{"v7 writer with unknown feature", &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 7,
+			WriterFeatures: []string{"unknownFeature"}}, false},
+		{"unknown writer version", &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 8}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := assertProtocolWrite(tt.protocol)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -13,10 +13,21 @@ import (
 type PartitionRowRecord struct {
 	partitionSchema *types.StructType
 	partitionValues map[string]string
+	// physicalNames maps the logical column names to the keys of partitionValues in column mapping mode,
+	// nil if column mapping is disabled.
+	physicalNames map[string]string
+}
+
+func (r *PartitionRowRecord) partitionValue(fieldName string) (string, bool) {
+	if name, ok := r.physicalNames[fieldName]; ok {
+		fieldName = name
+	}
+	v, ok := r.partitionValues[fieldName]
+	return v, ok
 }
 
 func (r *PartitionRowRecord) getPrimitive(field *types.StructField) (string, error) {
-	partitionValue, ok := r.partitionValues[field.Name]
+	partitionValue, ok := r.partitionValue(field.Name)
 	if !ok || len(partitionValue) == 0 {
 		return "", eris.Wrap(errno.NullValueFoundForPrimitiveTypes(field.Name), "")
 	}
@@ -37,7 +48,7 @@ func (p *PartitionRowRecord) IsNullAt(fieldName string) (bool, error) {
 	}
 
 	isNull := true
-	if v, exist := p.partitionValues[fieldName]; exist {
+	if v, exist := p.partitionValue(fieldName); exist {
 		isNull = len(v) == 0
 	}
 
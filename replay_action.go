@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/barweiss/go-tuple"
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/internal/util/path"
 	"github.com/csimplestring/delta-go/iter"
@@ -20,17 +21,19 @@ type InMemoryLogReplay struct {
 	numMetadata            int64
 	numProtocol            int64
 	transactions           map[string]*action.SetTransaction
-	activeFiles            map[string]*action.AddFile
-	tombstones             map[string]*action.RemoveFile
+	// files are keyed by (canonical path, deletion vector unique id)
+	activeFiles map[tuple.T2[string, string]]*action.AddFile
+	tombstones  map[tuple.T2[string, string]]*action.RemoveFile
 }
 
 func NewInMemoryLogReplayer(minFileRetentionTimestamp int64, storageType string) *InMemoryLogReplay {
 	return &InMemoryLogReplay{
 		MinFileRetentionTimestamp: minFileRetentionTimestamp,
 		storageType:               storageType,
+		currentVersion:            -1,
 		transactions:              make(map[string]*action.SetTransaction),
-		activeFiles:               make(map[string]*action.AddFile),
-		tombstones:                make(map[string]*action.RemoveFile),
+		activeFiles:               make(map[tuple.T2[string, string]]*action.AddFile),
+		tombstones:                make(map[tuple.T2[string, string]]*action.RemoveFile),
 	}
 }
 
@@ -61,7 +64,7 @@ func (r *InMemoryLogReplay) GetTombstones() iter.Iter[*action.RemoveFile] {
 }
 
 func (r *InMemoryLogReplay) Append(version int64, iter iter.Iter[action.Action]) error {
-	if r.currentVersion == -1 || version == r.currentVersion+1 {
+	if r.currentVersion != -1 && version != r.currentVersion+1 {
 		panic(fmt.Errorf("attempted to replay version %d, but state is at %d", version, r.currentVersion))
 	}
 	r.currentVersion = version
@@ -93,9 +96,13 @@ func (r *InMemoryLogReplay) Append(version int64, iter iter.Iter[action.Action])
 				return err
 			}
 			canonicalizedAdd := v.Copy(false, canonicalPath)
+			key := tuple.New2(canonicalPath, canonicalizedAdd.DeletionVectorUniqueId())
 
-			r.activeFiles[canonicalPath] = canonicalizedAdd
-			delete(r.tombstones, canonicalPath)
+			if existing, ok := r.activeFiles[key]; ok {
+				r.sizeInBytes -= existing.Size
+			}
+			r.activeFiles[key] = canonicalizedAdd
+			delete(r.tombstones, key)
 			r.sizeInBytes += canonicalizedAdd.Size
 		case *action.RemoveFile:
 			canonicalPath, err := path.Canonicalize(v.Path, r.storageType)
@@ -103,12 +110,13 @@ func (r *InMemoryLogReplay) Append(version int64, iter iter.Iter[action.Action])
 				return err
 			}
 			canonicalizedRemove := v.Copy(false, canonicalPath)
+			key := tuple.New2(canonicalPath, canonicalizedRemove.DeletionVectorUniqueId())
 
-			if removeFile, ok := r.activeFiles[canonicalPath]; ok {
-				delete(r.activeFiles, canonicalPath)
+			if removeFile, ok := r.activeFiles[key]; ok {
+				delete(r.activeFiles, key)
 				r.sizeInBytes -= removeFile.Size
 			}
-			r.tombstones[canonicalPath] = canonicalizedRemove
+			r.tombstones[key] = canonicalizedRemove
 		default:
 			{
 			}
@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/csimplestring/delta-go/store"
 	"github.com/rotisserie/eris"
@@ -47,11 +49,11 @@ func (r *customJSONIterator) Close() error {
 	return r.iter.Close()
 }
 
-type customParquetIterator struct {
+type customCheckpointIterator struct {
 	iter iter.Iter[action.Action]
 }
 
-func (c *customParquetIterator) Next() (*replayTuple, error) {
+func (c *customCheckpointIterator) Next() (*replayTuple, error) {
 	a, err := c.iter.Next()
 	if err != nil {
 		return nil, err
@@ -63,7 +65,7 @@ func (c *customParquetIterator) Next() (*replayTuple, error) {
 	}, nil
 }
 
-func (c *customParquetIterator) Close() error {
+func (c *customCheckpointIterator) Close() error {
 	return c.iter.Close()
 }
 
@@ -72,23 +74,55 @@ type MemoryOptimizedLogReplay struct {
 	logStore store.Store
 	//timezone      time.Location
 	checkpointReader checkpointReader
+	// concurrency is the number of the files read in parallel
+	concurrency int
 }
 
-func (m *MemoryOptimizedLogReplay) GetReverseIterator() iter.Iter[*replayTuple] {
+// GetReverseIterator returns the actions of the files in reverse order, the files are read with the ctx.
+// If concurrency is greater than 1, up to concurrency delta files are decoded in parallel and held in memory,
+// the checkpoint files, which can be large, are always streamed one after another.
+func (m *MemoryOptimizedLogReplay) GetReverseIterator(ctx context.Context) iter.Iter[*replayTuple] {
 	sort.Slice(m.files, func(i, j int) bool {
 		return m.files[i] > m.files[j]
 	})
-	reverseFilesIter := iter.FromSlice(m.files)
 
+	if m.concurrency <= 1 {
+		return m.streamFiles(ctx, m.files)
+	}
+
+	// the deltas are newer than the checkpoint, so they come first in the reverse order
+	var deltas, checkpoints []string
+	for _, f := range m.files {
+		if filenames.IsCheckpointFile(f) {
+			checkpoints = append(checkpoints, f)
+		} else {
+			deltas = append(deltas, f)
+		}
+	}
+	parallelDeltas := &flattenIterator[*replayTuple]{
+		it: readFilesInOrder(ctx, deltas, m.concurrency, func(ctx context.Context, path string) ([]*replayTuple, error) {
+			it, err := openLogFile(ctx, m.logStore, m.checkpointReader, path)
+			if err != nil {
+				return nil, err
+			}
+			return iter.ToSlice(it)
+		}),
+	}
+	return &concatIterator[*replayTuple]{its: []iter.Iter[*replayTuple]{parallelDeltas, m.streamFiles(ctx, checkpoints)}}
+}
+
+func (m *MemoryOptimizedLogReplay) streamFiles(ctx context.Context, files []string) iter.Iter[*replayTuple] {
 	return &logReplayIterator{
+		ctx:              ctx,
 		logStore:         m.logStore,
 		checkpointReader: m.checkpointReader,
-		reverseFilesIter: reverseFilesIter,
+		reverseFilesIter: iter.FromSlice(files),
 		actionIter:       mo.None[iter.Iter[*replayTuple]](),
 	}
 }
 
 type logReplayIterator struct {
+	ctx              context.Context
 	logStore         store.Store
 	checkpointReader checkpointReader
 	reverseFilesIter iter.Iter[string]
@@ -127,15 +161,26 @@ func (l *logReplayIterator) getNextIter() (iter.Iter[*replayTuple], error) {
 	if err != nil {
 		return nil, err
 	}
+	return openLogFile(l.ctx, l.logStore, l.checkpointReader, nextFile)
+}
 
-	if strings.HasSuffix(nextFile, ".json") {
-		iter, err := l.logStore.Read(nextFile)
-		return &customJSONIterator{iter: iter}, err
-	} else if strings.HasSuffix(nextFile, ".parquet") {
-		iter, err := l.checkpointReader.Read(nextFile)
-		return &customParquetIterator{iter: iter}, err
+// openLogFile returns the actions of a delta file or a checkpoint file.
+func openLogFile(ctx context.Context, logStore store.Store, cr checkpointReader, path string) (iter.Iter[*replayTuple], error) {
+	// the top level file of a V2 checkpoint can be a json file as well
+	if filenames.IsCheckpointFile(path) {
+		iter, err := readCheckpointFile(ctx, logStore, cr, path)
+		if err != nil {
+			return nil, err
+		}
+		return &customCheckpointIterator{iter: iter}, nil
+	} else if strings.HasSuffix(path, ".json") {
+		iter, err := logStore.Read(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return &customJSONIterator{iter: iter}, nil
 	} else {
-		return nil, fmt.Errorf("unexpected log file path: %s", nextFile)
+		return nil, fmt.Errorf("unexpected log file path: %s", path)
 	}
 }
 
@@ -0,0 +1,159 @@
+package deltago
+
+import (
+	"context"
+	"io"
+
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// readFilesInOrder reads the files with up to concurrency files in parallel, and returns the content of the files
+// in the order of the files, so that the replay semantics do not depend on the concurrency.
+// At most concurrency files are read ahead of the consumer. The files are read one after another if concurrency is 0 or 1.
+func readFilesInOrder[T any](ctx context.Context, files []string, concurrency int,
+	read func(ctx context.Context, path string) ([]T, error)) iter.Iter[[]T] {
+	r := &orderedFileReader[T]{ctx: ctx, files: files, read: read}
+	if concurrency <= 1 {
+		return r
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.slots = make(chan struct{}, concurrency)
+	r.results = make([]chan fileContent[T], len(files))
+	for i := range r.results {
+		r.results[i] = make(chan fileContent[T], 1)
+	}
+	go r.start(ctx)
+	return r
+}
+
+type fileContent[T any] struct {
+	values []T
+	err    error
+}
+
+type orderedFileReader[T any] struct {
+	ctx   context.Context
+	files []string
+	read  func(ctx context.Context, path string) ([]T, error)
+	next  int
+	err   error
+
+	// only used if the files are read in parallel
+	cancel context.CancelFunc
+	// slots limits the number of the files which are read but not consumed yet
+	slots   chan struct{}
+	results []chan fileContent[T]
+}
+
+var _ iter.Iter[[]int] = &orderedFileReader[int]{}
+
+func (r *orderedFileReader[T]) start(ctx context.Context) {
+	for i, f := range r.files {
+		select {
+		case r.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		go func(i int, f string) {
+			values, err := r.read(ctx, f)
+			r.results[i] <- fileContent[T]{values: values, err: err}
+		}(i, f)
+	}
+}
+
+func (r *orderedFileReader[T]) Next() ([]T, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.next >= len(r.files) {
+		return nil, io.EOF
+	}
+
+	var content fileContent[T]
+	if r.slots == nil {
+		content.values, content.err = r.read(r.ctx, r.files[r.next])
+	} else {
+		select {
+		case content = <-r.results[r.next]:
+			<-r.slots
+		case <-r.ctx.Done():
+			content.err = r.ctx.Err()
+		}
+	}
+	r.next++
+
+	if content.err != nil {
+		r.err = content.err
+		if r.cancel != nil {
+			r.cancel()
+		}
+		return nil, content.err
+	}
+	return content.values, nil
+}
+
+// Close stops reading the remaining files, the files being read are discarded.
+func (r *orderedFileReader[T]) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return nil
+}
+
+// flattenIterator returns the values of the slices one by one.
+type flattenIterator[T any] struct {
+	it      iter.Iter[[]T]
+	current []T
+}
+
+func (f *flattenIterator[T]) Next() (T, error) {
+	for len(f.current) == 0 {
+		values, err := f.it.Next()
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		f.current = values
+	}
+
+	v := f.current[0]
+	f.current = f.current[1:]
+	return v, nil
+}
+
+func (f *flattenIterator[T]) Close() error {
+	return f.it.Close()
+}
+
+// concatIterator returns the values of the iterators one after another.
+type concatIterator[T any] struct {
+	its []iter.Iter[T]
+}
+
+func (c *concatIterator[T]) Next() (T, error) {
+	for len(c.its) > 0 {
+		v, err := c.its[0].Next()
+		if err != io.EOF {
+			return v, err
+		}
+		if err := c.its[0].Close(); err != nil {
+			var zero T
+			return zero, err
+		}
+		c.its = c.its[1:]
+	}
+	var zero T
+	return zero, io.EOF
+}
+
+func (c *concatIterator[T]) Close() error {
+	var err error
+	for _, it := range c.its {
+		if e := it.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	c.its = nil
+	return err
+}
@@ -0,0 +1,188 @@
+package deltago
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestReadFilesInOrder(t *testing.T) {
+	var files []string
+	for i := 0; i < 20; i++ {
+		files = append(files, strconv.Itoa(i))
+	}
+
+	for _, concurrency := range []int{0, 1, 4} {
+		t.Run(strconv.Itoa(concurrency), func(t *testing.T) {
+			var reading, maxReading int32
+			it := readFilesInOrder(context.Background(), files, concurrency, func(ctx context.Context, path string) ([]string, error) {
+				n := atomic.AddInt32(&reading, 1)
+				defer atomic.AddInt32(&reading, -1)
+				for {
+					m := atomic.LoadInt32(&maxReading)
+					if n <= m || atomic.CompareAndSwapInt32(&maxReading, m, n) {
+						break
+					}
+				}
+				// the later files are read faster
+				i, _ := strconv.Atoi(path)
+				time.Sleep(time.Duration(20-i) * time.Millisecond)
+				return []string{path, path}, nil
+			})
+			defer it.Close()
+
+			var res []string
+			values, err := it.Next()
+			for ; err == nil; values, err = it.Next() {
+				res = append(res, values...)
+			}
+			assert.Equal(t, io.EOF, err)
+
+			var expected []string
+			for _, f := range files {
+				expected = append(expected, f, f)
+			}
+			assert.Equal(t, expected, res)
+
+			if concurrency > 1 {
+				assert.LessOrEqual(t, maxReading, int32(concurrency))
+				assert.Greater(t, maxReading, int32(1))
+			} else {
+				assert.Equal(t, int32(1), maxReading)
+			}
+		})
+	}
+}
+
+func TestReadFilesInOrder_error(t *testing.T) {
+	readErr := errors.New("read error")
+	it := readFilesInOrder(context.Background(), []string{"0", "1", "2", "3"}, 2, func(ctx context.Context, path string) ([]string, error) {
+		if path == "1" {
+			return nil, readErr
+		}
+		return []string{path}, nil
+	})
+	defer it.Close()
+
+	values, err := it.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, values)
+	_, err = it.Next()
+	assert.Equal(t, readErr, err)
+	_, err = it.Next()
+	assert.Equal(t, readErr, err)
+}
+
+func TestSnapshot_parallel_replay(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	tt.config.CheckpointPartSize = 2
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	now := time.Now().UnixMilli()
+
+	commitTestActions(t, log, getTestMetedata(), testAddFile("a"), testAddFile("b"), testAddFile("c"), testAddFile("d"))
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	// the files re-added and removed in later versions must be reconciled in the version order
+	for i := 0; i < 4; i++ {
+		commitTestActions(t, log, testAddFile("e"+strconv.Itoa(i)), &action.RemoveFile{Path: "a", DeletionTimestamp: &now, DataChange: true})
+		commitTestActions(t, log, testAddFile("a"), &action.RemoveFile{Path: "e" + strconv.Itoa(i), DeletionTimestamp: &now, DataChange: true})
+	}
+	commitTestActions(t, log, &action.RemoveFile{Path: "b", DeletionTimestamp: &now, DataChange: true})
+
+	load := func(concurrency int) ([]string, []string, Snapshot) {
+		config := tt.config
+		config.ReplayConcurrency = concurrency
+		reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), config, &SystemClock{})
+		assert.NoError(t, err)
+		s, err := reloaded.Snapshot()
+		assert.NoError(t, err)
+
+		files, err := s.AllFiles()
+		assert.NoError(t, err)
+		var paths []string
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+		sort.Strings(paths)
+
+		tombstones, err := s.(*snapshotImp).tombstones(context.Background())
+		assert.NoError(t, err)
+		var removed []string
+		for _, r := range tombstones {
+			removed = append(removed, r.Path)
+		}
+		sort.Strings(removed)
+		return paths, removed, s
+	}
+
+	paths, removed, sequential := load(0)
+	assert.Equal(t, []string{"a", "c", "d"}, paths)
+	assert.Len(t, sequential.(*snapshotImp).logSegment.Checkpoints, 3)
+	assert.Len(t, sequential.(*snapshotImp).logSegment.Deltas, 9)
+
+	parallelPaths, parallelRemoved, parallel := load(4)
+	assert.Equal(t, paths, parallelPaths)
+	assert.Equal(t, removed, parallelRemoved)
+
+	metadata, err := parallel.Metadata()
+	assert.NoError(t, err)
+	expectedMetadata, err := sequential.Metadata()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMetadata, metadata)
+
+	// the reverse replay of a scan returns the latest action of each file first
+	scan, err := parallel.Scan(nil)
+	assert.NoError(t, err)
+	scanned, err := scan.Files()
+	assert.NoError(t, err)
+	scannedFiles, err := iter.ToSlice(scanned)
+	assert.NoError(t, err)
+	var scannedPaths []string
+	for _, f := range scannedFiles {
+		scannedPaths = append(scannedPaths, f.Path)
+	}
+	sort.Strings(scannedPaths)
+	assert.Equal(t, paths, scannedPaths)
+	// the reverse replay decodes the deltas in parallel, but streams the checkpoint parts
+	// after all the deltas are consumed, so no checkpoint part is held in memory
+	replay := parallel.(*snapshotImp).memoryOptimizedLogReplay
+	cr := &countingCheckpointReader{checkpointReader: replay.checkpointReader}
+	replay.checkpointReader = cr
+	reverse := replay.GetReverseIterator(context.Background())
+	deltaActions := 0
+	for {
+		r, err := reverse.Next()
+		assert.NoError(t, err)
+		if r.fromCheckpoint {
+			break
+		}
+		assert.Equal(t, 0, cr.reads)
+		deltaActions++
+	}
+	assert.Greater(t, deltaActions, 0)
+	assert.Equal(t, 1, cr.reads)
+	rest, err := iter.ToSlice(reverse)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cr.reads)
+	for _, r := range rest {
+		assert.True(t, r.fromCheckpoint)
+	}
+}
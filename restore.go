@@ -0,0 +1,272 @@
+package deltago
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/path"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/op"
+)
+
+// RestoreResult is the outcome of a Restore.
+type RestoreResult struct {
+	// Version is the version committed by the restore.
+	Version int64
+	// RestoredVersion is the version the table is restored to.
+	RestoredVersion int64
+	// NumRemovedFiles and RemovedFilesSize are the files of the latest version removed by the restore.
+	NumRemovedFiles  int64
+	RemovedFilesSize int64
+	// NumRestoredFiles and RestoredFilesSize are the files of the restored version added back by the restore.
+	NumRestoredFiles  int64
+	RestoredFilesSize int64
+}
+
+// Restore restores the table to the state of the version, by committing a RESTORE which removes the files added
+// after the version and adds back the files removed after it. The metadata of the version is restored too,
+// while the protocol is never downgraded: the features of the version which are not supported any more are added.
+// The data files of the version in the table directory must not have been deleted by Vacuum.
+func (l *logImpl) Restore(version int64) (*RestoreResult, error) {
+	return l.RestoreContext(context.Background(), version)
+}
+
+// RestoreContext is Restore with a context to cancel the reading of the log and the commit.
+func (l *logImpl) RestoreContext(ctx context.Context, version int64) (*RestoreResult, error) {
+	res, err := l.restore(ctx, func(ctx context.Context) (*snapshotImp, error) {
+		return l.snapshotReader.getSnapshotForVersionAsOf(ctx, version)
+	}, map[string]any{"version": version})
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+// RestoreToTimestamp restores the table to the version active at the timestamp in milliseconds, see Restore.
+func (l *logImpl) RestoreToTimestamp(timestamp int64) (*RestoreResult, error) {
+	return l.RestoreToTimestampContext(context.Background(), timestamp)
+}
+
+// RestoreToTimestampContext is RestoreToTimestamp with a context to cancel the reading of the log and the commit.
+func (l *logImpl) RestoreToTimestampContext(ctx context.Context, timestamp int64) (*RestoreResult, error) {
+	res, err := l.restore(ctx, func(ctx context.Context) (*snapshotImp, error) {
+		return l.snapshotReader.getSnapshotForTimestampAsOf(ctx, timestamp)
+	}, map[string]any{"timestamp": time.UnixMilli(timestamp).UTC().Format(time.RFC3339Nano)})
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) restore(ctx context.Context, loadTarget func(ctx context.Context) (*snapshotImp, error),
+	params map[string]any) (*RestoreResult, error) {
+
+	// the target is loaded first, so that it is never later than the snapshot of the transaction
+	target, err := loadTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ot, err := l.StartTransactionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trx := ot.(*optimisticTransactionImp)
+	latest := trx.snapshot
+	if latest.Version() < 0 {
+		return nil, eris.Wrap(errno.ErrIllegalState, "cannot restore a table which does not exist: "+l.dataPath)
+	}
+
+	latestFiles, err := latest.AllFilesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targetFiles, err := target.AllFilesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	toRemove, toRestore := diffFiles(latestFiles, targetFiles)
+
+	if err := l.checkRestoredFilesExist(ctx, target.Version(), toRestore); err != nil {
+		return nil, err
+	}
+
+	res := &RestoreResult{RestoredVersion: target.Version()}
+	now := l.clock.NowInMillis()
+	dataChange := true
+	actions := make([]action.Action, 0, len(toRemove)+len(toRestore))
+	for _, f := range toRemove {
+		remove := f.RemoveWithTimestamp(&now, &dataChange)
+		remove.ExtendedFileMetadata = true
+		remove.PartitionValues = f.PartitionValues
+		remove.Size = &f.Size
+		remove.Tags = f.Tags
+		actions = append(actions, remove)
+		res.NumRemovedFiles++
+		res.RemovedFilesSize += f.Size
+	}
+	for _, f := range toRestore {
+		actions = append(actions, f.Copy(true, f.Path))
+		res.NumRestoredFiles++
+		res.RestoredFilesSize += f.Size
+	}
+
+	if err := restoreMetadataAndProtocol(trx, latest, target); err != nil {
+		return nil, err
+	}
+
+	// any concurrent change of the files conflicts with the restore
+	if err := trx.ReadWholeTable(); err != nil {
+		return nil, err
+	}
+
+	var numFiles, tableSize int64
+	for _, f := range targetFiles {
+		numFiles++
+		tableSize += f.Size
+	}
+	committed, err := trx.CommitContext(ctx, iter.FromSlice(actions), &op.Operation{
+		Name:       op.RESTORE,
+		Parameters: params,
+		Metrics: map[string]int64{
+			op.MetricNumRemovedFiles:        res.NumRemovedFiles,
+			op.MetricRemovedFilesSize:       res.RemovedFilesSize,
+			op.MetricNumRestoredFiles:       res.NumRestoredFiles,
+			op.MetricRestoredFilesSize:      res.RestoredFilesSize,
+			op.MetricNumOfFilesAfterRestore: numFiles,
+			op.MetricTableSizeAfterRestore:  tableSize,
+		},
+	}, engineInfo)
+	if err != nil {
+		return nil, err
+	}
+	res.Version = committed.Version
+	return res, nil
+}
+
+// diffFiles returns the files of latest which are not in target, and the files of target which are not in latest.
+// A file is identified by its path and its deletion vector.
+// It is also used by Diff, where latest is the older version.
+func diffFiles(latest []*action.AddFile, target []*action.AddFile) ([]*action.AddFile, []*action.AddFile) {
+	key := func(f *action.AddFile) string {
+		return f.Path + "#" + f.DeletionVectorUniqueId()
+	}
+
+	latestKeys := make(map[string]struct{}, len(latest))
+	for _, f := range latest {
+		latestKeys[key(f)] = struct{}{}
+	}
+	targetKeys := make(map[string]struct{}, len(target))
+	for _, f := range target {
+		targetKeys[key(f)] = struct{}{}
+	}
+
+	var toRemove, toRestore []*action.AddFile
+	for _, f := range latest {
+		if _, ok := targetKeys[key(f)]; !ok {
+			toRemove = append(toRemove, f)
+		}
+	}
+	for _, f := range target {
+		if _, ok := latestKeys[key(f)]; !ok {
+			toRestore = append(toRestore, f)
+		}
+	}
+	return toRemove, toRestore
+}
+
+// checkRestoredFilesExist checks that the files to add back and the files of their deletion vectors
+// have not been deleted, e.g. by VACUUM. Only the files in the table directory are checked.
+func (l *logImpl) checkRestoredFilesExist(ctx context.Context, version int64, files []*action.AddFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	dataStore, scheme, err := l.openDataStore()
+	if err != nil {
+		return err
+	}
+	tableRoot, err := path.Canonicalize(strings.TrimSuffix(l.dataPath, "/")+"/", scheme)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	check := func(p string) error {
+		rel, ok, err := relativizeToTable(tableRoot, scheme, p)
+		if err != nil || !ok {
+			return err
+		}
+		exists, err := dataStore.Exists(ctx, rel)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, p)
+		}
+		return nil
+	}
+	for _, f := range files {
+		if err := check(f.Path); err != nil {
+			return err
+		}
+		if f.DeletionVector != nil && f.DeletionVector.IsOnDisk() {
+			dvPath, err := f.DeletionVector.AbsolutePath(tableRoot)
+			if err != nil {
+				return err
+			}
+			if err := check(dvPath); err != nil {
+				return err
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return errno.RestoreMissingFilesError(version, missing)
+	}
+	return nil
+}
+
+// restoreMetadataAndProtocol updates the metadata of the transaction to the one of target if it is different,
+// and adds the features of the protocol of target which are not supported by the protocol of latest.
+func restoreMetadataAndProtocol(trx *optimisticTransactionImp, latest *snapshotImp, target *snapshotImp) error {
+	latestMetadata, err := latest.Metadata()
+	if err != nil {
+		return err
+	}
+	targetMetadata, err := target.Metadata()
+	if err != nil {
+		return err
+	}
+	if !latestMetadata.Equals(targetMetadata) {
+		if err := trx.UpdateMetadata(targetMetadata); err != nil {
+			return err
+		}
+	}
+
+	latestProtocol, err := latest.Protocol()
+	if err != nil {
+		return err
+	}
+	targetProtocol, err := target.Protocol()
+	if err != nil {
+		return err
+	}
+	var features []*action.TableFeature
+	for _, name := range targetProtocol.WriterFeatureNames() {
+		f, ok := action.GetTableFeature(name)
+		if !ok {
+			return errno.UnknownTableFeatureError(name)
+		}
+		features = append(features, f)
+	}
+	if restored := latestProtocol.WithFeatures(features...); restored != latestProtocol {
+		trx.newProtocol = mo.Some(restored)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package deltago
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/internal/util/z85"
+	"github.com/csimplestring/delta-go/op"
+)
+
+func TestLog_restore(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	dataDir := strings.TrimPrefix(strings.TrimSuffix(l.dataPath, "/"), "file://")
+
+	add := func(path string, size int64) *action.AddFile {
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, path), []byte(path), 0644))
+		f := testAddFile(path)
+		f.Size = size
+		return f
+	}
+	paths := func(s Snapshot) []string {
+		files, err := s.AllFiles()
+		assert.NoError(t, err)
+		var res []string
+		for _, f := range files {
+			res = append(res, f.Path)
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	metadata := getTestMetedata()
+	commitTestActions(t, log, metadata, add("a", 1), add("b", 2))
+	commitTestActions(t, log, add("c", 4), testRemoveFile("a"))
+	updated := getTestMetedata()
+	updated.Configuration = map[string]string{"delta.appendOnly": "false"}
+	commitTestActions(t, log, updated, add("d", 8))
+
+	res, err := log.Restore(0)
+	assert.NoError(t, err)
+	assert.Equal(t, &RestoreResult{
+		Version:           3,
+		RestoredVersion:   0,
+		NumRemovedFiles:   2,
+		RemovedFilesSize:  12,
+		NumRestoredFiles:  1,
+		RestoredFilesSize: 1,
+	}, res)
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), s.Version())
+	assert.Equal(t, []string{"a", "b"}, paths(s))
+	restoredMetadata, err := s.Metadata()
+	assert.NoError(t, err)
+	assert.True(t, metadata.Equals(restoredMetadata))
+
+	page, err := log.History(HistoryOptions{Limit: 1})
+	assert.NoError(t, err)
+	ci := page.Commits[0]
+	assert.Equal(t, op.RESTORE.String(), ci.Operation)
+	assert.Equal(t, float64(0), ci.OperationParameters["version"])
+	assert.Equal(t, "1", ci.OperationMetrics[op.MetricNumRestoredFiles])
+	assert.Equal(t, "2", ci.OperationMetrics[op.MetricNumRemovedFiles])
+	assert.Equal(t, "2", ci.OperationMetrics[op.MetricNumOfFilesAfterRestore])
+	assert.Equal(t, "3", ci.OperationMetrics[op.MetricTableSizeAfterRestore])
+
+	// restore to the version active at the timestamp
+	base := time.Now().Add(-time.Hour)
+	logDir := strings.TrimPrefix(l.logPath, "file://")
+	for v := int64(0); v <= 3; v++ {
+		mtime := base.Add(time.Duration(v) * time.Minute)
+		assert.NoError(t, os.Chtimes(filepath.Join(logDir, filenames.DeltaFile("", v)), mtime, mtime))
+	}
+	res, err = log.RestoreToTimestamp(base.Add(150 * time.Second).UnixMilli())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.RestoredVersion)
+	s, err = log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, paths(s))
+	restoredMetadata, err = s.Metadata()
+	assert.NoError(t, err)
+	assert.Equal(t, "false", restoredMetadata.Configuration["delta.appendOnly"])
+
+	// the files removed by vacuum cannot be restored
+	assert.NoError(t, os.Remove(filepath.Join(dataDir, "a")))
+	_, err = log.Restore(0)
+	assert.True(t, eris.Is(err, errno.ErrFileNotFound))
+	assert.Contains(t, err.Error(), "cannot restore the table to version 0")
+
+	_, err = log.Restore(10)
+	assert.Error(t, err)
+
+	s, err = log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), s.Version())
+
+	// the files of the deletion vectors removed by vacuum cannot be restored either
+	offset := int32(1)
+	dv := &action.DeletionVectorDescriptor{
+		StorageType:    action.DeletionVectorStorageUUIDRelative,
+		PathOrInlineDv: z85.Encode(make([]byte, 16)),
+		Offset:         &offset,
+		SizeInBytes:    10,
+		Cardinality:    1,
+	}
+	dvPath, err := dv.AbsolutePath(dataDir)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dvPath, []byte("dv"), 0644))
+	withDV := add("b", 2)
+	withDV.DeletionVector = dv
+	commitTestActions(t, log, testRemoveFile("b"), withDV)
+	removeDV := testRemoveFile("b")
+	removeDV.DeletionVector = dv
+	commitTestActions(t, log, removeDV)
+	assert.NoError(t, os.Remove(dvPath))
+	_, err = log.Restore(5)
+	assert.True(t, eris.Is(err, errno.ErrFileNotFound))
+	assert.Contains(t, err.Error(), filepath.Base(dvPath))
+}
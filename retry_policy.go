@@ -0,0 +1,81 @@
+package deltago
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/csimplestring/delta-go/errno"
+)
+
+// RetryPolicy controls the retries of a commit after a concurrent commit wins the version it attempts.
+// Before each retry the commit waits for a backoff, which grows exponentially from InitialBackoff up to MaxBackoff.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of the commit attempts including the first one,
+	// DELTA_MAX_RETRY_COMMIT_ATTEMPTS if it is not positive.
+	MaxAttempts int
+	// MaxElapsedTime is the maximum time to retry a commit, a retry is not started if it would wait past it.
+	// There is no limit if it is not positive.
+	MaxElapsedTime time.Duration
+	// InitialBackoff is the backoff before the first retry, the commit is retried immediately if it is not positive.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff, it is not capped if it is not positive.
+	MaxBackoff time.Duration
+	// Multiplier is the growth of the backoff after each retry, 2 if it is less than 1.
+	Multiplier float64
+	// Jitter in [0, 1] reduces each backoff by a random fraction up to it, so that concurrent writers do not retry together.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the policy used if it is not set in the Config or on the transaction,
+// it retries a commit immediately up to DELTA_MAX_RETRY_COMMIT_ATTEMPTS attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: DELTA_MAX_RETRY_COMMIT_ATTEMPTS,
+	}
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return DELTA_MAX_RETRY_COMMIT_ATTEMPTS
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the backoff before the retry, retry starts from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if d > math.MaxInt64/2 {
+		d = math.MaxInt64 / 2
+	}
+	if p.Jitter > 0 {
+		d -= d * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errno.CheckContext(ctx)
+	}
+}
@@ -0,0 +1,122 @@
+package deltago
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(4))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(1000))
+
+	p.Multiplier = 3
+	p.MaxBackoff = 0
+	assert.Equal(t, 90*time.Millisecond, p.backoff(3))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2)
+		assert.GreaterOrEqual(t, d, 15*time.Millisecond)
+		assert.LessOrEqual(t, d, 30*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(5))
+	assert.Equal(t, DELTA_MAX_RETRY_COMMIT_ATTEMPTS, RetryPolicy{}.maxAttempts())
+}
+
+func TestTrx_commit_retry_policy(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	tt.config.RetryPolicy = mo.Some(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	res, err := trx.Commit(iter.FromSlice([]action.Action{getTestMetedata()}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+	assert.Equal(t, CommitResult{Version: 0, Attempts: 1}, res)
+
+	// the transactions start at the same version, the later ones retry at the version after the winning commits
+	var trxs []OptimisticTransaction
+	for i := 0; i < 3; i++ {
+		trx, err := log.StartTransaction()
+		assert.NoError(t, err)
+		trxs = append(trxs, trx)
+	}
+	for i, attempts := range []int{1, 2, 2} {
+		res, err := trxs[i].Commit(iter.FromSlice([]action.Action{testAddFile(string(rune('a' + i)))}), getTestManualUpdate(), getTestEngineInfo())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+1), res.Version)
+		assert.Equal(t, attempts, res.Attempts)
+	}
+
+	// max attempts
+	trx, err = log.StartTransaction()
+	assert.NoError(t, err)
+	trx.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	winner, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = winner.Commit(iter.FromSlice([]action.Action{testAddFile("d")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{testAddFile("e")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.True(t, eris.Is(err, errno.ErrIllegalState))
+	assert.Contains(t, err.Error(), "max commit attempts (1) exceeded")
+
+	// max elapsed time
+	trx, err = log.StartTransaction()
+	assert.NoError(t, err)
+	trx.SetRetryPolicy(RetryPolicy{InitialBackoff: time.Hour, MaxElapsedTime: time.Minute})
+	winner, err = log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = winner.Commit(iter.FromSlice([]action.Action{testAddFile("f")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{testAddFile("g")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.True(t, eris.Is(err, errno.ErrIllegalState))
+	assert.Contains(t, err.Error(), "max commit retry time (1m0s) exceeded after 1 attempts")
+
+	// the elapsed time is measured with the clock of the log
+	l := log.(*logImpl)
+	clocked, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &steppingClock{step: time.Minute})
+	assert.NoError(t, err)
+	trx, err = clocked.StartTransaction()
+	assert.NoError(t, err)
+	trx.SetRetryPolicy(RetryPolicy{MaxElapsedTime: 59 * time.Second})
+	winner, err = log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = winner.Commit(iter.FromSlice([]action.Action{testAddFile("h")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{testAddFile("i")}), getTestManualUpdate(), getTestEngineInfo())
+	assert.True(t, eris.Is(err, errno.ErrIllegalState))
+	assert.Contains(t, err.Error(), "max commit retry time (59s) exceeded after 1 attempts")
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), s.Version())
+}
+
+// steppingClock advances by step every time the time is read in nanoseconds.
+type steppingClock struct {
+	SystemClock
+	step time.Duration
+	now  int64
+}
+
+func (c *steppingClock) NowInNano() int64 {
+	c.now += int64(c.step)
+	return c.now
+}
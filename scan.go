@@ -1,8 +1,10 @@
 package deltago
 
 import (
+	"context"
 	"io"
 
+	"github.com/barweiss/go-tuple"
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/internal/util"
 	"github.com/csimplestring/delta-go/internal/util/path"
@@ -15,8 +17,8 @@ import (
 
 // Scan provides access to an iterator over the files in this snapshot.
 // Typically created with a read predicate Expression to let users filter files.
-// Please note filtering is only supported on partition columns and
-// users should use getResidualPredicate() to check for any unapplied portion of the input predicate.
+// Please note filtering is only guaranteed on partition columns. Files are also skipped using the column statistics
+// if possible, but users should use ResidualPredicate() to check for any unapplied portion of the input predicate.
 type Scan interface {
 
 	// Creates a CloseableIterator over files belonging to this snapshot.
@@ -26,6 +28,9 @@ type Scan interface {
 	// a CloseableIterator over the files in this snapshot that satisfy getPushedPredicate()
 	Files() (iter.Iter[*action.AddFile], error)
 
+	// FilesContext is Files with a context, the context is used to read the log files during the iteration.
+	FilesContext(ctx context.Context) (iter.Iter[*action.AddFile], error)
+
 	// InputPredicate Returns the input predicate passed in by the user
 	InputPredicate() expr.Expression
 
@@ -37,6 +42,11 @@ type Scan interface {
 	// ResidualPredicate Returns portion of the input predicate that may not be fully applied.
 	// Files returned by Files() are not guaranteed to satisfy the residual predicate, and the caller should still apply them on the returned files.
 	ResidualPredicate() expr.Expression
+
+	// DataSkippingPredicate Returns portion of the residual predicate used to skip files by their column statistics.
+	// Files whose statistics show that no row can satisfy it are not returned by Files(),
+	// but the returned files are not guaranteed to satisfy it.
+	DataSkippingPredicate() expr.Expression
 }
 
 type accepter interface {
@@ -58,10 +68,14 @@ type scan struct {
 }
 
 func (s *scan) Files() (iter.Iter[*action.AddFile], error) {
+	return s.FilesContext(context.Background())
+}
+
+func (s *scan) FilesContext(ctx context.Context) (iter.Iter[*action.AddFile], error) {
 	return &scanFileIterator{
-		iter:         s.replay.GetReverseIterator(),
-		addFiles:     mapset.NewSet[string](),
-		tombstones:   mapset.NewSet[string](),
+		iter:         s.replay.GetReverseIterator(ctx),
+		addFiles:     mapset.NewSet[tuple.T2[string, string]](),
+		tombstones:   mapset.NewSet[tuple.T2[string, string]](),
 		nextMatching: mo.None[*action.AddFile](),
 		config:       s.config,
 		fileAccepter: s.fileAccepter,
@@ -80,10 +94,15 @@ func (s *scan) ResidualPredicate() expr.Expression {
 	return nil
 }
 
+func (s *scan) DataSkippingPredicate() expr.Expression {
+	return nil
+}
+
 type scanFileIterator struct {
-	iter         iter.Iter[*replayTuple]
-	addFiles     mapset.Set[string]
-	tombstones   mapset.Set[string]
+	iter iter.Iter[*replayTuple]
+	// files are keyed by (canonical path, deletion vector unique id)
+	addFiles     mapset.Set[tuple.T2[string, string]]
+	tombstones   mapset.Set[tuple.T2[string, string]]
 	nextMatching mo.Option[*action.AddFile]
 	config       Config
 	fileAccepter accepter
@@ -91,11 +110,12 @@ type scanFileIterator struct {
 
 func (s *scanFileIterator) findNextValid() (mo.Option[*action.AddFile], error) {
 	var err error
-	for tuple, err := s.iter.Next(); err == nil; tuple, err = s.iter.Next() {
+	var rt *replayTuple
+	for rt, err = s.iter.Next(); err == nil; rt, err = s.iter.Next() {
 
-		isCheckpoint := tuple.fromCheckpoint
+		isCheckpoint := rt.fromCheckpoint
 
-		switch a := tuple.act.(type) {
+		switch a := rt.act.(type) {
 		case *action.AddFile:
 			canonicalPath, err := path.Canonicalize(a.Path, s.config.StoreType)
 			if err != nil {
@@ -103,12 +123,13 @@ func (s *scanFileIterator) findNextValid() (mo.Option[*action.AddFile], error) {
 			}
 
 			canonicalizeAdd := a.Copy(false, canonicalPath)
-			alreadyDeleted := s.tombstones.Contains(canonicalizeAdd.Path)
-			alreadyReturned := s.addFiles.Contains(canonicalizeAdd.Path)
+			key := tuple.New2(canonicalizeAdd.Path, canonicalizeAdd.DeletionVectorUniqueId())
+			alreadyDeleted := s.tombstones.Contains(key)
+			alreadyReturned := s.addFiles.Contains(key)
 
 			if !alreadyReturned {
 				if !isCheckpoint {
-					s.addFiles.Add(canonicalizeAdd.Path)
+					s.addFiles.Add(key)
 				}
 				if !alreadyDeleted {
 					return mo.Some(canonicalizeAdd), nil
@@ -122,7 +143,7 @@ func (s *scanFileIterator) findNextValid() (mo.Option[*action.AddFile], error) {
 					return mo.None[*action.AddFile](), err
 				}
 				canonicalizeRemove := a.Copy(false, canonicalPath)
-				s.tombstones.Add(canonicalizeRemove.Path)
+				s.tombstones.Add(tuple.New2(canonicalizeRemove.Path, canonicalizeRemove.DeletionVectorUniqueId()))
 			}
 		}
 	}
@@ -180,9 +201,14 @@ func (s *scanFileIterator) Close() error {
 type filteredScanAccepter struct {
 	metadataConjunction mo.Option[expr.Expression]
 	partitionSchema     *expr.StructType
+	physicalNames       map[string]string
+	dataSkipping        *dataSkippingFilter
 }
 
 func (f *filteredScanAccepter) accept(addFile *action.AddFile) bool {
+	if !f.dataSkipping.mightMatch(addFile) {
+		return false
+	}
 	if f.metadataConjunction.IsAbsent() {
 		return true
 	}
@@ -190,6 +216,7 @@ func (f *filteredScanAccepter) accept(addFile *action.AddFile) bool {
 	r := &PartitionRowRecord{
 		partitionSchema: f.partitionSchema,
 		partitionValues: addFile.PartitionValues,
+		physicalNames:   f.physicalNames,
 	}
 	result, err := f.metadataConjunction.MustGet().Eval(r)
 	if err != nil {
@@ -203,19 +230,26 @@ type filteredScan struct {
 	exp                 expr.Expression
 	metadataConjunction expr.Expression
 	dataConjunction     expr.Expression
+	dataSkipping        *dataSkippingFilter
 	partitionSchema     *expr.StructType
 }
 
-func newFilteredScan(replay *MemoryOptimizedLogReplay, config Config, exp expr.Expression, partitionSchema *expr.StructType) (*filteredScan, error) {
+// newFilteredScan creates a scan filtered by the predicate exp,
+// physicalNames maps the logical column names to the physical ones in column mapping mode, nil otherwise.
+func newFilteredScan(replay *MemoryOptimizedLogReplay, config Config, exp expr.Expression,
+	schema *expr.StructType, partitionSchema *expr.StructType, physicalNames map[string]string) (*filteredScan, error) {
 
 	// extract
 	metadataConjunction, dataConjunction := util.SplitMetadataAndDataPredicates(exp, partitionSchema.FieldNames())
+	dataSkipping := newDataSkippingFilter(dataConjunction, schema, physicalNames)
 
 	s := &scan{
 		replay: replay,
 		fileAccepter: &filteredScanAccepter{
 			metadataConjunction: metadataConjunction,
 			partitionSchema:     partitionSchema,
+			physicalNames:       physicalNames,
+			dataSkipping:        dataSkipping,
 		},
 		config: config,
 	}
@@ -224,6 +258,7 @@ func newFilteredScan(replay *MemoryOptimizedLogReplay, config Config, exp expr.E
 		exp:                 exp,
 		metadataConjunction: metadataConjunction.OrEmpty(),
 		dataConjunction:     dataConjunction.OrEmpty(),
+		dataSkipping:        dataSkipping,
 		partitionSchema:     partitionSchema,
 	}
 
@@ -234,6 +269,10 @@ func (f *filteredScan) Files() (iter.Iter[*action.AddFile], error) {
 	return f.s.Files()
 }
 
+func (f *filteredScan) FilesContext(ctx context.Context) (iter.Iter[*action.AddFile], error) {
+	return f.s.FilesContext(ctx)
+}
+
 func (f *filteredScan) InputPredicate() expr.Expression {
 	return f.exp
 }
@@ -245,3 +284,16 @@ func (f *filteredScan) PushedPredicate() expr.Expression {
 func (f *filteredScan) ResidualPredicate() expr.Expression {
 	return f.dataConjunction
 }
+
+func (f *filteredScan) DataSkippingPredicate() expr.Expression {
+	return f.dataSkipping.used.OrEmpty()
+}
+
+// columnMappingPhysicalNames returns the physical names of the columns if column mapping is enabled, nil otherwise.
+func columnMappingPhysicalNames(metadata *action.Metadata) (map[string]string, error) {
+	mode, err := metadata.ColumnMappingMode()
+	if err != nil || mode == action.ColumnMappingModeNone {
+		return nil, err
+	}
+	return metadata.PhysicalNames()
+}
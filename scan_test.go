@@ -1,6 +1,8 @@
 package deltago
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"sort"
 	"strconv"
@@ -273,7 +275,7 @@ func TestScan_correct_reverse_replay(t *testing.T) {
 
 			for _, i := range []int64{12, 14, 15, 17} {
 				path := filenames.DeltaFile(store.Root(), i)
-				err = store.Write(path, iter.FromSlice([]string{}), true)
+				err = store.Write(context.Background(), path, iter.FromSlice([]string{}), true)
 				assert.NoError(t, err)
 			}
 
@@ -311,3 +313,167 @@ func TestScan_correct_reverse_replay(t *testing.T) {
 		})
 	}
 }
+
+func TestScan_data_skipping_with_column_stats(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	f := newScanTestFixtures()
+	metadata := &action.Metadata{SchemaString: f.schemaString}
+
+	// file i contains col3 in [10i, 10i+9], the odd files contain nulls, file "4" has no stats
+	var files []action.Action
+	for i := 0; i < 4; i++ {
+		files = append(files, &action.AddFile{
+			Path:             strconv.Itoa(i),
+			PartitionValues:  map[string]string{},
+			Size:             1,
+			ModificationTime: 1,
+			DataChange:       true,
+			Stats: fmt.Sprintf(`{"numRecords":10,"minValues":{"col3":%d},"maxValues":{"col3":%d},"nullCount":{"col3":%d}}`,
+				i*10, i*10+9, i%2),
+		})
+	}
+	files = append(files, &action.AddFile{Path: "4", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true})
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice(append([]action.Action{metadata}, files...)), f.op, "engineInfo")
+	assert.NoError(t, err)
+
+	col3 := f.schema.Column("col3")
+	col4 := f.schema.Column("col4")
+	cases := []struct {
+		name     string
+		filter   types.Expression
+		skipping types.Expression
+		expected []string
+	}{
+		{"equal", types.NewEqualTo(col3, types.LiteralInt(15)), types.NewEqualTo(col3, types.LiteralInt(15)), []string{"1", "4"}},
+		{"literal on the left", types.NewEqualTo(types.LiteralInt(15), col3), types.NewEqualTo(types.LiteralInt(15), col3), []string{"1", "4"}},
+		{"less than", types.NewLessThan(col3, types.LiteralInt(10)), types.NewLessThan(col3, types.LiteralInt(10)), []string{"0", "4"}},
+		{"greater or equal", types.NewGreaterThanOrEq(col3, types.LiteralInt(29)), types.NewGreaterThanOrEq(col3, types.LiteralInt(29)), []string{"2", "3", "4"}},
+		{"is null", types.NewIsNull(col3), types.NewIsNull(col3), []string{"1", "3", "4"}},
+		{"not", types.NewNot(types.NewLessThan(col3, types.LiteralInt(30))), types.NewNot(types.NewLessThan(col3, types.LiteralInt(30))), []string{"3", "4"}},
+		{"or", types.NewOr(types.NewEqualTo(col3, types.LiteralInt(5)), types.NewEqualTo(col3, types.LiteralInt(35))),
+			types.NewOr(types.NewEqualTo(col3, types.LiteralInt(5)), types.NewEqualTo(col3, types.LiteralInt(35))), []string{"0", "3", "4"}},
+		{"and with an unsupported conjunct",
+			types.NewAnd(types.NewGreaterThanOrEq(col3, types.LiteralInt(20)), types.NewLessThan(col3, col4)),
+			types.NewGreaterThanOrEq(col3, types.LiteralInt(20)), []string{"2", "3", "4"}},
+		{"or with an unsupported disjunct",
+			types.NewOr(types.NewEqualTo(col3, types.LiteralInt(5)), types.NewLessThan(col3, col4)),
+			nil, []string{"0", "1", "2", "3", "4"}},
+	}
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			scan, err := s.Scan(c.filter)
+			assert.NoError(t, err)
+
+			fIter, err := scan.Files()
+			assert.NoError(t, err)
+			addFiles, err := iter.ToSlice(fIter)
+			assert.NoError(t, err)
+
+			paths := fp.Map(func(a *action.AddFile) string { return a.Path })(addFiles)
+			sort.Strings(paths)
+			assert.Equal(t, c.expected, paths)
+
+			assert.Equal(t, c.filter.String(), scan.ResidualPredicate().String())
+			if c.skipping == nil {
+				assert.Nil(t, scan.DataSkippingPredicate())
+			} else {
+				assert.Equal(t, c.skipping.String(), scan.DataSkippingPredicate().String())
+			}
+		})
+	}
+}
+
+func TestScan_column_mapping(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	field := func(name string, id int, physicalName string) string {
+		return fmt.Sprintf(`{"name":"%s","type":"integer","nullable":true,"metadata":{"delta.columnMapping.id":%d,"delta.columnMapping.physicalName":"%s"}}`,
+			name, id, physicalName)
+	}
+	schemaString := `{"type":"struct","fields":[` + field("part", 1, "col-5f3a") + "," + field("value", 2, "col-9b1c") + `]}`
+	metadata := &action.Metadata{
+		ID:               "id",
+		Format:           action.Format{Proviver: "parquet", Options: map[string]string{}},
+		SchemaString:     schemaString,
+		PartitionColumns: []string{"part"},
+		Configuration:    map[string]string{action.ColumnMappingModeProp: string(action.ColumnMappingModeName)},
+	}
+	protocol := &action.Protocol{MinReaderVersion: 2, MinWriterVersion: 5}
+
+	// the partition values and stats of the data files are keyed by the physical names
+	var actions []action.Action
+	actions = append(actions, protocol, metadata)
+	for i := 0; i < 3; i++ {
+		actions = append(actions, &action.AddFile{
+			Path:             strconv.Itoa(i),
+			PartitionValues:  map[string]string{"col-5f3a": strconv.Itoa(i % 2)},
+			Size:             1,
+			ModificationTime: 1,
+			DataChange:       true,
+			Stats:            fmt.Sprintf(`{"numRecords":10,"minValues":{"col-9b1c":%d},"maxValues":{"col-9b1c":%d}}`, i*10, i*10+9),
+		})
+	}
+	var lines []string
+	for _, a := range actions {
+		line, err := a.Json()
+		assert.NoError(t, err)
+		lines = append(lines, line)
+	}
+
+	// delta-go can not write tables in column mapping mode, so the commit is written by another writer
+	l := log.(*logImpl)
+	assert.NoError(t, l.store.Create(context.Background(), l.logPath))
+	assert.NoError(t, l.store.Write(context.Background(), filenames.DeltaFile(l.logPath, 0), iter.FromSlice(lines), false))
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	m, err := s.Metadata()
+	assert.NoError(t, err)
+	physicalNames, err := m.PhysicalNames()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"part": "col-5f3a", "value": "col-9b1c"}, physicalNames)
+
+	schema, err := m.Schema()
+	assert.NoError(t, err)
+	part := schema.Column("part")
+	value := schema.Column("value")
+	cases := []struct {
+		name     string
+		filter   types.Expression
+		expected []string
+	}{
+		{"partition predicate", types.NewEqualTo(part, types.LiteralInt(1)), []string{"1"}},
+		{"data predicate", types.NewGreaterThanOrEq(value, types.LiteralInt(15)), []string{"1", "2"}},
+		{"both", types.NewAnd(types.NewEqualTo(part, types.LiteralInt(0)), types.NewLessThan(value, types.LiteralInt(15))), []string{"0"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			scan, err := s.Scan(c.filter)
+			assert.NoError(t, err)
+			fIter, err := scan.Files()
+			assert.NoError(t, err)
+			addFiles, err := iter.ToSlice(fIter)
+			assert.NoError(t, err)
+
+			paths := fp.Map(func(a *action.AddFile) string { return a.Path })(addFiles)
+			sort.Strings(paths)
+			assert.Equal(t, c.expected, paths)
+		})
+	}
+}
@@ -1,15 +1,18 @@
 package deltago
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/barweiss/go-tuple"
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/csimplestring/delta-go/store"
 	expr "github.com/csimplestring/delta-go/types"
@@ -26,6 +29,9 @@ type Snapshot interface {
 	// AllFiles returns all of the files present in this snapshot
 	AllFiles() ([]*action.AddFile, error)
 
+	// AllFilesContext is AllFiles with a context to cancel the loading of the files.
+	AllFilesContext(ctx context.Context) ([]*action.AddFile, error)
+
 	// Metadata returns the table metadata for this snapshot
 	Metadata() (*action.Metadata, error)
 
@@ -38,14 +44,21 @@ type Snapshot interface {
 	// EarliestVersion returns the earliest version in this Snapshot
 	EarliestVersion() (int64, error)
 
+	// EarliestVersionContext is EarliestVersion with a context to cancel the reading of the last checkpoint.
+	EarliestVersionContext(ctx context.Context) (int64, error)
+
 	// todo: i do not want to implement this for now
 	// CloseableIterator<RowRecord> open();
 }
 
 type snapshotState struct {
+	// replay is the replayed state, from which the state of the next snapshot is derived.
+	// It is handed over to the next snapshot by takeReplay, so that the replay is neither copied nor kept twice.
+	replay               *InMemoryLogReplay
+	replayLock           sync.Mutex
 	setTransactions      []*action.SetTransaction
-	activeFiles          iter.Iter[*action.AddFile]
-	tombstones           iter.Iter[*action.RemoveFile]
+	activeFiles          []*action.AddFile
+	tombstones           []*action.RemoveFile
 	sizeInBytes          int64
 	numOfFiles           int64
 	numOfRemoves         int64
@@ -62,14 +75,15 @@ type snapshotImp struct {
 	store                     store.Store
 	checkpointReader          checkpointReader
 
-	state               *util.Lazy[*snapshotState]
-	activeFiles         *util.Lazy[[]*action.AddFile]
-	protocolAndMetadata *util.Lazy[*tuple.T2[*action.Protocol, *action.Metadata]]
+	state       *util.Lazy[*snapshotState]
+	activeFiles *util.Lazy[[]*action.AddFile]
+	// protocolAndMetadata is loaded when the snapshot is created
+	protocolAndMetadata *tuple.T2[*action.Protocol, *action.Metadata]
 
 	memoryOptimizedLogReplay *MemoryOptimizedLogReplay
 }
 
-func newSnapshotImp(config Config, path string, version int64, logsegment *LogSegment,
+func newSnapshotImp(ctx context.Context, config Config, path string, version int64, logsegment *LogSegment,
 	minFileRetentionTimestamp int64, timestamp int64, store store.Store, checkpointReader checkpointReader) (*snapshotImp, error) {
 	s := &snapshotImp{
 		config:                    config,
@@ -86,20 +100,99 @@ func newSnapshotImp(config Config, path string, version int64, logsegment *LogSe
 		files:            s.files(),
 		logStore:         s.store,
 		checkpointReader: s.checkpointReader,
+		concurrency:      s.config.ReplayConcurrency,
 	}
 
 	s.state = util.LazyValue(s.loadState)
 	s.activeFiles = util.LazyValue(s.loadActiveFiles)
-	s.protocolAndMetadata = util.LazyValue(s.loadTableProtoclAndMetadata)
 
-	t, err := s.protocolAndMetadata.Get()
+	t, err := s.loadTableProtoclAndMetadata(ctx)
 	if err != nil {
 		return nil, eris.Wrap(err, "fail to get protocol and metadata when initializing snapshots")
 	}
+	s.protocolAndMetadata = t
 
 	return s, assertProtocolRead(t.V1)
 }
 
+// newIncrementalSnapshotImp creates the snapshot of the log segment, which has the same checkpoint as the log segment of prev
+// and more deltas. Only the new deltas are read: the protocol and metadata are taken from them or from prev,
+// and if the state of prev is already loaded, the state is derived by replaying the new deltas on it.
+// It returns false if the log segment does not extend the log segment of prev.
+func newIncrementalSnapshotImp(ctx context.Context, prev *snapshotImp, segment *LogSegment,
+	minFileRetentionTimestamp int64, timestamp int64) (*snapshotImp, bool, error) {
+	newDeltas, ok := prev.logSegment.newDeltas(segment)
+	if !ok {
+		return nil, false, nil
+	}
+
+	s := &snapshotImp{
+		config:                    prev.config,
+		path:                      prev.path,
+		version:                   segment.Version,
+		logSegment:                segment,
+		minFileRetentionTimestamp: minFileRetentionTimestamp,
+		timestamp:                 timestamp,
+		store:                     prev.store,
+		checkpointReader:          prev.checkpointReader,
+	}
+
+	s.memoryOptimizedLogReplay = &MemoryOptimizedLogReplay{
+		files:            s.files(),
+		logStore:         s.store,
+		checkpointReader: s.checkpointReader,
+		concurrency:      s.config.ReplayConcurrency,
+	}
+	s.activeFiles = util.LazyValue(s.loadActiveFiles)
+
+	protocol, metadata := prev.protocolAndMetadata.V1, prev.protocolAndMetadata.V2
+	versions := make([]int64, len(newDeltas))
+	deltaActions := make([][]action.Action, len(newDeltas))
+	for i, f := range newDeltas {
+		singleActions, err := s.loadInMemory(ctx, []string{f.Path()})
+		if err != nil {
+			return nil, false, err
+		}
+		versions[i] = filenames.DeltaVersion(f.Path())
+		deltaActions[i] = make([]action.Action, len(singleActions))
+		for j, sa := range singleActions {
+			a := sa.Unwrap()
+			switch v := a.(type) {
+			case *action.Protocol:
+				protocol = v
+			case *action.Metadata:
+				metadata = v
+			}
+			deltaActions[i][j] = a
+		}
+	}
+	t := tuple.New2(protocol, metadata)
+	s.protocolAndMetadata = &t
+
+	// replaying the new deltas on a state which is not loaded yet is not cheaper than a full replay,
+	// neither is it possible if the replay of the state was already handed over to another snapshot
+	var replay *InMemoryLogReplay
+	if prevState, loaded := prev.state.Peek(); loaded {
+		replay = prevState.takeReplay()
+	}
+	if replay == nil {
+		s.state = util.LazyValue(s.loadState)
+		return s, true, assertProtocolRead(protocol)
+	}
+
+	// the new deltas are few, they are replayed now, only the materialization of the state is deferred
+	replay.MinFileRetentionTimestamp = minFileRetentionTimestamp
+	for i, actions := range deltaActions {
+		if err := replay.Append(versions[i], iter.FromSlice(actions)); err != nil {
+			return nil, false, err
+		}
+	}
+	s.state = util.LazyValue(func(ctx context.Context) (*snapshotState, error) {
+		return newSnapshotState(replay, s.version)
+	})
+	return s, true, assertProtocolRead(protocol)
+}
+
 // Scan scan of the files in this snapshot matching the pushed portion of predicate
 func (s *snapshotImp) Scan(predicate expr.Expression) (Scan, error) {
 	if predicate == nil {
@@ -115,29 +208,43 @@ func (s *snapshotImp) Scan(predicate expr.Expression) (Scan, error) {
 		return nil, err
 	}
 
+	schema, err := metadata.Schema()
+	if err != nil {
+		return nil, err
+	}
+
 	ps, err := metadata.PartitionSchema()
 	if err != nil {
 		return nil, err
 	}
 
-	return newFilteredScan(s.memoryOptimizedLogReplay, s.config, predicate, ps)
+	physicalNames, err := columnMappingPhysicalNames(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFilteredScan(s.memoryOptimizedLogReplay, s.config, predicate, schema, ps, physicalNames)
 }
 
 // AllFiles returns all of the files present in this snapshot
 func (s *snapshotImp) AllFiles() ([]*action.AddFile, error) {
-	return s.activeFiles.Get()
+	return s.AllFilesContext(context.Background())
+}
+
+// AllFilesContext is AllFiles with a context to cancel the loading of the files.
+func (s *snapshotImp) AllFilesContext(ctx context.Context) ([]*action.AddFile, error) {
+	files, err := s.activeFiles.Get(ctx)
+	return files, errno.ContextError(ctx, err)
 }
 
 // Metadata returns the table metadata for this snapshot
 func (s *snapshotImp) Metadata() (*action.Metadata, error) {
-	t, err := s.protocolAndMetadata.Get()
-	return t.V2, err
+	return s.protocolAndMetadata.V2, nil
 }
 
 // Protocol returns the table protocol for this snapshot
 func (s *snapshotImp) Protocol() (*action.Protocol, error) {
-	t, err := s.protocolAndMetadata.Get()
-	return t.V1, err
+	return s.protocolAndMetadata.V1, nil
 }
 
 func (s *snapshotImp) Version() int64 {
@@ -145,9 +252,14 @@ func (s *snapshotImp) Version() int64 {
 }
 
 func (s *snapshotImp) EarliestVersion() (int64, error) {
-	lastCheckpoint, err := LastCheckpoint(s.store)
+	return s.EarliestVersionContext(context.Background())
+}
+
+// EarliestVersionContext is EarliestVersion with a context to cancel the reading of the last checkpoint.
+func (s *snapshotImp) EarliestVersionContext(ctx context.Context) (int64, error) {
+	lastCheckpoint, err := LastCheckpoint(ctx, s.store)
 	if err != nil {
-		return 0, err
+		return 0, errno.ContextError(ctx, err)
 	}
 	v, ok := lastCheckpoint.Get()
 	if !ok {
@@ -156,34 +268,37 @@ func (s *snapshotImp) EarliestVersion() (int64, error) {
 	return v.Version, nil
 }
 
-func (s *snapshotImp) tombstones() ([]*action.RemoveFile, error) {
-	state, err := s.state.Get()
+func (s *snapshotImp) tombstones(ctx context.Context) ([]*action.RemoveFile, error) {
+	state, err := s.state.Get(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return iter.ToSlice(state.tombstones)
+	return state.tombstones, nil
 }
 
-func (s *snapshotImp) setTransactions() []*action.SetTransaction {
-	state, err := s.state.Get()
+func (s *snapshotImp) setTransactions(ctx context.Context) ([]*action.SetTransaction, error) {
+	state, err := s.state.Get(ctx)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return state.setTransactions
+	return state.setTransactions, nil
 }
 
-func (s *snapshotImp) transactions() map[string]int64 {
+func (s *snapshotImp) transactions(ctx context.Context) (map[string]int64, error) {
 	// appID to version
-	trxs := s.setTransactions()
+	trxs, err := s.setTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
 	res := make(map[string]int64, len(trxs))
 	for _, trx := range trxs {
 		res[trx.AppId] = int64(trx.Version)
 	}
-	return res
+	return res, nil
 }
 
-func (s *snapshotImp) numOfFiles() (int64, error) {
-	state, err := s.state.Get()
+func (s *snapshotImp) numOfFiles(ctx context.Context) (int64, error) {
+	state, err := s.state.Get(ctx)
 	if err != nil {
 		return -1, err
 	}
@@ -202,10 +317,10 @@ func (s *snapshotImp) files() []string {
 	return res
 }
 
-func (s *snapshotImp) loadTableProtoclAndMetadata() (*tuple.T2[*action.Protocol, *action.Metadata], error) {
+func (s *snapshotImp) loadTableProtoclAndMetadata(ctx context.Context) (*tuple.T2[*action.Protocol, *action.Metadata], error) {
 	var protocol *action.Protocol = nil
 	var metadata *action.Metadata = nil
-	iter := s.memoryOptimizedLogReplay.GetReverseIterator()
+	iter := s.memoryOptimizedLogReplay.GetReverseIterator(ctx)
 	defer iter.Close()
 
 	var err error
@@ -244,50 +359,69 @@ func (s *snapshotImp) loadTableProtoclAndMetadata() (*tuple.T2[*action.Protocol,
 	return nil, eris.Wrap(errno.ErrIllegalState, "should not happen")
 }
 
-func (s *snapshotImp) loadInMemory(files []string) ([]*action.SingleAction, error) {
+func (s *snapshotImp) loadInMemory(ctx context.Context, files []string) ([]*action.SingleAction, error) {
 	sort.Slice(files, func(i, j int) bool {
 		return files[i] < files[j]
 	})
 
+	// the files are read in parallel, but their actions are returned in the order of the files
+	it := readFilesInOrder(ctx, files, s.config.ReplayConcurrency, s.readLogFile)
+	defer it.Close()
+
 	var actions []*action.SingleAction
-	for _, f := range files {
-		if strings.HasSuffix(f, "json") {
-			iter, err := s.store.Read(f)
-			if err != nil {
-				return nil, err
-			}
+	var fileActions []*action.SingleAction
+	var err error
+	for fileActions, err = it.Next(); err == nil; fileActions, err = it.Next() {
+		actions = append(actions, fileActions...)
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	return actions, nil
+}
 
-			for s, err := iter.Next(); err == nil; s, err = iter.Next() {
-				action := &action.SingleAction{}
-				if err := json.Unmarshal([]byte(s), &action); err != nil {
-					return nil, eris.Wrap(err, "")
-				}
-				actions = append(actions, action)
-			}
-			if err != nil && err != io.EOF {
-				return nil, err
-			}
-			iter.Close()
-		} else if strings.HasSuffix(f, "parquet") {
-			iter, err := s.checkpointReader.Read(f)
-			if err != nil {
-				return nil, err
-			}
-			for s, err := iter.Next(); err == nil; s, err = iter.Next() {
-				actions = append(actions, s.Wrap())
-			}
-			if err != nil && err != io.EOF {
-				return nil, err
+// readLogFile returns the actions of a delta file or a checkpoint file.
+func (s *snapshotImp) readLogFile(ctx context.Context, f string) ([]*action.SingleAction, error) {
+	var actions []*action.SingleAction
+	if filenames.IsCheckpointFile(f) {
+		iter, err := readCheckpointFile(ctx, s.store, s.checkpointReader, f)
+		if err != nil {
+			return nil, err
+		}
+		defer iter.Close()
+
+		var a action.Action
+		for a, err = iter.Next(); err == nil; a, err = iter.Next() {
+			actions = append(actions, a.Wrap())
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	} else if strings.HasSuffix(f, "json") {
+		iter, err := s.store.Read(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		defer iter.Close()
+
+		var line string
+		for line, err = iter.Next(); err == nil; line, err = iter.Next() {
+			action := &action.SingleAction{}
+			if err := json.Unmarshal([]byte(line), &action); err != nil {
+				return nil, eris.Wrap(err, "")
 			}
-			iter.Close()
+			actions = append(actions, action)
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
 		}
 	}
 	return actions, nil
 }
 
-func (s *snapshotImp) loadState() (*snapshotState, error) {
+func (s *snapshotImp) loadState(ctx context.Context) (*snapshotState, error) {
 	replay := NewInMemoryLogReplayer(s.minFileRetentionTimestamp, s.config.StoreType)
-	singleActions, err := s.loadInMemory(s.files())
+	singleActions, err := s.loadInMemory(ctx, s.files())
 	if err != nil {
 		return nil, err
 	}
@@ -297,21 +431,35 @@ func (s *snapshotImp) loadState() (*snapshotState, error) {
 		actions[i] = sa.Unwrap()
 	}
 
-	if err := replay.Append(0, iter.FromSlice(actions)); err != nil {
+	if err := replay.Append(s.version, iter.FromSlice(actions)); err != nil {
 		return nil, err
 	}
 
+	return newSnapshotState(replay, s.version)
+}
+
+func newSnapshotState(replay *InMemoryLogReplay, version int64) (*snapshotState, error) {
 	if replay.currentProtocolVersion == nil {
-		return nil, errno.ActionNotFound("protocl", s.version)
+		return nil, errno.ActionNotFound("protocl", version)
 	}
 	if replay.currentMetaData == nil {
-		return nil, errno.ActionNotFound("metadata", s.version)
+		return nil, errno.ActionNotFound("metadata", version)
+	}
+
+	activeFiles, err := iter.ToSlice(replay.GetActiveFiles())
+	if err != nil {
+		return nil, err
+	}
+	tombstones, err := iter.ToSlice(replay.GetTombstones())
+	if err != nil {
+		return nil, err
 	}
 
 	return &snapshotState{
+		replay:               replay,
 		setTransactions:      replay.GetSetTransactions(),
-		activeFiles:          replay.GetActiveFiles(),
-		tombstones:           replay.GetTombstones(),
+		activeFiles:          activeFiles,
+		tombstones:           tombstones,
 		sizeInBytes:          replay.sizeInBytes,
 		numOfFiles:           int64(len(replay.activeFiles)),
 		numOfRemoves:         int64(len(replay.tombstones)),
@@ -319,12 +467,22 @@ func (s *snapshotImp) loadState() (*snapshotState, error) {
 	}, nil
 }
 
-func (s *snapshotImp) loadActiveFiles() ([]*action.AddFile, error) {
-	v, err := s.state.Get()
+// takeReplay hands the replay over to the caller, which may append to it. The state is not changed,
+// as everything else is already materialized from the replay. It returns nil if the replay was already taken.
+func (s *snapshotState) takeReplay() *InMemoryLogReplay {
+	s.replayLock.Lock()
+	defer s.replayLock.Unlock()
+	replay := s.replay
+	s.replay = nil
+	return replay
+}
+
+func (s *snapshotImp) loadActiveFiles(ctx context.Context) ([]*action.AddFile, error) {
+	v, err := s.state.Get(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return iter.ToSlice(v.activeFiles)
+	return v.activeFiles, nil
 }
 
 func newInitialSnapshotImp(config Config, path string, store store.Store, cpReader checkpointReader) (*snapshotImp, error) {
@@ -348,13 +506,11 @@ func newInitialSnapshotImp(config Config, path string, store store.Store, cpRead
 		logStore:         store,
 		checkpointReader: cpReader,
 	}
-	s.state = util.LazyValue(func() (*snapshotState, error) {
+	s.state = util.LazyValue(func(ctx context.Context) (*snapshotState, error) {
 		return &snapshotState{}, nil
 	})
-	s.protocolAndMetadata = util.LazyValue(func() (*tuple.T2[*action.Protocol, *action.Metadata], error) {
-		t := tuple.New2(action.DefaultProtocol(), action.DefaultMetadata())
-		return &t, nil
-	})
+	t := tuple.New2(action.DefaultProtocol(), action.DefaultMetadata())
+	s.protocolAndMetadata = &t
 
 	return s, nil
 }
@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"io"
 	"log"
 	"strings"
@@ -31,7 +32,7 @@ type SnapshotReader struct {
 	currentSnapshot atomic.Pointer[snapshotImp]
 }
 
-func newSnapshotReader(config Config, cpReader checkpointReader, logStore store.Store, clock Clock, history *historyManager, mu *sync.Mutex) (*SnapshotReader, error) {
+func newSnapshotReader(ctx context.Context, config Config, cpReader checkpointReader, logStore store.Store, clock Clock, history *historyManager, mu *sync.Mutex) (*SnapshotReader, error) {
 	s := &SnapshotReader{
 		logStore:         logStore,
 		config:           config,
@@ -41,7 +42,7 @@ func newSnapshotReader(config Config, cpReader checkpointReader, logStore store.
 		mu:               mu,
 	}
 
-	initSnapshot, err := s.getSnapshotAtInit()
+	initSnapshot, err := s.getSnapshotAtInit(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -74,13 +75,13 @@ func (sr *SnapshotReader) snapshot() *snapshotImp {
 	return sr.currentSnapshot.Load()
 }
 
-func (sr *SnapshotReader) getSnapshotAtInit() (*snapshotImp, error) {
-	lastCheckpoint, err := LastCheckpoint(sr.logStore)
+func (sr *SnapshotReader) getSnapshotAtInit(ctx context.Context) (*snapshotImp, error) {
+	lastCheckpoint, err := LastCheckpoint(ctx, sr.logStore)
 	if err != nil {
 		return nil, eris.Wrap(err, "last checkpoint")
 	}
 
-	logSegment, err := sr.getLogSegmentForVersion(
+	logSegment, err := sr.getLogSegmentForVersion(ctx,
 		util.MapOptional(lastCheckpoint, func(v *CheckpointMetaDataJSON) int64 { return v.Version }),
 		mo.None[int64](),
 	)
@@ -91,48 +92,48 @@ func (sr *SnapshotReader) getSnapshotAtInit() (*snapshotImp, error) {
 		return nil, err
 	}
 
-	return sr.createSnapshot(logSegment, logSegment.LastCommitTimestamp.UnixMilli())
+	return sr.createSnapshot(ctx, logSegment, logSegment.LastCommitTimestamp.UnixMilli())
 }
 
-func (sr *SnapshotReader) getSnapshotAt(version int64) (*snapshotImp, error) {
+func (sr *SnapshotReader) getSnapshotAt(ctx context.Context, version int64) (*snapshotImp, error) {
 	if sr.snapshot().Version() == version {
 		return sr.snapshot(), nil
 	}
 
-	startingCheckpoint, err := FindLastCompleteCheckpoint(sr.logStore, CheckpointInstance{Version: version, NumParts: mo.None[int]()})
+	startingCheckpoint, err := FindLastCompleteCheckpoint(ctx, sr.logStore, CheckpointInstance{Version: version, NumParts: mo.None[int]()})
 	if err != nil {
 		return nil, err
 	}
 
 	start := util.MapOptional(startingCheckpoint, func(v *CheckpointInstance) int64 { return v.Version })
-	segment, err := sr.getLogSegmentForVersion(start, mo.Some(version))
+	segment, err := sr.getLogSegmentForVersion(ctx, start, mo.Some(version))
 	if err != nil {
 		return nil, err
 	}
 
-	return sr.createSnapshot(segment, segment.LastCommitTimestamp.UnixMilli())
+	return sr.createSnapshot(ctx, segment, segment.LastCommitTimestamp.UnixMilli())
 }
 
-func (sr *SnapshotReader) getSnapshotForVersionAsOf(version int64) (*snapshotImp, error) {
+func (sr *SnapshotReader) getSnapshotForVersionAsOf(ctx context.Context, version int64) (*snapshotImp, error) {
 
-	if err := sr.history.checkVersionExists(version, sr); err != nil {
+	if err := sr.history.checkVersionExists(ctx, version, sr); err != nil {
 		return nil, err
 	}
-	return sr.getSnapshotAt(version)
+	return sr.getSnapshotAt(ctx, version)
 }
 
-func (sr *SnapshotReader) getSnapshotForTimestampAsOf(timestamp int64) (*snapshotImp, error) {
+func (sr *SnapshotReader) getSnapshotForTimestampAsOf(ctx context.Context, timestamp int64) (*snapshotImp, error) {
 
-	latestCommit, err := sr.history.getActiveCommitAtTime(sr, timestamp, false, true, false)
+	latestCommit, err := sr.history.getActiveCommitAtTime(ctx, sr, timestamp, false, true, false)
 	if err != nil {
 		return nil, err
 	}
-	return sr.getSnapshotAt(latestCommit.version)
+	return sr.getSnapshotAt(ctx, latestCommit.version)
 }
 
-func (sr *SnapshotReader) getLogSegmentForVersion(startCheckpoint mo.Option[int64], versionToLoad mo.Option[int64]) (*LogSegment, error) {
+func (sr *SnapshotReader) getLogSegmentForVersion(ctx context.Context, startCheckpoint mo.Option[int64], versionToLoad mo.Option[int64]) (*LogSegment, error) {
 
-	iter, err := sr.logStore.ListFrom(filenames.CheckpointPrefix(sr.logStore.Root(), startCheckpoint.OrElse(0)))
+	iter, err := sr.logStore.ListFrom(ctx, filenames.CheckpointPrefix(sr.logStore.Root(), startCheckpoint.OrElse(0)))
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +142,8 @@ func (sr *SnapshotReader) getLogSegmentForVersion(startCheckpoint mo.Option[int6
 	var newFiles []*store.FileMeta
 	// List from the starting  If a checkpoint doesn't exist, this will still return
 	// deltaVersion=0.
-	for f, err := iter.Next(); err == nil; f, err = iter.Next() {
+	var f *store.FileMeta
+	for f, err = iter.Next(); err == nil; f, err = iter.Next() {
 		if !(filenames.IsCheckpointFile(f.Path()) || filenames.IsDeltaFile(f.Path())) {
 			continue
 		}
@@ -167,7 +169,7 @@ func (sr *SnapshotReader) getLogSegmentForVersion(startCheckpoint mo.Option[int6
 	} else if len(newFiles) == 0 {
 		// The directory may be deleted and recreated and we may have stale state in our DeltaLog
 		// singleton, so try listing from the first version
-		return sr.getLogSegmentForVersion(mo.None[int64](), versionToLoad)
+		return sr.getLogSegmentForVersion(ctx, mo.None[int64](), versionToLoad)
 	}
 
 	checkpoints, deltas := splitDeltaAndCheckpoint(newFiles)
@@ -206,7 +208,7 @@ func (sr *SnapshotReader) getLogSegmentForVersion(startCheckpoint mo.Option[int6
 			if deltaVersions[0] != newCheckpointVersion+1 {
 				return nil, errno.NoFirstDeltaFile()
 			}
-			if versionToLoad.IsPresent() && versionToLoad.MustGet() == deltaVersions[len(deltaVersions)-1] {
+			if versionToLoad.IsPresent() && versionToLoad.MustGet() != deltaVersions[len(deltaVersions)-1] {
 				return nil, errno.NoLastDeltaFile()
 			}
 		}
@@ -267,27 +269,45 @@ func (sr *SnapshotReader) getLogSegmentForVersion(startCheckpoint mo.Option[int6
 	}
 }
 
-func (sr *SnapshotReader) createSnapshot(segment *LogSegment, lastCommitTs int64) (*snapshotImp, error) {
+func (sr *SnapshotReader) createSnapshot(ctx context.Context, segment *LogSegment, lastCommitTs int64) (*snapshotImp, error) {
 	minFileRetention, err := sr.getMinFileRetentionTimestamp()
 	if err != nil {
 		return nil, err
 	}
 
-	return newSnapshotImp(sr.config, sr.logStore.Root(), segment.Version, segment, minFileRetention, lastCommitTs, sr.logStore, sr.checkpointReader)
+	return newSnapshotImp(ctx, sr.config, sr.logStore.Root(), segment.Version, segment, minFileRetention, lastCommitTs, sr.logStore, sr.checkpointReader)
 }
 
-func (sr *SnapshotReader) update() (*snapshotImp, error) {
+// updateSnapshot creates the snapshot of the new log segment, which is derived from the current snapshot
+// if no new checkpoint is written after it.
+func (sr *SnapshotReader) updateSnapshot(ctx context.Context, current *snapshotImp, segment *LogSegment) (*snapshotImp, error) {
+	minFileRetention, err := sr.getMinFileRetentionTimestamp()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok, err := newIncrementalSnapshotImp(ctx, current, segment, minFileRetention, segment.LastCommitTimestamp.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return s, nil
+	}
+	return sr.createSnapshot(ctx, segment, segment.LastCommitTimestamp.UnixMilli())
+}
+
+func (sr *SnapshotReader) update(ctx context.Context) (*snapshotImp, error) {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
 
-	return sr.updateInternal()
+	return sr.updateInternal(ctx)
 }
 
 // updateInternal is not goroutine-safe, the caller should take care of locking.
-func (sr *SnapshotReader) updateInternal() (*snapshotImp, error) {
+func (sr *SnapshotReader) updateInternal(ctx context.Context) (*snapshotImp, error) {
 	currentSnapshot := sr.currentSnapshot.Load()
 	v := currentSnapshot.logSegment.CheckpointVersion
-	segment, err := sr.getLogSegmentForVersion(v, mo.None[int64]())
+	segment, err := sr.getLogSegmentForVersion(ctx, v, mo.None[int64]())
 
 	if err != nil && eris.Is(err, errno.ErrFileNotFound) {
 		if strings.Contains(err.Error(), "reconstruct state at version") {
@@ -302,9 +322,12 @@ func (sr *SnapshotReader) updateInternal() (*snapshotImp, error) {
 		sr.currentSnapshot.Store(newSnapshot)
 		return newSnapshot, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	if !currentSnapshot.logSegment.equal(segment) {
-		newSnapshot, err := sr.createSnapshot(segment, segment.LastCommitTimestamp.UnixMilli())
+		newSnapshot, err := sr.updateSnapshot(ctx, currentSnapshot, segment)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,234 @@
+package deltago
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+type countingCheckpointReader struct {
+	checkpointReader
+	reads int
+}
+
+func (c *countingCheckpointReader) Read(ctx context.Context, path string) (iter.Iter[action.Action], error) {
+	c.reads++
+	return c.checkpointReader.Read(ctx, path)
+}
+
+func TestSnapshot_incremental_update(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	add := func(path string, size int64) *action.AddFile {
+		f := testAddFile(path)
+		f.Size = size
+		return f
+	}
+	now := time.Now().UnixMilli()
+
+	commitTestActions(t, log, getTestMetedata(), add("a", 1), add("b", 2), add("c", 3))
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+
+	reloaded, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	s, err = reloaded.Snapshot()
+	assert.NoError(t, err)
+	cr := &countingCheckpointReader{checkpointReader: s.(*snapshotImp).checkpointReader}
+	s.(*snapshotImp).checkpointReader = cr
+	_, err = s.AllFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cr.reads)
+
+	commitTestActions(t, log, add("d", 4), &action.RemoveFile{Path: "a", DeletionTimestamp: &now, DataChange: true})
+	commitTestActions(t, log, &action.SetTransaction{AppId: "app", Version: 1}, add("b", 5))
+
+	updated, err := reloaded.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version())
+
+	// only the new commits are replayed on the state of the previous snapshot
+	files, err := updated.AllFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cr.reads)
+
+	full, err := ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+	assert.NoError(t, err)
+	expected, err := full.Snapshot()
+	assert.NoError(t, err)
+	expectedFiles, err := expected.AllFiles()
+	assert.NoError(t, err)
+
+	byPath := func(files []*action.AddFile) {
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	}
+	byPath(files)
+	byPath(expectedFiles)
+	assert.Equal(t, expectedFiles, files)
+
+	ctx := context.Background()
+	for _, s := range []*snapshotImp{updated.(*snapshotImp), expected.(*snapshotImp)} {
+		tombstones, err := s.tombstones(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, tombstones, 1)
+		assert.Equal(t, "a", tombstones[0].Path)
+
+		txns, err := s.transactions(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"app": 1}, txns)
+
+		state, err := s.state.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), state.numOfFiles)
+		assert.Equal(t, int64(12), state.sizeInBytes)
+	}
+
+	// the replay is handed over to the updated snapshot, the previous snapshot does not keep it
+	prevState, ok := s.(*snapshotImp).state.Peek()
+	assert.True(t, ok)
+	assert.Nil(t, prevState.replay)
+
+	// the previous snapshot is not changed by the update
+	files, err = s.AllFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	tombstones, err := s.(*snapshotImp).tombstones(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, tombstones)
+}
+
+func TestSnapshot_full_replay_after_new_checkpoint(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	trx, err := log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{getTestMetedata(),
+		&action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true}}),
+		getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	s, err := log.Update()
+	assert.NoError(t, err)
+	_, err = s.AllFiles()
+	assert.NoError(t, err)
+
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+	trx, err = log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice([]action.Action{
+		&action.AddFile{Path: "b", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true}}),
+		getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+
+	updated, err := log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), updated.(*snapshotImp).logSegment.CheckpointVersion.OrEmpty())
+	_, ok := s.(*snapshotImp).logSegment.newDeltas(updated.(*snapshotImp).logSegment)
+	assert.False(t, ok)
+
+	files, err := updated.AllFiles()
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestSnapshot_version_as_of_after_checkpoint(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	commitTestActions(t, log, getTestMetedata(), testAddFile("a"))
+	commitTestActions(t, log, testAddFile("b"))
+	s, err := log.Update()
+	assert.NoError(t, err)
+	l := log.(*logImpl)
+	assert.NoError(t, checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock))
+	commitTestActions(t, log, testAddFile("c"))
+	commitTestActions(t, log, testAddFile("d"))
+
+	// the versions after the checkpoint are loaded from the checkpoint and the delta files up to the version
+	for version, numFiles := range map[int64]int{1: 2, 2: 3, 3: 4} {
+		s, err := log.SnapshotForVersionAsOf(version)
+		assert.NoError(t, err)
+		assert.Equal(t, version, s.Version())
+		files, err := s.AllFiles()
+		assert.NoError(t, err)
+		assert.Len(t, files, numFiles)
+	}
+}
+
+// BenchmarkSnapshot_update compares deriving the state of the updated snapshot from the previous state
+// with loading the state of the table from the checkpoint, after one more commit.
+func BenchmarkSnapshot_update(b *testing.B) {
+	for _, name := range []string{"incremental", "full"} {
+		b.Run(name, func(b *testing.B) {
+			tt := newTestLogCases("file")[0]
+			defer tt.clean()
+
+			log, err := tt.getTempLog()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			actions := []action.Action{getTestMetedata()}
+			for i := 0; i < 10000; i++ {
+				actions = append(actions, testAddFile(fmt.Sprintf("file-%d", i)))
+			}
+			commitTestActions(b, log, actions...)
+			s, err := log.Update()
+			if err != nil {
+				b.Fatal(err)
+			}
+			l := log.(*logImpl)
+			if err := checkpoint(context.Background(), l.logPath, l.store, s.(*snapshotImp), l.clock); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := s.AllFiles(); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				commitTestActions(b, log, testAddFile(fmt.Sprintf("new-%d", i)))
+				b.StartTimer()
+
+				if name == "incremental" {
+					s, err = log.Update()
+				} else {
+					var reloaded Log
+					reloaded, err = ForTable(strings.TrimSuffix(l.dataPath, "/"), tt.config, &SystemClock{})
+					if err == nil {
+						s, err = reloaded.Snapshot()
+					}
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := s.AllFiles(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
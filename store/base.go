@@ -3,8 +3,10 @@ package store
 import (
 	"context"
 
+	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/iter"
 	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
 )
 
 type baseStore struct {
@@ -14,17 +16,21 @@ type baseStore struct {
 	writeErrorFn  func(err error, path string) error
 }
 
-func (b *baseStore) Read(path string) (iter.Iter[string], error) {
+func (b *baseStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
 	// path must be relative to the root log path, do NOT start with '/'
-	r, err := b.bucket.NewReader(context.Background(), path, nil)
-	if err != nil {
+	// some drivers, e.g. fileblob, do not check the context when opening a reader
+	if err := errno.CheckContext(ctx); err != nil {
 		return nil, err
 	}
+	r, err := b.bucket.NewReader(ctx, path, nil)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
 
 	return iter.FromReadCloser(r), nil
 }
 
-func (b *baseStore) Write(path string, actions iter.Iter[string], overwrite bool) error {
+func (b *baseStore) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
 	// path must be relative to the root log path, do NOT start with '/'
 	var writeOpt *blob.WriterOptions
 
@@ -34,28 +40,29 @@ func (b *baseStore) Write(path string, actions iter.Iter[string], overwrite bool
 		}
 	}
 
-	w, err := b.bucket.NewWriter(context.Background(), path, writeOpt)
+	w, err := b.bucket.NewWriter(ctx, path, writeOpt)
 	if err != nil {
-		return err
+		return errno.ContextError(ctx, err)
 	}
 
 	if _, err = w.ReadFrom(iter.AsReadCloser(actions, true)); err != nil {
-		return err
+		return errno.ContextError(ctx, b.writeErrorFn(err, path))
 	}
 
 	if err := w.Close(); err != nil {
-		return b.writeErrorFn(err, path)
+		return errno.ContextError(ctx, b.writeErrorFn(err, path))
 	}
 
 	return actions.Close()
 }
 
-func (b *baseStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
+func (b *baseStore) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
 
-	return newListingIter(b.logDir, path, b.bucket)
+	return newListingIter(ctx, b.logDir, path, b.bucket)
 }
 
 type listingIter struct {
+	ctx       context.Context
 	startPath string
 	logDir    string
 	bucket    *blob.Bucket
@@ -63,9 +70,10 @@ type listingIter struct {
 	buffer    []*blob.ListObject
 }
 
-func newListingIter(logDir string, startPath string, bucket *blob.Bucket) (*listingIter, error) {
+func newListingIter(ctx context.Context, logDir string, startPath string, bucket *blob.Bucket) (*listingIter, error) {
 
 	return &listingIter{
+		ctx:       ctx,
 		startPath: startPath,
 		logDir:    logDir,
 		bucket:    bucket,
@@ -78,9 +86,9 @@ var _ iter.Iter[*FileMeta] = &listingIter{}
 func (l *listingIter) Next() (*FileMeta, error) {
 
 	if len(l.buffer) == 0 {
-		ret, nextPageToken, err := l.bucket.ListPage(context.Background(), l.pageToken, 500, nil)
+		ret, nextPageToken, err := l.listPage()
 		if err != nil {
-			return nil, err
+			return nil, errno.ContextError(l.ctx, err)
 		}
 
 		l.pageToken = nextPageToken
@@ -103,14 +111,44 @@ func (l *listingIter) Next() (*FileMeta, error) {
 
 }
 
+// maxListPageAttempts is the number of times a page is listed when a file is removed while it is listed.
+const maxListPageAttempts = 5
+
+// listPage lists the next page. The file system bucket fails the page with NotFound
+// if a file is removed between reading the directory and stating the file,
+// e.g. the temp file of a concurrent commit of the local store, so the page is listed again.
+func (l *listingIter) listPage() ([]*blob.ListObject, []byte, error) {
+	var err error
+	for i := 0; i < maxListPageAttempts; i++ {
+		var ret []*blob.ListObject
+		var nextPageToken []byte
+		ret, nextPageToken, err = l.bucket.ListPage(l.ctx, l.pageToken, 500, nil)
+		if gcerrors.Code(err) != gcerrors.NotFound {
+			return ret, nextPageToken, err
+		}
+	}
+	return nil, nil, err
+}
+
 func (l *listingIter) Close() error {
 	return nil
 }
 
-func (b *baseStore) Exists(path string) (bool, error) {
-	return b.bucket.Exists(context.Background(), path)
+func (b *baseStore) Exists(ctx context.Context, path string) (bool, error) {
+	ok, err := b.bucket.Exists(ctx, path)
+	return ok, errno.ContextError(ctx, err)
+}
+
+func (b *baseStore) Create(ctx context.Context, path string) error {
+	return errno.ContextError(ctx, b.bucket.WriteAll(ctx, path, []byte{}, nil))
 }
 
-func (b *baseStore) Create(path string) error {
-	return b.bucket.WriteAll(context.Background(), path, []byte{}, nil)
+func (b *baseStore) Delete(ctx context.Context, path string) error {
+	if err := b.bucket.Delete(ctx, path); err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return errno.FileNotFound(path)
+		}
+		return errno.ContextError(ctx, err)
+	}
+	return nil
 }
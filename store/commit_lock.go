@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/errno"
+)
+
+// CommitEntry is an entry of a CommitLockStore, which records a commit of a Delta table.
+// An incomplete entry means the commit was claimed and its content written to TempPath,
+// but it is not known yet whether the delta file was written.
+type CommitEntry struct {
+	// TablePath is the log directory of the table.
+	TablePath string `json:"tablePath"`
+	// FileName is the name of the delta file, e.g. 00000000000000000001.json.
+	FileName string `json:"fileName"`
+	// TempPath is the path of the temp file relative to the log directory.
+	TempPath string `json:"tempPath"`
+	Complete bool   `json:"complete"`
+	// ExpireTime is the unix time in seconds after which a complete entry can be deleted, 0 if it must be kept.
+	ExpireTime int64 `json:"expireTime,omitempty"`
+}
+
+// CommitLockStore is the external store of the multi-driver log store, e.g. a DynamoDB table.
+// It provides the mutual exclusion for the commits of the same version which S3 does not provide.
+// See https://delta.io/blog/2022-05-18-multi-cluster-writes-to-delta-lake-storage-in-s3/
+type CommitLockStore interface {
+	// PutEntry puts the entry. If overwrite is false and an entry of the same table and file name exists,
+	// it must return FileAlreadyExists error. The check and the put must be atomic.
+	PutEntry(ctx context.Context, entry *CommitEntry, overwrite bool) error
+
+	// GetEntry returns the entry of the table and file name, if any.
+	GetEntry(ctx context.Context, tablePath string, fileName string) (mo.Option[*CommitEntry], error)
+
+	// GetLatestEntry returns the entry of the table with the greatest file name, if any.
+	GetLatestEntry(ctx context.Context, tablePath string) (mo.Option[*CommitEntry], error)
+}
+
+// NewMemoryCommitLockStore creates a CommitLockStore which keeps the entries in memory,
+// it only coordinates the writers in the same process.
+func NewMemoryCommitLockStore() *MemoryCommitLockStore {
+	return &MemoryCommitLockStore{entries: map[string]map[string]CommitEntry{}}
+}
+
+type MemoryCommitLockStore struct {
+	mu sync.Mutex
+	// table path -> file name -> entry
+	entries map[string]map[string]CommitEntry
+}
+
+func (m *MemoryCommitLockStore) PutEntry(ctx context.Context, entry *CommitEntry, overwrite bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table, ok := m.entries[entry.TablePath]
+	if !ok {
+		table = map[string]CommitEntry{}
+		m.entries[entry.TablePath] = table
+	}
+	if _, ok := table[entry.FileName]; ok && !overwrite {
+		return errno.FileAlreadyExists(entry.TablePath + entry.FileName)
+	}
+	table[entry.FileName] = *entry
+	return nil
+}
+
+func (m *MemoryCommitLockStore) GetEntry(ctx context.Context, tablePath string, fileName string) (mo.Option[*CommitEntry], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[tablePath][fileName]; ok {
+		return mo.Some(&e), nil
+	}
+	return mo.None[*CommitEntry](), nil
+}
+
+func (m *MemoryCommitLockStore) GetLatestEntry(ctx context.Context, tablePath string) (mo.Option[*CommitEntry], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var latest *CommitEntry
+	for _, e := range m.entries[tablePath] {
+		if latest == nil || e.FileName > latest.FileName {
+			e := e
+			latest = &e
+		}
+	}
+	if latest == nil {
+		return mo.None[*CommitEntry](), nil
+	}
+	return mo.Some(latest), nil
+}
+
+// NewFileCommitLockStore creates a CommitLockStore which keeps each entry as a json file in dir,
+// the entries of a table are in a sub directory named by the escaped table path.
+// The entries are created by link(2) which fails if the entry exists, so it is safe for the writers sharing the same file system.
+func NewFileCommitLockStore(dir string) (*FileCommitLockStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, eris.Wrap(err, "creating the commit lock directory "+dir)
+	}
+	return &FileCommitLockStore{dir: dir}, nil
+}
+
+type FileCommitLockStore struct {
+	dir string
+}
+
+func (f *FileCommitLockStore) tableDir(tablePath string) string {
+	return filepath.Join(f.dir, url.PathEscape(tablePath))
+}
+
+func (f *FileCommitLockStore) PutEntry(ctx context.Context, entry *CommitEntry, overwrite bool) error {
+	if err := errno.CheckContext(ctx); err != nil {
+		return err
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return errno.JsonMarshalError(err)
+	}
+	dir := f.tableDir(entry.TablePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return eris.Wrap(err, "creating the commit lock directory "+dir)
+	}
+	p := filepath.Join(dir, entry.FileName)
+
+	// the entry is written to a temp file first so the readers never see a partial entry
+	tmp, err := os.CreateTemp(dir, ".tmp-"+entry.FileName)
+	if err != nil {
+		return eris.Wrap(err, "writing the commit entry "+p)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return eris.Wrap(err, "writing the commit entry "+p)
+	}
+	if err := tmp.Close(); err != nil {
+		return eris.Wrap(err, "writing the commit entry "+p)
+	}
+
+	if overwrite {
+		if err := os.Rename(tmp.Name(), p); err != nil {
+			return eris.Wrap(err, "writing the commit entry "+p)
+		}
+		return nil
+	}
+	// link fails if the entry exists
+	if err := os.Link(tmp.Name(), p); err != nil {
+		if os.IsExist(err) {
+			return errno.FileAlreadyExists(entry.TablePath + entry.FileName)
+		}
+		return eris.Wrap(err, "writing the commit entry "+p)
+	}
+	return nil
+}
+
+func (f *FileCommitLockStore) GetEntry(ctx context.Context, tablePath string, fileName string) (mo.Option[*CommitEntry], error) {
+	p := filepath.Join(f.tableDir(tablePath), fileName)
+	b, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return mo.None[*CommitEntry](), nil
+	}
+	if err != nil {
+		return mo.None[*CommitEntry](), eris.Wrap(err, "reading the commit entry "+p)
+	}
+	entry := &CommitEntry{}
+	if err := json.Unmarshal(b, entry); err != nil {
+		return mo.None[*CommitEntry](), errno.JsonUnmarshalError(err)
+	}
+	return mo.Some(entry), nil
+}
+
+func (f *FileCommitLockStore) GetLatestEntry(ctx context.Context, tablePath string) (mo.Option[*CommitEntry], error) {
+	dirEntries, err := os.ReadDir(f.tableDir(tablePath))
+	if os.IsNotExist(err) {
+		return mo.None[*CommitEntry](), nil
+	}
+	if err != nil {
+		return mo.None[*CommitEntry](), eris.Wrap(err, "listing the commit entries of "+tablePath)
+	}
+
+	var names []string
+	for _, e := range dirEntries {
+		if !e.IsDir() && e.Name()[0] != '.' {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return mo.None[*CommitEntry](), nil
+	}
+	sort.Strings(names)
+	return f.GetEntry(ctx, tablePath, names[len(names)-1])
+}
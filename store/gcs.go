@@ -68,23 +68,23 @@ func (a *GCSLogStore) Root() string {
 // Read the given file and return an `Iterator` of lines, with line breaks removed from
 // each line. Callers of this function are responsible to close the iterator if they are
 // done with it.
-func (a *GCSLogStore) Read(path string) (iter.Iter[string], error) {
+func (a *GCSLogStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
 	path, err := a.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.s.Read(path)
+	return a.s.Read(ctx, path)
 }
 
 // List the paths in the same directory that are lexicographically greater or equal to (UTF-8 sorting) the given `path`. The result should also be sorted by the file name.
-func (a *GCSLogStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
+func (a *GCSLogStore) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
 	path, err := a.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.s.ListFrom(path)
+	return a.s.ListFrom(ctx, path)
 }
 
 // Write the given `actions` to the given `path` with or without overwrite as indicated.
@@ -92,14 +92,14 @@ func (a *GCSLogStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
 // exists and overwrite = false. Furthermore, if isPartialWriteVisible returns false,
 // implementation must ensure that the entire file is made visible atomically, that is,
 // it should not generate partial files.
-func (a *GCSLogStore) Write(path string, actions iter.Iter[string], overwrite bool) error {
+func (a *GCSLogStore) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
 
 	path, err := a.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return err
 	}
 
-	return a.s.Write(path, actions, overwrite)
+	return a.s.Write(ctx, path, actions, overwrite)
 }
 
 // Resolve the fully qualified path for the given `path`.
@@ -112,10 +112,19 @@ func (a *GCSLogStore) IsPartialWriteVisible(path string) bool {
 	return false
 }
 
-func (a *GCSLogStore) Exists(path string) (bool, error) {
-	return a.s.Exists(path)
+func (a *GCSLogStore) Exists(ctx context.Context, path string) (bool, error) {
+	return a.s.Exists(ctx, path)
 }
 
-func (a *GCSLogStore) Create(path string) error {
-	return a.s.Create(path)
+func (a *GCSLogStore) Create(ctx context.Context, path string) error {
+	return a.s.Create(ctx, path)
+}
+
+func (a *GCSLogStore) Delete(ctx context.Context, path string) error {
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return err
+	}
+
+	return a.s.Delete(ctx, path)
 }
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// LegacyStore is the method set of Store before its methods took a context.Context and Delete was added.
+// An existing implementation of it can still be used as a Store through FromLegacyStore.
+type LegacyStore interface {
+	Root() string
+	Read(path string) (iter.Iter[string], error)
+	ListFrom(path string) (iter.Iter[*FileMeta], error)
+	Write(path string, actions iter.Iter[string], overwrite bool) error
+	ResolvePathOnPhysicalStore(path string) (string, error)
+	IsPartialWriteVisible(path string) bool
+	Exists(path string) (bool, error)
+	Create(path string) error
+}
+
+// legacyDeleter is implemented by a LegacyStore which can delete files.
+type legacyDeleter interface {
+	Delete(path string) error
+}
+
+// FromLegacyStore adapts a LegacyStore to Store. As a LegacyStore cannot be canceled,
+// the ctx is only checked before each call.
+// Delete is forwarded if the LegacyStore has a Delete(path string) error method,
+// otherwise it fails with ErrUnsupportedOperation, e.g. the expired log files are not cleaned up.
+func FromLegacyStore(s LegacyStore) Store {
+	return &legacyStoreAdapter{s: s}
+}
+
+type legacyStoreAdapter struct {
+	s LegacyStore
+}
+
+func (a *legacyStoreAdapter) Root() string {
+	return a.s.Root()
+}
+
+func (a *legacyStoreAdapter) Read(ctx context.Context, path string) (iter.Iter[string], error) {
+	if err := errno.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+	return a.s.Read(path)
+}
+
+func (a *legacyStoreAdapter) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
+	if err := errno.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+	return a.s.ListFrom(path)
+}
+
+func (a *legacyStoreAdapter) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
+	if err := errno.CheckContext(ctx); err != nil {
+		return err
+	}
+	return a.s.Write(path, actions, overwrite)
+}
+
+func (a *legacyStoreAdapter) ResolvePathOnPhysicalStore(path string) (string, error) {
+	return a.s.ResolvePathOnPhysicalStore(path)
+}
+
+func (a *legacyStoreAdapter) IsPartialWriteVisible(path string) bool {
+	return a.s.IsPartialWriteVisible(path)
+}
+
+func (a *legacyStoreAdapter) Exists(ctx context.Context, path string) (bool, error) {
+	if err := errno.CheckContext(ctx); err != nil {
+		return false, err
+	}
+	return a.s.Exists(path)
+}
+
+func (a *legacyStoreAdapter) Create(ctx context.Context, path string) error {
+	if err := errno.CheckContext(ctx); err != nil {
+		return err
+	}
+	return a.s.Create(path)
+}
+
+func (a *legacyStoreAdapter) Delete(ctx context.Context, path string) error {
+	if err := errno.CheckContext(ctx); err != nil {
+		return err
+	}
+	d, ok := a.s.(legacyDeleter)
+	if !ok {
+		return eris.Wrap(errno.ErrUnsupportedOperation, "the legacy store does not support deleting "+path)
+	}
+	return d.Delete(path)
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// memoryLegacyStore is a LegacyStore keeping the files in memory, which can also delete files.
+type memoryLegacyStore struct {
+	files map[string][]string
+}
+
+func (m *memoryLegacyStore) Root() string { return "" }
+
+func (m *memoryLegacyStore) Read(path string) (iter.Iter[string], error) {
+	lines, ok := m.files[path]
+	if !ok {
+		return nil, errno.FileNotFound(path)
+	}
+	return iter.FromSlice(lines), nil
+}
+
+func (m *memoryLegacyStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
+	return iter.FromSlice([]*FileMeta{{path: path}}), nil
+}
+
+func (m *memoryLegacyStore) Write(path string, actions iter.Iter[string], overwrite bool) error {
+	if _, ok := m.files[path]; ok && !overwrite {
+		return errno.FileAlreadyExists(path)
+	}
+	lines, err := iter.ToSlice(actions)
+	if err != nil {
+		return err
+	}
+	m.files[path] = lines
+	return nil
+}
+
+func (m *memoryLegacyStore) ResolvePathOnPhysicalStore(path string) (string, error) { return path, nil }
+
+func (m *memoryLegacyStore) IsPartialWriteVisible(path string) bool { return false }
+
+func (m *memoryLegacyStore) Exists(path string) (bool, error) {
+	_, ok := m.files[path]
+	return ok, nil
+}
+
+func (m *memoryLegacyStore) Create(path string) error {
+	m.files[path] = nil
+	return nil
+}
+
+func (m *memoryLegacyStore) Delete(path string) error {
+	delete(m.files, path)
+	return nil
+}
+
+func TestFromLegacyStore(t *testing.T) {
+	s := FromLegacyStore(&memoryLegacyStore{files: map[string][]string{}})
+
+	assert.NoError(t, s.Write(context.Background(), "0.json", iter.FromSlice([]string{"a"}), false))
+	err := s.Write(context.Background(), "0.json", iter.FromSlice([]string{"b"}), false)
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+
+	it, err := s.Read(context.Background(), "0.json")
+	assert.NoError(t, err)
+	lines, err := iter.ToSlice(it)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, lines)
+
+	ok, err := s.Exists(context.Background(), "0.json")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the ctx is checked before each call
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Exists(ctx, "0.json")
+	assert.ErrorIs(t, err, errno.ErrCanceled)
+	assert.ErrorIs(t, s.Delete(ctx, "0.json"), errno.ErrCanceled)
+
+	assert.NoError(t, s.Delete(context.Background(), "0.json"))
+	ok, err = s.Exists(context.Background(), "0.json")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// a legacy store without Delete cannot delete files
+	s = FromLegacyStore(struct{ LegacyStore }{&memoryLegacyStore{files: map[string][]string{}}})
+	assert.ErrorIs(t, s.Delete(context.Background(), "0.json"), errno.ErrUnsupportedOperation)
+}
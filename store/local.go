@@ -2,8 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/rotisserie/eris"
 	"gocloud.dev/blob"
@@ -58,25 +63,28 @@ func (l *LocalStore) ResolvePathOnPhysicalStore(path string) (string, error) {
 	return relativePath("file", l.logBasePath, path)
 }
 
-func (l *LocalStore) Read(path string) (iter.Iter[string], error) {
+func (l *LocalStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
 	path, err := l.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return l.s.Read(path)
+	return l.s.Read(ctx, path)
 }
 
-func (l *LocalStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
+func (l *LocalStore) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
 	path, err := l.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return l.s.ListFrom(path)
+	return l.s.ListFrom(ctx, path)
 }
 
-func (l *LocalStore) Write(path string, iter iter.Iter[string], overwrite bool) error {
+// Write the given `actions` to the given `path` with or without overwrite as indicated.
+// Without overwrite, the file is created exclusively so it is safe for writers from multiple processes sharing the file system,
+// it returns FileAlreadyExists error if the file exists.
+func (l *LocalStore) Write(ctx context.Context, path string, iter iter.Iter[string], overwrite bool) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -86,17 +94,109 @@ func (l *LocalStore) Write(path string, iter iter.Iter[string], overwrite bool)
 	}
 
 	if !overwrite {
+		return l.writeExclusive(ctx, path, iter)
+	}
+
+	return l.s.Write(ctx, path, iter, overwrite)
+}
+
+// writeExclusive writes the content to a temp file next to the target first, then links it to the target by link(2),
+// which fails if the target exists. So the target can only be created once and is never seen partially written.
+// Only if the file system does not support hard links, the target is created by O_EXCL and written in place,
+// and it is removed if the write fails.
+func (l *LocalStore) writeExclusive(ctx context.Context, path string, actions iter.Iter[string]) error {
+	target := filepath.Join(l.logBasePath, path)
+	dir, name := filepath.Split(target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return eris.Wrap(err, "creating the directory of "+path)
+	}
+
+	// the temp file starts with '.' so it is listed before any delta or checkpoint file
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return eris.Wrap(err, "creating the temp file of "+path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeLocalFile(tmp, actions); err != nil {
+		return eris.Wrap(err, "writing the temp file of "+path)
+	}
+	// the commit is not visible until linked, so it can still be canceled
+	if err := errno.CheckContext(ctx); err != nil {
+		return err
+	}
+
+	err = linkFile(tmp.Name(), target)
+	if err == nil {
+		return nil
+	}
+	if os.IsExist(err) {
+		return errno.FileAlreadyExists(path)
+	}
+	if !isLinkUnsupported(err) {
+		return eris.Wrap(err, "linking the temp file to "+path)
+	}
+
+	// link(2) is not supported, fall back to O_EXCL and copy the temp file
+	src, err := os.Open(tmp.Name())
+	if err != nil {
+		return eris.Wrap(err, "reading the temp file of "+path)
+	}
+	defer src.Close()
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return errno.FileAlreadyExists(path)
+	}
+	if err != nil {
+		return eris.Wrap(err, "creating "+path)
+	}
+	if err := copyLocalFile(f, src); err != nil {
+		// a partially written commit must not be left behind
+		os.Remove(target)
+		return eris.Wrap(err, "writing "+path)
+	}
+	return nil
+}
+
+// linkFile is os.Link, it is replaced in the tests to simulate the link(2) errors.
+var linkFile = os.Link
+
+// isLinkUnsupported returns whether the link(2) error means the file system does not support hard links.
+func isLinkUnsupported(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOTSUP) ||
+		errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.EXDEV)
+}
 
-		exist, err := l.s.bucket.Exists(context.Background(), path)
-		if err != nil {
-			return eris.Wrap(err, "something wrong when checking for existence of "+path)
-		}
-		if exist {
-			return errno.FileAlreadyExists(path)
-		}
+func copyLocalFile(f *os.File, src io.Reader) error {
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
 	}
+	return f.Close()
+}
 
-	return l.s.Write(path, iter, overwrite)
+func writeLocalFile(f *os.File, actions iter.Iter[string]) error {
+	if _, err := io.Copy(f, iter.AsReadCloser(actions, true)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	// the content must be durable before the file is linked
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return actions.Close()
 }
 
 func (l *LocalStore) IsPartialWriteVisible(path string) bool {
@@ -104,10 +204,19 @@ func (l *LocalStore) IsPartialWriteVisible(path string) bool {
 	return false
 }
 
-func (l *LocalStore) Exists(path string) (bool, error) {
-	return l.s.Exists(path)
+func (l *LocalStore) Exists(ctx context.Context, path string) (bool, error) {
+	return l.s.Exists(ctx, path)
+}
+
+func (l *LocalStore) Create(ctx context.Context, path string) error {
+	return l.s.Create(ctx, path)
 }
 
-func (l *LocalStore) Create(path string) error {
-	return l.s.Create(path)
+func (l *LocalStore) Delete(ctx context.Context, path string) error {
+	path, err := l.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return err
+	}
+
+	return l.s.Delete(ctx, path)
 }
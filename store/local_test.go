@@ -1,10 +1,16 @@
 package store
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
 	"testing"
 
+	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/stretchr/testify/assert"
 	_ "gocloud.dev/blob/fileblob"
@@ -21,7 +27,7 @@ func TestLocalStore(t *testing.T) {
 	assert.NoError(t, err)
 
 	// reading using absolute path
-	data, err := s.Read(absPath + "00000000000000000000.json")
+	data, err := s.Read(context.Background(), absPath+"00000000000000000000.json")
 	assert.NoError(t, err)
 
 	sl, err := iter.ToSlice(data)
@@ -29,7 +35,7 @@ func TestLocalStore(t *testing.T) {
 	assert.Equal(t, 4, len(sl))
 
 	// listing using the relative path
-	it, err := s.ListFrom("00000000000000000007.json")
+	it, err := s.ListFrom(context.Background(), "00000000000000000007.json")
 	assert.NoError(t, err)
 
 	files, err := iter.Map(it, func(f *FileMeta) (string, error) {
@@ -52,6 +58,145 @@ func TestLocalStore(t *testing.T) {
 	}, files)
 }
 
+func TestLocalStore_Delete(t *testing.T) {
+	absPath := fmt.Sprintf("file://%s/", t.TempDir())
+
+	s, err := NewFileLogStore(absPath, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"{}"}), false))
+	exist, err := s.Exists(context.Background(), "00000000000000000000.json")
+	assert.NoError(t, err)
+	assert.True(t, exist)
+
+	assert.NoError(t, s.Delete(context.Background(), absPath+"00000000000000000000.json"))
+	exist, err = s.Exists(context.Background(), "00000000000000000000.json")
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	assert.ErrorIs(t, s.Delete(context.Background(), "00000000000000000000.json"), errno.ErrFileNotFound)
+}
+
+func TestLocalStore_Write_exclusive(t *testing.T) {
+	dir := t.TempDir()
+	absPath := fmt.Sprintf("file://%s/", dir)
+
+	// each store has its own mutex, like the writers from different processes
+	n := 8
+	stores := make([]*LocalStore, n)
+	for i := range stores {
+		s, err := NewFileLogStore(absPath, nil)
+		assert.NoError(t, err)
+		stores[i] = s
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i, s := range stores {
+		wg.Add(1)
+		go func(i int, s *LocalStore) {
+			defer wg.Done()
+			errs[i] = s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{strconv.Itoa(i)}), false)
+		}(i, s)
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		if err == nil {
+			assert.Equal(t, -1, winner, "only one writer can succeed")
+			winner = i
+		} else {
+			assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+		}
+	}
+	assert.NotEqual(t, -1, winner)
+
+	data, err := stores[0].Read(context.Background(), "00000000000000000000.json")
+	assert.NoError(t, err)
+	sl, err := iter.ToSlice(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{strconv.Itoa(winner)}, sl)
+
+	// no temp file is left
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// overwrite replaces the file
+	assert.NoError(t, stores[0].Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), true))
+	data, err = stores[1].Read(context.Background(), "00000000000000000000.json")
+	assert.NoError(t, err)
+	sl, err = iter.ToSlice(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, sl)
+}
+
+func TestLocalStore_ListFrom_concurrent_writes(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileLogStore(fmt.Sprintf("file://%s/", dir), nil)
+	assert.NoError(t, err)
+
+	// the temp files of the writes are created and removed while the directory is listed
+	n := 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			assert.NoError(t, s.Write(context.Background(), fmt.Sprintf("%020d.json", i), iter.FromSlice([]string{"a"}), false))
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		it, err := s.ListFrom(context.Background(), "00000000000000000000.json")
+		assert.NoError(t, err)
+		_, err = iter.ToSlice(it)
+		if !assert.NoError(t, err) {
+			<-done
+			return
+		}
+	}
+}
+
+func TestLocalStore_Write_link_errors(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileLogStore(fmt.Sprintf("file://%s/", dir), nil)
+	assert.NoError(t, err)
+	defer func() { linkFile = os.Link }()
+
+	// a link(2) error other than unsupported fails the commit without writing the file in place
+	linkFile = func(oldname, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EIO}
+	}
+	err = s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), false)
+	assert.ErrorIs(t, err, syscall.EIO)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+
+	// the file is created by O_EXCL if hard links are not supported
+	linkFile = func(oldname, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.ENOTSUP}
+	}
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"b"}), false))
+	err = s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"c"}), false)
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+
+	data, err := s.Read(context.Background(), "00000000000000000000.json")
+	assert.NoError(t, err)
+	sl, err := iter.ToSlice(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, sl)
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
 func Test_relativePath(t *testing.T) {
 
 	tests := []struct {
@@ -2,10 +2,18 @@ package store
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 
+	s3managerv2 "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"gocloud.dev/blob"
 
 	"github.com/csimplestring/delta-go/errno"
@@ -14,10 +22,56 @@ import (
 	"github.com/rotisserie/eris"
 )
 
-// Note: currently s3 log store only supports single driver.
-// TODO: support multi-drivers writes
-// see https://delta.io/blog/2022-05-18-multi-cluster-writes-to-delta-lake-storage-in-s3/
-func NewS3LogStore(logDir string, m *blob.URLMux) (*S3SingleDriverLogStore, error) {
+// S3Option configures the S3 log stores.
+type S3Option func(s *baseStore)
+
+// WithS3ConditionalWrite makes the S3 log stores create the files with the conditional header If-None-Match: *,
+// so S3 rejects the write if the file exists. It provides the mutual exclusion of concurrent writers from multiple processes,
+// but it requires the S3 (compatible) store to support conditional writes, e.g. AWS S3 since 2024, MinIO or R2.
+func WithS3ConditionalWrite() S3Option {
+	return func(s *baseStore) {
+		s.beforeWriteFn = s3IfNoneMatchBeforeWrite
+		s.writeErrorFn = s3PreconditionFailedWriteError
+	}
+}
+
+// s3IfNoneMatchBeforeWrite adds the If-None-Match header to the requests of the uploader of either AWS SDK.
+func s3IfNoneMatchBeforeWrite(asFunc func(interface{}) bool) error {
+	var uploaderV2 *s3managerv2.Uploader
+	if asFunc(&uploaderV2) {
+		uploaderV2.ClientOptions = append(uploaderV2.ClientOptions, func(o *s3v2.Options) {
+			o.APIOptions = append(o.APIOptions, smithyhttp.AddHeaderValue("If-None-Match", "*"))
+		})
+		return nil
+	}
+	var uploader *s3manager.Uploader
+	if asFunc(&uploader) {
+		uploader.RequestOptions = append(uploader.RequestOptions, request.WithSetRequestHeaders(map[string]string{"If-None-Match": "*"}))
+		return nil
+	}
+	return eris.Wrap(errno.ErrUnsupportedOperation, "conditional write is only supported by the s3 blob driver")
+}
+
+// s3PreconditionFailedWriteError maps the 412 PreconditionFailed response of a conditional write to FileAlreadyExists.
+func s3PreconditionFailedWriteError(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+	var responseErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+		return errno.FileAlreadyExists(path)
+	}
+	var requestFailure awserr.RequestFailure
+	if errors.As(err, &requestFailure) && requestFailure.StatusCode() == http.StatusPreconditionFailed {
+		return errno.FileAlreadyExists(path)
+	}
+	return err
+}
+
+// Note: the single driver s3 log store only supports concurrent writers in the same process,
+// use WithS3ConditionalWrite if the store supports conditional writes,
+// or NewS3MultiDriverLogStore for writers from multiple processes.
+func NewS3LogStore(logDir string, m *blob.URLMux, opts ...S3Option) (*S3SingleDriverLogStore, error) {
 	// logDir is like: s3:///a/b/c/_delta_log/, must end with "/"
 	blobURL, err := path.ConvertToBlobURL(logDir)
 	if err != nil {
@@ -35,25 +89,10 @@ func NewS3LogStore(logDir string, m *blob.URLMux) (*S3SingleDriverLogStore, erro
 		return nil, err
 	}
 
-	logDir = strings.TrimPrefix(logDir, "s3://")
-	s := &baseStore{
-		logDir: logDir,
-		bucket: bucket,
-		beforeWriteFn: func(asFunc func(interface{}) bool) error {
-			return nil
-		},
-		writeErrorFn: func(err error, path string) error {
-			return err
-		},
-	}
-
-	return &S3SingleDriverLogStore{
-		logDir: logDir,
-		s:      s,
-	}, nil
+	return newS3SingleDriverLogStore(strings.TrimPrefix(logDir, "s3://"), bucket, opts...), nil
 }
 
-func NewS3CompatLogStore(u *url.URL, m *blob.URLMux) (*S3SingleDriverLogStore, error) {
+func NewS3CompatLogStore(u *url.URL, m *blob.URLMux, opts ...S3Option) (*S3SingleDriverLogStore, error) {
 	var bucket *blob.Bucket
 	var err error
 	if m == nil {
@@ -66,6 +105,11 @@ func NewS3CompatLogStore(u *url.URL, m *blob.URLMux) (*S3SingleDriverLogStore, e
 	}
 	logDir := handleLogDirPath(u.Path)
 	bucket = blob.PrefixedBucket(bucket, logDir)
+
+	return newS3SingleDriverLogStore(logDir, bucket, opts...), nil
+}
+
+func newS3SingleDriverLogStore(logDir string, bucket *blob.Bucket, opts ...S3Option) *S3SingleDriverLogStore {
 	s := &baseStore{
 		logDir: logDir,
 		bucket: bucket,
@@ -76,11 +120,14 @@ func NewS3CompatLogStore(u *url.URL, m *blob.URLMux) (*S3SingleDriverLogStore, e
 			return err
 		},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
 
 	return &S3SingleDriverLogStore{
 		logDir: logDir,
 		s:      s,
-	}, nil
+	}
 }
 
 func handleLogDirPath(path string) string {
@@ -109,23 +156,23 @@ func (a *S3SingleDriverLogStore) Root() string {
 // Read the given file and return an `Iterator` of lines, with line breaks removed from
 // each line. Callers of this function are responsible to close the iterator if they are
 // done with it.
-func (a *S3SingleDriverLogStore) Read(path string) (iter.Iter[string], error) {
+func (a *S3SingleDriverLogStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
 	path, err := a.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.s.Read(path)
+	return a.s.Read(ctx, path)
 }
 
 // List the paths in the same directory that are lexicographically greater or equal to (UTF-8 sorting) the given `path`. The result should also be sorted by the file name.
-func (a *S3SingleDriverLogStore) ListFrom(path string) (iter.Iter[*FileMeta], error) {
+func (a *S3SingleDriverLogStore) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
 	path, err := a.ResolvePathOnPhysicalStore(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.s.ListFrom(path)
+	return a.s.ListFrom(ctx, path)
 }
 
 // Write the given `actions` to the given `path` with or without overwrite as indicated.
@@ -133,7 +180,7 @@ func (a *S3SingleDriverLogStore) ListFrom(path string) (iter.Iter[*FileMeta], er
 // exists and overwrite = false. Furthermore, if isPartialWriteVisible returns false,
 // implementation must ensure that the entire file is made visible atomically, that is,
 // it should not generate partial files.
-func (a *S3SingleDriverLogStore) Write(path string, actions iter.Iter[string], overwrite bool) error {
+func (a *S3SingleDriverLogStore) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -143,7 +190,7 @@ func (a *S3SingleDriverLogStore) Write(path string, actions iter.Iter[string], o
 	}
 
 	if !overwrite {
-		ok, err := a.s.Exists(path)
+		ok, err := a.s.Exists(ctx, path)
 		if err != nil {
 			return eris.Wrap(err, "s3 failed to check existing file "+path)
 		}
@@ -152,7 +199,7 @@ func (a *S3SingleDriverLogStore) Write(path string, actions iter.Iter[string], o
 		}
 	}
 
-	return a.s.Write(path, actions, overwrite)
+	return a.s.Write(ctx, path, actions, overwrite)
 }
 
 // Resolve the fully qualified path for the given `path`.
@@ -165,10 +212,19 @@ func (a *S3SingleDriverLogStore) IsPartialWriteVisible(path string) bool {
 	return false
 }
 
-func (a *S3SingleDriverLogStore) Exists(path string) (bool, error) {
-	return a.s.Exists(path)
+func (a *S3SingleDriverLogStore) Exists(ctx context.Context, path string) (bool, error) {
+	return a.s.Exists(ctx, path)
 }
 
-func (a *S3SingleDriverLogStore) Create(path string) error {
-	return a.s.Create(path)
+func (a *S3SingleDriverLogStore) Create(ctx context.Context, path string) error {
+	return a.s.Create(ctx, path)
+}
+
+func (a *S3SingleDriverLogStore) Delete(ctx context.Context, path string) error {
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return err
+	}
+
+	return a.s.Delete(ctx, path)
 }
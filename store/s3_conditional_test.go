@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/stretchr/testify/assert"
+	"gocloud.dev/blob/s3blob"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// fakeConditionalS3 is a minimal S3 server which supports PutObject with If-None-Match: *.
+type fakeConditionalS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeConditionalS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	if _, ok := f.objects[r.URL.Path]; ok && r.Header.Get("If-None-Match") == "*" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		io.WriteString(w, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+		return
+	}
+	b, _ := io.ReadAll(r.Body)
+	f.objects[r.URL.Path] = string(b)
+	w.Header().Set("ETag", `"etag"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestS3LogStore_conditional_write(t *testing.T) {
+	fake := &fakeConditionalS3{objects: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := s3v2.New(s3v2.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	bucket, err := s3blob.OpenBucketV2(context.Background(), client, "bucket", nil)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	s := newS3SingleDriverLogStore("/table/_delta_log/", bucket, WithS3ConditionalWrite())
+
+	// write through the base store to skip the existence check of the single driver store
+	err = s.s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\n", fake.objects["/bucket/00000000000000000000.json"])
+
+	err = s.s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"b"}), false)
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+	assert.Equal(t, "a\n", fake.objects["/bucket/00000000000000000000.json"])
+
+	// overwrite is not conditional
+	err = s.s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"c"}), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "c\n", fake.objects["/bucket/00000000000000000000.json"])
+}
+
+func TestS3IfNoneMatchBeforeWrite_sdk_v1(t *testing.T) {
+	uploader := &s3manager.Uploader{}
+	err := s3IfNoneMatchBeforeWrite(func(i interface{}) bool {
+		p, ok := i.(**s3manager.Uploader)
+		if ok {
+			*p = uploader
+		}
+		return ok
+	})
+	assert.NoError(t, err)
+	assert.Len(t, uploader.RequestOptions, 1)
+
+	err = s3IfNoneMatchBeforeWrite(func(i interface{}) bool {
+		return false
+	})
+	assert.ErrorIs(t, err, errno.ErrUnsupportedOperation)
+}
+
+func TestS3PreconditionFailedWriteError(t *testing.T) {
+	err := s3PreconditionFailedWriteError(awserr.NewRequestFailure(awserr.New("PreconditionFailed", "", nil), 412, "id"), "0.json")
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+
+	err = s3PreconditionFailedWriteError(awserr.NewRequestFailure(awserr.New("InternalError", "", nil), 500, "id"), "0.json")
+	assert.NotErrorIs(t, err, errno.ErrFileAlreadyExists)
+
+	assert.NoError(t, s3PreconditionFailedWriteError(nil, "0.json"))
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+	"gocloud.dev/blob"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/internal/util/path"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+// the directory of the temp files in the log directory
+const multiDriverTempDir = ".tmp/"
+
+// the time a complete entry is kept in the CommitLockStore
+const commitEntryExpiration = 24 * time.Hour
+
+// NewS3MultiDriverLogStore creates a log store which supports concurrent writers from multiple processes,
+// with the mutual exclusion provided by the CommitLockStore. A commit of version N is done by:
+//  1. writing the content to a temp file,
+//  2. claiming version N by putting an incomplete entry to the CommitLockStore, which fails if another writer claimed it,
+//  3. copying the temp file to the delta file N,
+//  4. marking the entry as complete.
+//
+// The commit succeeds once version N is claimed, a failure in 3 or 4 is logged and leaves an incomplete entry,
+// which is recovered by the next writer or listing of the table. Only the writer which claimed the entry deletes its temp file.
+// All writers of the table must use the same CommitLockStore.
+func NewS3MultiDriverLogStore(logDir string, m *blob.URLMux, lockStore CommitLockStore) (*S3MultiDriverLogStore, error) {
+	// logDir is like: s3:///a/b/c/_delta_log/, must end with "/"
+	u, err := url.Parse(logDir)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error in parsing %s for Store", logDir)
+	}
+	blobURL, err := path.ConvertToBlobURL(logDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var bucket *blob.Bucket
+	if m == nil {
+		bucket, err = blob.OpenBucket(context.Background(), blobURL)
+	} else {
+		bucket, err = m.OpenBucket(context.Background(), blobURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &baseStore{
+		logDir: strings.TrimPrefix(logDir, u.Scheme+"://"),
+		bucket: bucket,
+		beforeWriteFn: func(asFunc func(interface{}) bool) error {
+			return nil
+		},
+		writeErrorFn: func(err error, path string) error {
+			return err
+		},
+	}
+
+	return &S3MultiDriverLogStore{
+		scheme:    u.Scheme,
+		tablePath: logDir,
+		s:         s,
+		lockStore: lockStore,
+	}, nil
+}
+
+type S3MultiDriverLogStore struct {
+	scheme string
+	// tablePath is the key of the table in the CommitLockStore
+	tablePath string
+	s         *baseStore
+	lockStore CommitLockStore
+	mu        sync.Mutex
+}
+
+func (a *S3MultiDriverLogStore) Root() string {
+	return ""
+}
+
+// Read the given file and return an `Iterator` of lines, with line breaks removed from
+// each line. Callers of this function are responsible to close the iterator if they are
+// done with it.
+func (a *S3MultiDriverLogStore) Read(ctx context.Context, path string) (iter.Iter[string], error) {
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.s.Read(ctx, path)
+}
+
+// List the paths in the same directory that are lexicographically greater or equal to (UTF-8 sorting) the given `path`. The result should also be sorted by the file name.
+// The latest commit is recovered first if it is incomplete, so it is always listed.
+func (a *S3MultiDriverLogStore) ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error) {
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.recoverLatestEntry(ctx); err != nil {
+		return nil, err
+	}
+
+	return a.s.ListFrom(ctx, path)
+}
+
+// Write the given `actions` to the given `path` with or without overwrite as indicated.
+// Delta files which are not overwritten are committed through the CommitLockStore,
+// it returns FileAlreadyExists error if another writer committed the same version.
+func (a *S3MultiDriverLogStore) Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return err
+	}
+
+	if overwrite {
+		return a.s.Write(ctx, path, actions, overwrite)
+	}
+	if !filenames.IsDeltaFile(path) {
+		ok, err := a.s.Exists(ctx, path)
+		if err != nil {
+			return eris.Wrap(err, "s3 failed to check existing file "+path)
+		}
+		if ok {
+			return errno.FileAlreadyExists(path)
+		}
+		return a.s.Write(ctx, path, actions, overwrite)
+	}
+
+	return a.writeDeltaFile(ctx, path, actions)
+}
+
+func (a *S3MultiDriverLogStore) writeDeltaFile(ctx context.Context, path string, actions iter.Iter[string]) error {
+	fileName := filepath.Base(path)
+	version := filenames.DeltaVersion(path)
+
+	if err := a.recoverLatestEntry(ctx); err != nil {
+		return err
+	}
+	// the previous version must be committed first
+	if version > 0 {
+		prev := filepath.Join(filepath.Dir(path), filepath.Base(filenames.DeltaFile("", version-1)))
+		ok, err := a.s.Exists(ctx, prev)
+		if err != nil {
+			return eris.Wrap(err, "s3 failed to check existing file "+prev)
+		}
+		if !ok {
+			return errno.IllegalStateError("the previous version of " + path + " does not exist")
+		}
+	}
+
+	tempPath := multiDriverTempDir + fileName + "." + uuid.New().String()
+	if err := a.s.Write(ctx, tempPath, actions, true); err != nil {
+		return err
+	}
+
+	entry := &CommitEntry{
+		TablePath: a.tablePath,
+		FileName:  fileName,
+		TempPath:  tempPath,
+		Complete:  false,
+	}
+	if err := a.lockStore.PutEntry(ctx, entry, false); err != nil {
+		// the version is claimed by another writer, so the temp file is never referenced.
+		// It is kept on other errors, as the entry may have been put.
+		if eris.Is(err, errno.ErrFileAlreadyExists) {
+			if err := a.s.Delete(ctx, tempPath); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+				log.Println("failed to delete the temp file " + tempPath + ": " + err.Error())
+			}
+		}
+		return err
+	}
+
+	// the commit is claimed and succeeds, a failure from now on is recovered later
+	if err := a.completeEntry(ctx, entry); err != nil {
+		log.Println("failed to complete the commit of " + path + ", it will be recovered later: " + err.Error())
+		return nil
+	}
+
+	// the temp file is not needed any more
+	if err := a.s.Delete(ctx, entry.TempPath); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+		log.Println("failed to delete the temp file " + entry.TempPath + ": " + err.Error())
+	}
+	return nil
+}
+
+// recoverLatestEntry completes the latest commit of the table if the writer failed after claiming it.
+func (a *S3MultiDriverLogStore) recoverLatestEntry(ctx context.Context) error {
+	latest, err := a.lockStore.GetLatestEntry(ctx, a.tablePath)
+	if err != nil {
+		return err
+	}
+	if e, ok := latest.Get(); ok && !e.Complete {
+		return a.completeEntry(ctx, e)
+	}
+	return nil
+}
+
+// completeEntry copies the temp file to the delta file if it does not exist and marks the entry as complete.
+// The temp file is kept, as the writer which claimed the entry may be copying it concurrently.
+func (a *S3MultiDriverLogStore) completeEntry(ctx context.Context, entry *CommitEntry) error {
+	path := entry.FileName
+	ok, err := a.s.Exists(ctx, path)
+	if err != nil {
+		return eris.Wrap(err, "s3 failed to check existing file "+path)
+	}
+	if !ok {
+		if err := a.copy(ctx, entry.TempPath, path); err != nil {
+			// the temp file is deleted after a concurrent writer completed the entry
+			if ok, existsErr := a.s.Exists(ctx, path); existsErr != nil || !ok {
+				return err
+			}
+		}
+	}
+
+	complete := *entry
+	complete.Complete = true
+	complete.ExpireTime = time.Now().Add(commitEntryExpiration).Unix()
+	return a.lockStore.PutEntry(ctx, &complete, true)
+}
+
+func (a *S3MultiDriverLogStore) copy(ctx context.Context, src string, dst string) error {
+	r, err := a.s.bucket.NewReader(ctx, src, nil)
+	if err != nil {
+		return errno.ContextError(ctx, eris.Wrap(err, "reading the temp file "+src))
+	}
+	defer r.Close()
+
+	w, err := a.s.bucket.NewWriter(ctx, dst, nil)
+	if err != nil {
+		return errno.ContextError(ctx, err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return errno.ContextError(ctx, eris.Wrap(err, "copying "+src+" to "+dst))
+	}
+	return errno.ContextError(ctx, w.Close())
+}
+
+// Resolve the fully qualified path for the given `path`.
+func (a *S3MultiDriverLogStore) ResolvePathOnPhysicalStore(path string) (string, error) {
+	return relativePath(a.scheme, a.s.logDir, path)
+}
+
+// Whether a partial write is visible for the underlying file system of `path`.
+func (a *S3MultiDriverLogStore) IsPartialWriteVisible(path string) bool {
+	return false
+}
+
+func (a *S3MultiDriverLogStore) Exists(ctx context.Context, path string) (bool, error) {
+	return a.s.Exists(ctx, path)
+}
+
+func (a *S3MultiDriverLogStore) Create(ctx context.Context, path string) error {
+	return a.s.Create(ctx, path)
+}
+
+func (a *S3MultiDriverLogStore) Delete(ctx context.Context, path string) error {
+	path, err := a.ResolvePathOnPhysicalStore(path)
+	if err != nil {
+		return err
+	}
+
+	return a.s.Delete(ctx, path)
+}
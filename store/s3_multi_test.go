@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	_ "gocloud.dev/blob/fileblob"
+
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+func newTestMultiDriverStores(t *testing.T, n int, lockStore CommitLockStore) (string, []*S3MultiDriverLogStore) {
+	dir := t.TempDir()
+	var stores []*S3MultiDriverLogStore
+	for i := 0; i < n; i++ {
+		s, err := NewS3MultiDriverLogStore(fmt.Sprintf("file://%s/", dir), nil, lockStore)
+		assert.NoError(t, err)
+		stores = append(stores, s)
+	}
+	return dir, stores
+}
+
+func listFileNames(t *testing.T, s Store) []string {
+	it, err := s.ListFrom(context.Background(), "0")
+	assert.NoError(t, err)
+	names, err := iter.Map(it, func(f *FileMeta) (string, error) {
+		return f.Path(), nil
+	})
+	assert.NoError(t, err)
+	return names
+}
+
+func TestS3MultiDriverLogStore_Write(t *testing.T) {
+	fileLockStore, err := NewFileCommitLockStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for name, lockStore := range map[string]CommitLockStore{"memory": NewMemoryCommitLockStore(), "file": fileLockStore} {
+		t.Run(name, func(t *testing.T) {
+			_, stores := newTestMultiDriverStores(t, 1, lockStore)
+			s := stores[0]
+
+			assert.NoError(t, s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), false))
+			assert.NoError(t, s.Write(context.Background(), "00000000000000000001.json", iter.FromSlice([]string{"b"}), false))
+
+			err := s.Write(context.Background(), "00000000000000000001.json", iter.FromSlice([]string{"c"}), false)
+			assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+
+			// version 2 is missing
+			err = s.Write(context.Background(), "00000000000000000003.json", iter.FromSlice([]string{"d"}), false)
+			assert.ErrorIs(t, err, errno.ErrIllegalState)
+
+			assert.Equal(t, []string{"00000000000000000000.json", "00000000000000000001.json"}, listFileNames(t, s))
+			lines, err := s.Read(context.Background(), "00000000000000000001.json")
+			assert.NoError(t, err)
+			sl, err := iter.ToSlice(lines)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"b"}, sl)
+
+			e, err := lockStore.GetEntry(context.Background(), s.tablePath, "00000000000000000001.json")
+			assert.NoError(t, err)
+			assert.True(t, e.MustGet().Complete)
+			// the temp files are deleted
+			exists, err := s.Exists(context.Background(), e.MustGet().TempPath)
+			assert.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestS3MultiDriverLogStore_concurrent_writers(t *testing.T) {
+	lockStore, err := NewFileCommitLockStore(t.TempDir())
+	assert.NoError(t, err)
+	dir, stores := newTestMultiDriverStores(t, 8, lockStore)
+
+	assert.NoError(t, stores[0].Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"init"}), false))
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(stores))
+	for i, s := range stores {
+		wg.Add(1)
+		go func(i int, s *S3MultiDriverLogStore) {
+			defer wg.Done()
+			errs[i] = s.Write(context.Background(), "00000000000000000001.json", iter.FromSlice([]string{fmt.Sprint(i)}), false)
+		}(i, s)
+	}
+	wg.Wait()
+
+	winners := 0
+	for _, err := range errs {
+		if err == nil {
+			winners++
+		} else {
+			assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+		}
+	}
+	assert.Equal(t, 1, winners)
+
+	// the temp files of the writers which lost the version are deleted too
+	entries, err := os.ReadDir(filepath.Join(dir, multiDriverTempDir))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestS3MultiDriverLogStore_recover_incomplete_commit(t *testing.T) {
+	lockStore := NewMemoryCommitLockStore()
+	dir, stores := newTestMultiDriverStores(t, 1, lockStore)
+	s := stores[0]
+
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), false))
+
+	// a writer failed after claiming version 1
+	tempPath := multiDriverTempDir + "00000000000000000001.json.failed"
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, multiDriverTempDir), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, tempPath), []byte("b\n"), 0644))
+	assert.NoError(t, lockStore.PutEntry(context.Background(), &CommitEntry{
+		TablePath: s.tablePath,
+		FileName:  "00000000000000000001.json",
+		TempPath:  tempPath,
+	}, false))
+
+	// the listing completes the commit
+	assert.Equal(t, []string{"00000000000000000000.json", "00000000000000000001.json"}, listFileNames(t, s))
+	e, err := lockStore.GetLatestEntry(context.Background(), s.tablePath)
+	assert.NoError(t, err)
+	assert.True(t, e.MustGet().Complete)
+
+	lines, err := s.Read(context.Background(), "00000000000000000001.json")
+	assert.NoError(t, err)
+	sl, err := iter.ToSlice(lines)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, sl)
+
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000002.json", iter.FromSlice([]string{"c"}), false))
+}
+
+// failingCompleteLockStore fails to mark the entries as complete while fail is set.
+type failingCompleteLockStore struct {
+	CommitLockStore
+	fail bool
+}
+
+func (f *failingCompleteLockStore) PutEntry(ctx context.Context, entry *CommitEntry, overwrite bool) error {
+	if f.fail && entry.Complete {
+		return errors.New("lock store unavailable")
+	}
+	return f.CommitLockStore.PutEntry(ctx, entry, overwrite)
+}
+
+func TestS3MultiDriverLogStore_failure_after_claim(t *testing.T) {
+	lockStore := &failingCompleteLockStore{CommitLockStore: NewMemoryCommitLockStore()}
+	dir, stores := newTestMultiDriverStores(t, 2, lockStore)
+	s := stores[0]
+
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000000.json", iter.FromSlice([]string{"a"}), false))
+
+	// the commit succeeds once it is claimed
+	lockStore.fail = true
+	assert.NoError(t, s.Write(context.Background(), "00000000000000000001.json", iter.FromSlice([]string{"b"}), false))
+	e, err := lockStore.GetLatestEntry(context.Background(), s.tablePath)
+	assert.NoError(t, err)
+	assert.False(t, e.MustGet().Complete)
+	// the temp file is kept for the recovery
+	_, err = os.Stat(filepath.Join(dir, e.MustGet().TempPath))
+	assert.NoError(t, err)
+
+	// the other writer recovers the entry and cannot commit the same version again
+	lockStore.fail = false
+	err = stores[1].Write(context.Background(), "00000000000000000001.json", iter.FromSlice([]string{"c"}), false)
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+	e, err = lockStore.GetLatestEntry(context.Background(), s.tablePath)
+	assert.NoError(t, err)
+	assert.True(t, e.MustGet().Complete)
+
+	lines, err := s.Read(context.Background(), "00000000000000000001.json")
+	assert.NoError(t, err)
+	sl, err := iter.ToSlice(lines)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, sl)
+
+	// the recovery does not need the deleted temp file when the delta file exists
+	tempPath := multiDriverTempDir + "00000000000000000002.json.deleted"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000002.json"), []byte("d\n"), 0644))
+	assert.NoError(t, lockStore.PutEntry(context.Background(), &CommitEntry{
+		TablePath: s.tablePath,
+		FileName:  "00000000000000000002.json",
+		TempPath:  tempPath,
+	}, false))
+	assert.NoError(t, s.completeEntry(context.Background(), &CommitEntry{
+		TablePath: s.tablePath,
+		FileName:  "00000000000000000002.json",
+		TempPath:  tempPath,
+	}))
+}
+
+func TestFileCommitLockStore(t *testing.T) {
+	s, err := NewFileCommitLockStore(t.TempDir())
+	assert.NoError(t, err)
+
+	latest, err := s.GetLatestEntry(context.Background(), "s3://bucket/table/_delta_log/")
+	assert.NoError(t, err)
+	assert.True(t, latest.IsAbsent())
+
+	for _, name := range []string{"00000000000000000000.json", "00000000000000000002.json", "00000000000000000001.json"} {
+		assert.NoError(t, s.PutEntry(context.Background(), &CommitEntry{TablePath: "s3://bucket/table/_delta_log/", FileName: name, TempPath: "tmp"}, false))
+	}
+	err = s.PutEntry(context.Background(), &CommitEntry{TablePath: "s3://bucket/table/_delta_log/", FileName: "00000000000000000001.json"}, false)
+	assert.ErrorIs(t, err, errno.ErrFileAlreadyExists)
+	assert.NoError(t, s.PutEntry(context.Background(), &CommitEntry{TablePath: "s3://bucket/table/_delta_log/", FileName: "00000000000000000001.json", Complete: true}, true))
+
+	e, err := s.GetEntry(context.Background(), "s3://bucket/table/_delta_log/", "00000000000000000001.json")
+	assert.NoError(t, err)
+	assert.True(t, e.MustGet().Complete)
+
+	latest, err = s.GetLatestEntry(context.Background(), "s3://bucket/table/_delta_log/")
+	assert.NoError(t, err)
+	assert.Equal(t, &CommitEntry{TablePath: "s3://bucket/table/_delta_log/", FileName: "00000000000000000002.json", TempPath: "tmp"}, latest.MustGet())
+
+	// other tables are separated
+	latest, err = s.GetLatestEntry(context.Background(), "s3://bucket/other/_delta_log/")
+	assert.NoError(t, err)
+	assert.True(t, latest.IsAbsent())
+}
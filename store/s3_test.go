@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -22,14 +23,14 @@ func TestS3LogStore(t *testing.T) {
 	s, err := NewS3LogStore(path, nil)
 	assert.NoError(t, err)
 
-	data, err := s.Read("00000000000000000000.json")
+	data, err := s.Read(context.Background(), "00000000000000000000.json")
 	assert.NoError(t, err)
 
 	sl, err := iter.ToSlice(data)
 	assert.NoError(t, err)
 	assert.Equal(t, 4, len(sl))
 
-	it, err := s.ListFrom("00000000000000000007.json")
+	it, err := s.ListFrom(context.Background(), "00000000000000000007.json")
 	assert.NoError(t, err)
 
 	files, err := iter.Map(it, func(f *FileMeta) (string, error) {
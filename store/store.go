@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -27,23 +28,26 @@ import (
 // as a single parameter. This constructor is used to dynamically create the Store.
 // Store and its implementations are not meant for direct access but for configuration based
 // on storage system. See [[https://docs.delta.io/latest/delta-storage.html]] for details.
+// The ctx of a method is passed to the underlying storage calls, an iterator keeps using the ctx it was created with.
+// If the ctx is done, the method returns errno.CanceledError.
+// An implementation without the ctx parameters can be adapted by FromLegacyStore.
 type Store interface {
 	Root() string
 
 	// Read the given file and return an `Iterator` of lines, with line breaks removed from
 	// each line. Callers of this function are responsible to close the iterator if they are
 	// done with it.
-	Read(path string) (iter.Iter[string], error)
+	Read(ctx context.Context, path string) (iter.Iter[string], error)
 
 	// List the paths in the same directory that are lexicographically greater or equal to (UTF-8 sorting) the given `path`. The result should also be sorted by the file name.
-	ListFrom(path string) (iter.Iter[*FileMeta], error)
+	ListFrom(ctx context.Context, path string) (iter.Iter[*FileMeta], error)
 
 	// Write the given `actions` to the given `path` with or without overwrite as indicated.
 	// Implementation must throw FileAlreadyExistsException exception if the file already
 	// exists and overwrite = false. Furthermore, if isPartialWriteVisible returns false,
 	// implementation must ensure that the entire file is made visible atomically, that is,
 	// it should not generate partial files.
-	Write(path string, actions iter.Iter[string], overwrite bool) error
+	Write(ctx context.Context, path string, actions iter.Iter[string], overwrite bool) error
 
 	// Resolve the fully qualified path for the given `path`.
 	ResolvePathOnPhysicalStore(path string) (string, error)
@@ -51,9 +55,13 @@ type Store interface {
 	// Whether a partial write is visible for the underlying file system of `path`.
 	IsPartialWriteVisible(path string) bool
 
-	Exists(path string) (bool, error)
+	Exists(ctx context.Context, path string) (bool, error)
 
-	Create(path string) error
+	Create(ctx context.Context, path string) error
+
+	// Delete the file at the given `path`.
+	// Implementation must return FileNotFound error if the file does not exist.
+	Delete(ctx context.Context, path string) error
 }
 
 type FileMeta struct {
@@ -95,6 +103,36 @@ func New(logPath string, m *blob.URLMux) (Store, error) {
 	return nil, errno.UnsupportedFileSystem("unsupported schema " + logPath + " to create log store")
 }
 
+// NewWithCommitLock creates the Store for the logPath like New. For S3, which does not support mutual exclusion of writes,
+// the multi-driver log store is created with the mutual exclusion provided by the lockStore.
+func NewWithCommitLock(logPath string, m *blob.URLMux, lockStore CommitLockStore) (Store, error) {
+	p, err := url.Parse(logPath)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error in parsing %s for Store", logPath)
+	}
+
+	if p.Scheme == "s3" {
+		return NewS3MultiDriverLogStore(logPath, m, lockStore)
+	}
+	return New(logPath, m)
+}
+
+// NewWithConditionalWrite creates the Store for the logPath like New. For S3, the files are created by conditional writes,
+// see WithS3ConditionalWrite.
+func NewWithConditionalWrite(logPath string, m *blob.URLMux) (Store, error) {
+	p, err := url.Parse(logPath)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error in parsing %s for Store", logPath)
+	}
+
+	if p.Scheme == "s3" {
+		return NewS3LogStore(logPath, m, WithS3ConditionalWrite())
+	} else if p.Scheme == "lakefs" {
+		return NewS3CompatLogStore(p, m, WithS3ConditionalWrite())
+	}
+	return New(logPath, m)
+}
+
 func relativePath(scheme string, basePath string, path string) (string, error) {
 	rel := func(base string, path string) (string, error) {
 		relativePath, err := filepath.Rel(basePath, path)
@@ -0,0 +1,376 @@
+package deltago
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/types"
+)
+
+// defaultMaxFilesPerTrigger is the default number of the files in a batch of a StreamSource, the same as Spark's.
+const defaultMaxFilesPerTrigger = 1000
+
+// streamOffsetSourceVersion is the version of the format of StreamOffset.
+const streamOffsetSourceVersion = 1
+
+// StreamSourceOptions are the options of a StreamSource, they mirror the options of Spark's Delta streaming source.
+// At most one of StartingVersion, StartingTimestamp and StartingLatest can be set. If none is set,
+// the source starts with all the files of the latest snapshot, then the changes committed after it.
+type StreamSourceOptions struct {
+	// StartingVersion starts the source from the changes committed in the version.
+	StartingVersion mo.Option[int64]
+	// StartingTimestamp starts the source from the changes committed in the version at or after the timestamp in milliseconds.
+	StartingTimestamp mo.Option[int64]
+	// StartingLatest starts the source from the changes committed after the latest version.
+	StartingLatest bool
+	// StartingOffset resumes the source right after the offset of a batch, the other starting options are ignored.
+	StartingOffset mo.Option[StreamOffset]
+
+	// MaxFilesPerTrigger is the maximum number of the files in a batch, 1000 if it is not positive.
+	MaxFilesPerTrigger int
+	// MaxBytesPerTrigger is the soft maximum size of the files in a batch, a batch has at least one file even if it is larger.
+	// There is no limit if it is not positive.
+	MaxBytesPerTrigger int64
+
+	// IgnoreDeletes skips the commits which only remove files, e.g. the deletion of partitions.
+	IgnoreDeletes bool
+	// IgnoreChanges skips the removed files of the commits which rewrite files, e.g. UPDATE, MERGE or DELETE,
+	// the rewritten files are returned again. It implies IgnoreDeletes.
+	IgnoreChanges bool
+}
+
+// StreamOffset is the position of a StreamSource, it can be serialized by Json and restored by ParseStreamOffset.
+// The files up to Index of Version are read, Index -1 means no file of Version is read.
+type StreamOffset struct {
+	SourceVersion int `json:"sourceVersion"`
+	// TableId is the id of the table, the offset cannot be used after the table is replaced.
+	TableId string `json:"reservoirId"`
+	Version int64  `json:"reservoirVersion"`
+	Index   int64  `json:"index"`
+	// IsStartingVersion is true if the files of the snapshot of Version are read, rather than the files added in Version.
+	IsStartingVersion bool `json:"isStartingVersion"`
+}
+
+func (o StreamOffset) Json() (string, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "", errno.JsonMarshalError(err)
+	}
+	return string(b), nil
+}
+
+// ParseStreamOffset parses an offset serialized by StreamOffset.Json.
+func ParseStreamOffset(s string) (StreamOffset, error) {
+	var o StreamOffset
+	if err := json.Unmarshal([]byte(s), &o); err != nil {
+		return StreamOffset{}, errno.JsonUnmarshalError(err)
+	}
+	if o.SourceVersion > streamOffsetSourceVersion {
+		return StreamOffset{}, eris.Wrap(errno.ErrIllegalArgument, fmt.Sprintf("unsupported version %d of the stream offset", o.SourceVersion))
+	}
+	return o, nil
+}
+
+// StreamBatch is a batch of the files of a StreamSource.
+type StreamBatch struct {
+	// Files are the new files in the order of the versions and of the actions in a version,
+	// or sorted by modification time and path for the starting snapshot.
+	Files []*action.AddFile
+	// Offset is the offset after the batch, it should be stored when the batch is processed to resume the source.
+	Offset StreamOffset
+}
+
+// StreamSource returns the files added to a table batch by batch, like Spark's Delta streaming source.
+// The files removed by the commits fail the source, unless they are ignored by the options.
+// A StreamSource is not safe for concurrent use.
+type StreamSource struct {
+	log    *logImpl
+	opts   StreamSourceOptions
+	schema *types.StructType
+	offset StreamOffset
+	// startingFiles are the files of the starting snapshot sorted by modification time and path, loaded when it is read
+	startingFiles []*action.AddFile
+}
+
+// StreamSource creates a StreamSource on the table, the schema of the latest snapshot is the schema of the source.
+func (l *logImpl) StreamSource(opts StreamSourceOptions) (*StreamSource, error) {
+	return l.StreamSourceContext(context.Background(), opts)
+}
+
+// StreamSourceContext is StreamSource with a context to cancel the reading of the log.
+func (l *logImpl) StreamSourceContext(ctx context.Context, opts StreamSourceOptions) (*StreamSource, error) {
+	res, err := l.newStreamSource(ctx, opts)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) newStreamSource(ctx context.Context, opts StreamSourceOptions) (*StreamSource, error) {
+	s, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.Version() < 0 {
+		return nil, eris.Wrap(errno.ErrIllegalState, "cannot stream from a table which does not exist: "+l.dataPath)
+	}
+	metadata, err := s.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := metadata.Schema()
+	if err != nil {
+		return nil, err
+	}
+	src := &StreamSource{log: l, opts: opts, schema: schema}
+
+	if offset, ok := opts.StartingOffset.Get(); ok {
+		if offset.TableId != metadata.ID {
+			return nil, errno.StreamSourceTableIdMismatchError(offset.TableId, metadata.ID)
+		}
+		src.offset = offset
+		return src, nil
+	}
+
+	numStartingOptions := 0
+	for _, set := range []bool{opts.StartingVersion.IsPresent(), opts.StartingTimestamp.IsPresent(), opts.StartingLatest} {
+		if set {
+			numStartingOptions++
+		}
+	}
+	if numStartingOptions > 1 {
+		return nil, eris.Wrap(errno.ErrIllegalArgument, "only one of StartingVersion, StartingTimestamp and StartingLatest can be set")
+	}
+
+	src.offset = StreamOffset{SourceVersion: streamOffsetSourceVersion, TableId: metadata.ID, Index: -1}
+	switch {
+	case opts.StartingLatest:
+		src.offset.Version = s.Version() + 1
+	case opts.StartingVersion.IsPresent():
+		version := opts.StartingVersion.MustGet()
+		earliest, err := l.history.getEarliestDeltaFile(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if version < earliest || version > s.Version() {
+			return nil, errno.VersionNotExist(version, earliest, s.Version())
+		}
+		src.offset.Version = version
+	case opts.StartingTimestamp.IsPresent():
+		version, err := l.VersionAtOrAfterTimestampContext(ctx, opts.StartingTimestamp.MustGet())
+		if err != nil {
+			return nil, err
+		}
+		src.offset.Version = version
+	default:
+		src.offset.Version = s.Version()
+		src.offset.IsStartingVersion = true
+	}
+	return src, nil
+}
+
+// Offset returns the offset of the last batch, or the starting offset if no batch is returned yet.
+func (s *StreamSource) Offset() StreamOffset {
+	return s.offset
+}
+
+// NextBatch returns the files after the offset of the last batch within the limits of MaxFilesPerTrigger and MaxBytesPerTrigger.
+// The batch has no file if there are no new files yet.
+func (s *StreamSource) NextBatch() (*StreamBatch, error) {
+	return s.NextBatchContext(context.Background())
+}
+
+// NextBatchContext is NextBatch with a context to cancel the reading of the log.
+func (s *StreamSource) NextBatchContext(ctx context.Context) (*StreamBatch, error) {
+	res, err := s.nextBatch(ctx)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (s *StreamSource) nextBatch(ctx context.Context) (*StreamBatch, error) {
+	limits := &admissionLimits{files: s.opts.MaxFilesPerTrigger, bytes: s.opts.MaxBytesPerTrigger}
+	if limits.files <= 0 {
+		limits.files = defaultMaxFilesPerTrigger
+	}
+	if limits.bytes <= 0 {
+		limits.bytes = math.MaxInt64
+	}
+
+	batch := &StreamBatch{}
+	pos := s.offset
+	if pos.IsStartingVersion {
+		files, err := s.loadStartingFiles(ctx, pos.Version)
+		if err != nil {
+			return nil, err
+		}
+		for i := pos.Index + 1; i < int64(len(files)); i++ {
+			if !limits.admit(files[i]) {
+				return s.complete(batch, pos), nil
+			}
+			batch.Files = append(batch.Files, files[i])
+			pos.Index = i
+		}
+		pos = s.nextVersion(pos)
+		s.startingFiles = nil
+	}
+
+	snapshot, err := s.log.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pos.Version > snapshot.Version() {
+		return s.complete(batch, pos), nil
+	}
+
+	versionLogs, err := s.log.changes(ctx, pos.Version, true)
+	if err != nil {
+		return nil, err
+	}
+	defer versionLogs.Close()
+
+	for {
+		v, err := versionLogs.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(*MemOptimizedCheckpoint); ok {
+			continue
+		}
+
+		actions, err := v.Actions()
+		if err != nil {
+			return nil, err
+		}
+		adds, err := s.filterAddFiles(v.Version(), actions)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range adds {
+			if a.index <= pos.Index {
+				continue
+			}
+			if !limits.admit(a.add) {
+				return s.complete(batch, pos), nil
+			}
+			batch.Files = append(batch.Files, a.add)
+			pos.Index = a.index
+		}
+		pos = s.nextVersion(pos)
+	}
+	return s.complete(batch, pos), nil
+}
+
+func (s *StreamSource) complete(batch *StreamBatch, pos StreamOffset) *StreamBatch {
+	s.offset = pos
+	batch.Offset = pos
+	return batch
+}
+
+func (s *StreamSource) nextVersion(pos StreamOffset) StreamOffset {
+	pos.Version++
+	pos.Index = -1
+	pos.IsStartingVersion = false
+	return pos
+}
+
+func (s *StreamSource) loadStartingFiles(ctx context.Context, version int64) ([]*action.AddFile, error) {
+	if s.startingFiles != nil {
+		return s.startingFiles, nil
+	}
+
+	snapshot, err := s.log.snapshotReader.getSnapshotForVersionAsOf(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	files, err := snapshot.AllFilesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files = append([]*action.AddFile{}, files...)
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].ModificationTime != files[j].ModificationTime {
+			return files[i].ModificationTime < files[j].ModificationTime
+		}
+		return files[i].Path < files[j].Path
+	})
+	s.startingFiles = files
+	return files, nil
+}
+
+type indexedAddFile struct {
+	index int64
+	add   *action.AddFile
+}
+
+// filterAddFiles returns the files added by the commit with their indexes in the commit,
+// and checks that the files removed by the commit and the schema changes are allowed.
+// A schema change is allowed if the files are still readable with the schema of the source, e.g. nullable columns are added.
+func (s *StreamSource) filterAddFiles(version int64, actions []action.Action) ([]indexedAddFile, error) {
+	var (
+		adds          []indexedAddFile
+		removedFile   mo.Option[string]
+		allowChanges  = s.opts.IgnoreChanges
+		allowDeletes  = allowChanges || s.opts.IgnoreDeletes
+		schemaChanged bool
+	)
+	for i, a := range actions {
+		switch v := a.(type) {
+		case *action.AddFile:
+			if v.DataChange {
+				adds = append(adds, indexedAddFile{index: int64(i), add: v})
+			}
+		case *action.RemoveFile:
+			if v.DataChange && removedFile.IsAbsent() {
+				removedFile = mo.Some(v.Path)
+			}
+		case *action.Metadata:
+			schema, err := v.Schema()
+			if err != nil {
+				return nil, err
+			}
+			schemaChanged = schemaChanged || !types.IsReadCompatible(s.schema, schema)
+		}
+	}
+
+	if schemaChanged {
+		return nil, errno.StreamSourceSchemaChangeError(version)
+	}
+	if path, ok := removedFile.Get(); ok {
+		if len(adds) > 0 && !allowChanges {
+			return nil, errno.StreamSourceChangeError(version, path)
+		}
+		if len(adds) == 0 && !allowDeletes {
+			return nil, errno.StreamSourceDeleteError(version, path)
+		}
+	}
+	return adds, nil
+}
+
+// admissionLimits limits the files admitted in a batch, a file is admitted as long as the limits are not exhausted.
+type admissionLimits struct {
+	files int
+	bytes int64
+}
+
+func (a *admissionLimits) admit(f *action.AddFile) bool {
+	if a.files <= 0 || a.bytes <= 0 {
+		return false
+	}
+	a.files--
+	a.bytes -= f.Size
+	return true
+}
@@ -0,0 +1,140 @@
+package deltago
+
+import (
+	"testing"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/types"
+)
+
+func TestStreamSource(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	add := func(path string, modificationTime int64, dataChange bool) *action.AddFile {
+		f := testAddFile(path)
+		f.Size = 10
+		f.ModificationTime = modificationTime
+		f.DataChange = dataChange
+		return f
+	}
+	paths := func(b *StreamBatch) []string {
+		var res []string
+		for _, f := range b.Files {
+			res = append(res, f.Path)
+		}
+		return res
+	}
+	nextBatch := func(src *StreamSource) *StreamBatch {
+		b, err := src.NextBatch()
+		assert.NoError(t, err)
+		return b
+	}
+
+	metadata := getTestMetedata()
+	metadata.ID = "table"
+	commitTestActions(t, log, metadata, add("a", 2, true), add("b", 1, true))
+
+	// the starting snapshot is sorted by modification time
+	src, err := log.StreamSource(StreamSourceOptions{MaxFilesPerTrigger: 1})
+	assert.NoError(t, err)
+	b := nextBatch(src)
+	assert.Equal(t, []string{"b"}, paths(b))
+	assert.Equal(t, StreamOffset{SourceVersion: 1, TableId: "table", Version: 0, Index: 0, IsStartingVersion: true}, b.Offset)
+	b = nextBatch(src)
+	assert.Equal(t, []string{"a"}, paths(b))
+	assert.Equal(t, StreamOffset{SourceVersion: 1, TableId: "table", Version: 1, Index: -1}, b.Offset)
+	b = nextBatch(src)
+	assert.Empty(t, b.Files)
+	assert.Equal(t, src.Offset(), b.Offset)
+
+	// the files which do not change data are skipped
+	commitTestActions(t, log, add("c", 3, true), add("d", 4, true), add("e", 5, false))
+	b = nextBatch(src)
+	assert.Equal(t, []string{"c"}, paths(b))
+
+	// resume from the serialized offset
+	offset, err := b.Offset.Json()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sourceVersion":1,"reservoirId":"table","reservoirVersion":1,"index":1,"isStartingVersion":false}`, offset)
+	resumedOffset, err := ParseStreamOffset(offset)
+	assert.NoError(t, err)
+	resumed, err := log.StreamSource(StreamSourceOptions{StartingOffset: mo.Some(resumedOffset)})
+	assert.NoError(t, err)
+	b = nextBatch(resumed)
+	assert.Equal(t, []string{"d"}, paths(b))
+	assert.Equal(t, StreamOffset{SourceVersion: 1, TableId: "table", Version: 2, Index: -1}, b.Offset)
+
+	// deletes
+	commitTestActions(t, log, testRemoveFile("c"))
+	_, err = resumed.NextBatch()
+	assert.True(t, eris.Is(err, errno.ErrUnsupportedOperation))
+	assert.Contains(t, err.Error(), "IgnoreDeletes")
+
+	ignoreDeletes, err := log.StreamSource(StreamSourceOptions{StartingOffset: mo.Some(resumed.Offset()), IgnoreDeletes: true})
+	assert.NoError(t, err)
+	b = nextBatch(ignoreDeletes)
+	assert.Empty(t, b.Files)
+	assert.Equal(t, int64(3), b.Offset.Version)
+
+	// updates
+	commitTestActions(t, log, testRemoveFile("d"), add("f", 6, true))
+	_, err = ignoreDeletes.NextBatch()
+	assert.True(t, eris.Is(err, errno.ErrUnsupportedOperation))
+	assert.Contains(t, err.Error(), "IgnoreChanges")
+
+	ignoreChanges, err := log.StreamSource(StreamSourceOptions{StartingOffset: mo.Some(resumed.Offset()), IgnoreChanges: true})
+	assert.NoError(t, err)
+	b = nextBatch(ignoreChanges)
+	assert.Equal(t, []string{"f"}, paths(b))
+
+	// rate limits
+	fromVersion, err := log.StreamSource(StreamSourceOptions{StartingVersion: mo.Some[int64](1), MaxBytesPerTrigger: 15, IgnoreChanges: true})
+	assert.NoError(t, err)
+	var batches [][]string
+	for b = nextBatch(fromVersion); len(b.Files) > 0; b = nextBatch(fromVersion) {
+		batches = append(batches, paths(b))
+	}
+	assert.Equal(t, [][]string{{"c", "d"}, {"f"}}, batches)
+
+	latest, err := log.StreamSource(StreamSourceOptions{StartingLatest: true})
+	assert.NoError(t, err)
+	b = nextBatch(latest)
+	assert.Empty(t, b.Files)
+	assert.Equal(t, int64(4), b.Offset.Version)
+
+	// schema changes
+	commitSchema := func(fields ...*types.StructField) {
+		schemaString, err := types.ToJSON(types.NewStructType(fields))
+		assert.NoError(t, err)
+		newMetadata := getTestMetedata()
+		newMetadata.ID = "table"
+		newMetadata.SchemaString = schemaString
+		commitTestActions(t, log, newMetadata)
+	}
+	// the additive nullable columns are readable with the schema of the source
+	commitSchema(types.NewStructField("x", &types.IntegerType{}, true), types.NewStructField("y", &types.IntegerType{}, true))
+	commitTestActions(t, log, add("g", 7, true))
+	b = nextBatch(latest)
+	assert.Equal(t, []string{"g"}, paths(b))
+	commitSchema(types.NewStructField("x", &types.IntegerType{}, true), types.NewStructField("y", &types.IntegerType{}, true),
+		types.NewStructField("z", &types.IntegerType{}, false))
+	_, err = latest.NextBatch()
+	assert.True(t, eris.Is(err, errno.ErrIllegalState))
+
+	// invalid options
+	_, err = log.StreamSource(StreamSourceOptions{StartingLatest: true, StartingVersion: mo.Some[int64](1)})
+	assert.True(t, eris.Is(err, errno.ErrIllegalArgument))
+	_, err = log.StreamSource(StreamSourceOptions{StartingVersion: mo.Some[int64](10)})
+	assert.Error(t, err)
+	_, err = log.StreamSource(StreamSourceOptions{StartingOffset: mo.Some(StreamOffset{TableId: "other"})})
+	assert.True(t, eris.Is(err, errno.ErrIllegalArgument))
+}
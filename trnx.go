@@ -1,11 +1,14 @@
 package deltago
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/errno"
@@ -28,6 +31,8 @@ import (
 
 // OptimisticTransaction  to perform a set of reads in a transaction and
 // then commit a set of updates to the state of the log.
+// The methods suffixed with Context accept a context to cancel the I/O of the call,
+// a canceled call returns an error matching errno.ErrCanceled and the context error.
 // All reads from the DeltaLog MUST go through this instance rather than directly to the DeltaLog otherwise they will not be checked for logical conflicts with concurrent updates.
 // This class is not thread-safe.
 type OptimisticTransaction interface {
@@ -38,6 +43,10 @@ type OptimisticTransaction interface {
 	// Because of this, be sure to generate all RemoveFiles using AddFiles read from the Delta Log (do not use the actions.AddFiles created pre-commit.)
 	Commit(actions iter.Iter[action.Action], op *op.Operation, engineInfo string) (CommitResult, error)
 
+	// CommitContext is Commit with a context. The context is checked before every commit attempt,
+	// if it is canceled after the commit file is written, the commit may have succeeded even though an error is returned.
+	CommitContext(ctx context.Context, actions iter.Iter[action.Action], op *op.Operation, engineInfo string) (CommitResult, error)
+
 	// Mark files matched by the readPredicate as read by this transaction.
 	// Please note filtering is only supported on partition columns, thus the files matched may be a superset of the files in the Delta table that satisfy readPredicate.
 	// Users should use Scan.ResidualPredicate() to check for any unapplied portion of the input predicate.
@@ -49,6 +58,8 @@ type OptimisticTransaction interface {
 	//   Using the readPredicates and resultant readFiles, TXN1 can see that none of its read files were changed by TXN2. Thus there are no logical conflicts and TXN1 can commit at table version N+1.
 	MarkFilesAsRead(readPredicate types.Expression) (Scan, error)
 
+	MarkFilesAsReadContext(ctx context.Context, readPredicate types.Expression) (Scan, error)
+
 	// Records an update to the metadata that should be committed with this transaction.
 	// IMPORTANT: It is the responsibility of the caller to ensure that files currently present in the table are still valid under the new meta
 	UpdateMetadata(metadata *action.Metadata) error
@@ -62,12 +73,33 @@ type OptimisticTransaction interface {
 
 	// returns the latest version that has committed for the idempotent transaction with given id.
 	TxnVersion(id string) (int64, error)
+
+	TxnVersionContext(ctx context.Context, id string) (int64, error)
+
+	// UpgradeProtocol enables the table features on the table and commits the new protocol as an UPGRADE_PROTOCOL operation.
+	// The features must be known and supported by delta-go, the protocol is never downgraded.
+	// If all the features are already enabled, nothing is committed and the read version is returned.
+	UpgradeProtocol(features []string, engineInfo string) (CommitResult, error)
+
+	UpgradeProtocolContext(ctx context.Context, features []string, engineInfo string) (CommitResult, error)
+
+	// RegisterPostCommitHook registers a hook which runs after the commit of this transaction, after the hooks of the log.
+	RegisterPostCommitHook(hook PostCommitHook)
+
+	// SetRetryPolicy sets the policy of the retries of the commit of this transaction, overriding Config.RetryPolicy.
+	SetRetryPolicy(policy RetryPolicy)
 }
 
 const DELTA_MAX_RETRY_COMMIT_ATTEMPTS = 10000000
 
 type CommitResult struct {
+	// Version is the committed version, i.e. the version won by the last attempt.
 	Version int64
+	// Attempts is the number of the commit attempts, 1 if no concurrent commit won the version attempted first.
+	// It is 0 if nothing is committed.
+	Attempts int
+	// PostCommitHookErrors are the errors of the post-commit hooks which failed, the commit succeeded anyway.
+	PostCommitHookErrors []error
 }
 
 type optimisticTransactionImp struct {
@@ -90,6 +122,10 @@ type optimisticTransactionImp struct {
 	lock           *sync.Mutex
 	logStore       store.Store
 	logPath        string
+
+	// postCommitHooks run after the built-in hooks
+	postCommitHooks []PostCommitHook
+	retryPolicy     RetryPolicy
 }
 
 func newOptimisticTransaction(snapshot *snapshotImp,
@@ -113,6 +149,7 @@ func newOptimisticTransaction(snapshot *snapshotImp,
 		newProtocol:            mo.None[*action.Protocol](),
 		isCreatingNewTable:     false,
 		commitAttemptStartTime: 0,
+		retryPolicy:            DefaultRetryPolicy(),
 
 		clock:          clock,
 		configurations: configuration,
@@ -126,11 +163,7 @@ func (trx *optimisticTransactionImp) protocol() (*action.Protocol, error) {
 	if trx.newProtocol.IsPresent() {
 		return trx.newProtocol.MustGet(), nil
 	}
-	t, err := trx.snapshot.protocolAndMetadata.Get()
-	if err != nil {
-		return nil, err
-	}
-	return t.V1, nil
+	return trx.snapshot.protocolAndMetadata.V1, nil
 }
 
 func (trx *optimisticTransactionImp) readVersion() int64 {
@@ -142,11 +175,23 @@ func (trx *optimisticTransactionImp) readVersion() int64 {
 // Note: any AddFile with an absolute path within the table path will be updated to have a relative path (based off of the table path).
 // Because of this, be sure to generate all RemoveFiles using AddFiles read from the Delta Log (do not use the actions.AddFiles created pre-commit.)
 func (trx *optimisticTransactionImp) Commit(actionsIter iter.Iter[action.Action], op *op.Operation, engineInfo string) (CommitResult, error) {
+	return trx.CommitContext(context.Background(), actionsIter, op, engineInfo)
+}
+
+// CommitContext is Commit with a context. The context is checked before every commit attempt,
+// if it is canceled after the commit file is written, the commit may have succeeded even though an error is returned.
+func (trx *optimisticTransactionImp) CommitContext(ctx context.Context, actionsIter iter.Iter[action.Action], op *op.Operation, engineInfo string) (CommitResult, error) {
+	res, err := trx.commit(ctx, actionsIter, op, engineInfo)
+	return res, errno.ContextError(ctx, err)
+}
+
+func (trx *optimisticTransactionImp) commit(ctx context.Context, actionsIter iter.Iter[action.Action], op *op.Operation, engineInfo string) (CommitResult, error) {
 	defer actionsIter.Close()
 
 	var actions []action.Action
 	var err error
-	for a, err := actionsIter.Next(); err == nil; a, err = actionsIter.Next() {
+	var a action.Action
+	for a, err = actionsIter.Next(); err == nil; a, err = actionsIter.Next() {
 		switch v := a.(type) {
 		case *action.Metadata:
 			trx.UpdateMetadata(v)
@@ -158,7 +203,7 @@ func (trx *optimisticTransactionImp) Commit(actionsIter iter.Iter[action.Action]
 		return CommitResult{}, err
 	}
 
-	preparedActions, err := trx.prepareCommit(actions)
+	preparedActions, err := trx.prepareCommit(ctx, actions)
 	if err != nil {
 		return CommitResult{}, eris.Wrap(err, "prepareCommit")
 	}
@@ -204,23 +249,70 @@ func (trx *optimisticTransactionImp) Commit(actionsIter iter.Iter[action.Action]
 		ReadVersion:         util.OptionalFilterToPtr(mo.Some(trx.readVersion()), func(v int64) bool { return v >= 0 }),
 		IsolationLevel:      util.OptionalToPtr(mo.Some(isolationLevelToUse.String())),
 		IsBlindAppend:       util.OptionalToPtr(mo.Some(isBlindAppend)),
-		OperationMetrics:    nil,
+		OperationMetrics:    operationMetrics(preparedActions, op),
 		UserMetadata:        util.OptionalToPtr(op.UserMetadata),
 		EngineInfo:          &engineInfo,
 	}
 
 	preparedActions = append([]action.Action{commitInfo}, preparedActions...)
 
-	commitVersion, err := trx.doCommitRetryIteratively(trx.snapshot.Version()+1, preparedActions, isolationLevelToUse)
+	commitVersion, attempts, err := trx.doCommitRetryIteratively(ctx, trx.snapshot.Version()+1, preparedActions, isolationLevelToUse)
 	if err != nil {
 		return CommitResult{}, err
 	}
 
-	if err := trx.postCommit(commitVersion); err != nil {
-		return CommitResult{}, err
+	hookErrors := trx.postCommit(ctx, commitVersion, preparedActions)
+
+	return CommitResult{Version: commitVersion, Attempts: attempts, PostCommitHookErrors: hookErrors}, nil
+}
+
+// operationMetrics returns the metrics of the commit in the string map format of Spark. The file metrics are computed
+// from the actions, the metrics given by the engine in the operation are added and override the computed ones.
+// The number of the output rows is only computed if all the added files have the statistics.
+func operationMetrics(actions []action.Action, operation *op.Operation) map[string]string {
+	var numAdded, numRemoved, addedBytes, removedBytes, numRows int64
+	hasNumRows := true
+	for _, a := range actions {
+		switch v := a.(type) {
+		case *action.AddFile:
+			numAdded++
+			addedBytes += v.Size
+			if stats, ok := addFileStats(v); ok && stats.NumRecords != nil {
+				numRows += *stats.NumRecords
+			} else {
+				hasNumRows = false
+			}
+		case *action.RemoveFile:
+			numRemoved++
+			if v.Size != nil {
+				removedBytes += *v.Size
+			}
+		}
 	}
 
-	return CommitResult{Version: commitVersion}, nil
+	metrics := make(map[string]int64)
+	if numAdded > 0 || numRemoved > 0 {
+		metrics[op.MetricNumFiles] = numAdded
+		metrics[op.MetricNumOutputBytes] = addedBytes
+		metrics[op.MetricNumAddedFiles] = numAdded
+		metrics[op.MetricNumRemovedFiles] = numRemoved
+		metrics[op.MetricNumRemovedBytes] = removedBytes
+		if numAdded > 0 && hasNumRows {
+			metrics[op.MetricNumOutputRows] = numRows
+		}
+	}
+	for k, v := range operation.Metrics {
+		metrics[k] = v
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(metrics))
+	for k, v := range metrics {
+		res[k] = strconv.FormatInt(v, 10)
+	}
+	return res
 }
 
 // Mark files matched by the readPredicate as read by this transaction.
@@ -233,29 +325,33 @@ func (trx *optimisticTransactionImp) Commit(actionsIter iter.Iter[action.Action]
 //   - TXN1 sees that another commit won, and needs to know whether to commit at version N+1 or fail.
 //     Using the readPredicates and resultant readFiles, TXN1 can see that none of its read files were changed by TXN2. Thus there are no logical conflicts and TXN1 can commit at table version N+1.
 func (trx *optimisticTransactionImp) MarkFilesAsRead(readPredicate types.Expression) (Scan, error) {
+	return trx.MarkFilesAsReadContext(context.Background(), readPredicate)
+}
+
+func (trx *optimisticTransactionImp) MarkFilesAsReadContext(ctx context.Context, readPredicate types.Expression) (Scan, error) {
 	scan, err := trx.snapshot.Scan(readPredicate)
 	if err != nil {
 		return nil, err
 	}
 
-	matchedFiles, err := scan.Files()
+	matchedFiles, err := scan.FilesContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, errno.ContextError(ctx, err)
 	}
+	defer matchedFiles.Close()
 
 	if scan.PushedPredicate() != nil {
 		trx.readPredicates = append(trx.readPredicates, scan.PushedPredicate())
 	}
 
-	for f, err := matchedFiles.Next(); err == nil; f, err = matchedFiles.Next() {
+	var f *action.AddFile
+	for f, err = matchedFiles.Next(); err == nil; f, err = matchedFiles.Next() {
 		trx.readFiles.Add(f)
 	}
 	if err != nil && err != io.EOF {
-		return nil, err
+		return nil, errno.ContextError(ctx, err)
 	}
 
-	matchedFiles.Close()
-
 	return scan, nil
 }
 
@@ -312,11 +408,7 @@ func (trx *optimisticTransactionImp) Metadata() (*action.Metadata, error) {
 	if trx.newMetadata.IsPresent() {
 		return trx.newMetadata.MustGet(), nil
 	}
-	t, err := trx.snapshot.protocolAndMetadata.Get()
-	if err != nil {
-		return nil, err
-	}
-	return t.V2, nil
+	return trx.snapshot.protocolAndMetadata.V2, nil
 }
 
 // Mark the entire table as tainted (i.e. read) by this transaction.
@@ -328,14 +420,69 @@ func (trx *optimisticTransactionImp) ReadWholeTable() error {
 
 // returns the latest version that has committed for the idempotent transaction with given id.
 func (trx *optimisticTransactionImp) TxnVersion(id string) (int64, error) {
+	return trx.TxnVersionContext(context.Background(), id)
+}
+
+func (trx *optimisticTransactionImp) TxnVersionContext(ctx context.Context, id string) (int64, error) {
 	trx.readTxn = append(trx.readTxn, id)
-	if v, ok := trx.snapshot.transactions()[id]; ok {
+	txns, err := trx.snapshot.transactions(ctx)
+	if err != nil {
+		return -1, errno.ContextError(ctx, err)
+	}
+	if v, ok := txns[id]; ok {
 		return v, nil
 	} else {
 		return -1, nil
 	}
 }
 
+// UpgradeProtocol enables the table features on the table and commits the new protocol as an UPGRADE_PROTOCOL operation.
+// The features must be known and supported by delta-go, the protocol is never downgraded.
+// If all the features are already enabled, nothing is committed and the read version is returned.
+func (trx *optimisticTransactionImp) UpgradeProtocol(features []string, engineInfo string) (CommitResult, error) {
+	return trx.UpgradeProtocolContext(context.Background(), features, engineInfo)
+}
+
+func (trx *optimisticTransactionImp) UpgradeProtocolContext(ctx context.Context, features []string, engineInfo string) (CommitResult, error) {
+	if trx.readVersion() == -1 {
+		return CommitResult{}, errno.IllegalStateError("cannot upgrade the protocol of a table which does not exist")
+	}
+
+	var toEnable []*action.TableFeature
+	for _, name := range features {
+		f, ok := action.GetTableFeature(name)
+		if !ok {
+			return CommitResult{}, errno.UnknownTableFeatureError(name)
+		}
+		if f.ReaderWriter && !f.ReadSupported {
+			return CommitResult{}, errno.UnsupportedReaderFeatureError(name)
+		}
+		if !f.WriteSupported {
+			return CommitResult{}, errno.UnsupportedWriterFeatureError(name)
+		}
+		toEnable = append(toEnable, f)
+	}
+
+	current, err := trx.protocol()
+	if err != nil {
+		return CommitResult{}, err
+	}
+	upgraded := current.WithFeatures(toEnable...)
+	if upgraded == current {
+		return CommitResult{Version: trx.readVersion()}, nil
+	}
+
+	newProtocol, err := json.Marshal(upgraded)
+	if err != nil {
+		return CommitResult{}, errno.JsonMarshalError(err)
+	}
+	trx.newProtocol = mo.Some(upgraded)
+	return trx.CommitContext(ctx, iter.FromSlice([]action.Action{}), &op.Operation{
+		Name:       op.UPGRADEPROTOCOL,
+		Parameters: map[string]any{"newProtocol": string(newProtocol)},
+	}, engineInfo)
+}
+
 func (trx *optimisticTransactionImp) withGlobalConfigDefaults(metadata *action.Metadata) *action.Metadata {
 
 	newMetadata := &action.Metadata{}
@@ -388,11 +535,12 @@ func (trx *optimisticTransactionImp) checkPartitionColumns(partitionCols []strin
 }
 
 func (trx *optimisticTransactionImp) verifySchemaCompatibility(
+	ctx context.Context,
 	existingSchema *types.StructType,
 	newSchema *types.StructType,
 	actions []action.Action) error {
 
-	numFiles, err := trx.snapshot.numOfFiles()
+	numFiles, err := trx.snapshot.numOfFiles(ctx)
 	if err != nil {
 		return err
 	}
@@ -407,7 +555,7 @@ func (trx *optimisticTransactionImp) verifySchemaCompatibility(
 		}
 	}
 
-	allFiles, err := trx.snapshot.AllFiles()
+	allFiles, err := trx.snapshot.AllFilesContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -425,7 +573,7 @@ func (trx *optimisticTransactionImp) verifySchemaCompatibility(
 	return nil
 }
 
-func (trx *optimisticTransactionImp) prepareCommit(actions []action.Action) ([]action.Action, error) {
+func (trx *optimisticTransactionImp) prepareCommit(ctx context.Context, actions []action.Action) ([]action.Action, error) {
 	if trx.committed {
 		return nil, errno.AssertionError("Trasaction already committed")
 	}
@@ -460,20 +608,27 @@ func (trx *optimisticTransactionImp) prepareCommit(actions []action.Action) ([]a
 			return nil, err
 		}
 
-		if err := trx.verifySchemaCompatibility(existingSchema, newSchema, actions); err != nil {
+		if err := trx.verifySchemaCompatibility(ctx, existingSchema, newSchema, actions); err != nil {
 			return nil, err
 		}
 
 		finalActions = append([]action.Action{trx.newMetadata.MustGet()}, finalActions...)
 	}
 
+	if trx.newProtocol.IsPresent() && !util.Exists(finalActions, func(a action.Action) bool {
+		_, ok := a.(*action.Protocol)
+		return ok
+	}) {
+		finalActions = append([]action.Action{trx.newProtocol.MustGet()}, finalActions...)
+	}
+
 	if trx.snapshot.version == -1 {
-		exist, err := trx.logStore.Exists(trx.logPath)
+		exist, err := trx.logStore.Exists(ctx, trx.logPath)
 		if err != nil {
 			return nil, err
 		}
 		if !exist {
-			if err := trx.logStore.Create(trx.logPath); err != nil {
+			if err := trx.logStore.Create(ctx, trx.logPath); err != nil {
 				return nil, err
 			}
 		}
@@ -497,14 +652,20 @@ func (trx *optimisticTransactionImp) prepareCommit(actions []action.Action) ([]a
 		}
 	}
 
-	protocolOpt := mo.None[*action.Protocol]()
 	for _, a := range finalActions {
 		if p, ok := a.(*action.Protocol); ok {
-			protocolOpt = mo.Some(p)
+			trx.newProtocol = mo.Some(p)
 		}
 	}
-	if protocolOpt.IsPresent() && !protocolOpt.MustGet().Equals(action.DefaultProtocol()) {
-		return nil, errno.AssertionError("Currently only Protocol readerVersion 1 and writerVersion 2 is supported.")
+	protocol, err := trx.protocol()
+	if err != nil {
+		return nil, err
+	}
+	if err := assertProtocolRead(protocol); err != nil {
+		return nil, err
+	}
+	if err := assertProtocolWrite(protocol); err != nil {
+		return nil, err
 	}
 
 	metadata, err := trx.Metadata()
@@ -512,7 +673,18 @@ func (trx *optimisticTransactionImp) prepareCommit(actions []action.Action) ([]a
 		return nil, err
 	}
 
-	partitionColumns := mapset.NewSet(metadata.PartitionColumns...)
+	// the partition values are keyed by the physical names in column mapping mode
+	physicalNames, err := columnMappingPhysicalNames(metadata)
+	if err != nil {
+		return nil, err
+	}
+	partitionColumns := mapset.NewSet[string]()
+	for _, c := range metadata.PartitionColumns {
+		if name, ok := physicalNames[c]; ok {
+			c = name
+		}
+		partitionColumns.Add(c)
+	}
 	for _, a := range finalActions {
 		switch v := a.(type) {
 		case *action.AddFile:
@@ -538,44 +710,63 @@ func (trx *optimisticTransactionImp) prepareCommit(actions []action.Action) ([]a
 	return finalActions, nil
 }
 
-func (trx *optimisticTransactionImp) doCommitRetryIteratively(attemptVersion int64, actions []action.Action, isolationLevel isolation.Level) (int64, error) {
-	trx.lock.Lock()
-	defer trx.lock.Unlock()
-
-	tryCommit := true
+// doCommitRetryIteratively commits the actions at attemptVersion, and retries at the next version after checking
+// the conflicts with the winning commits, following the retry policy of the transaction.
+// It returns the committed version and the number of the attempts.
+func (trx *optimisticTransactionImp) doCommitRetryIteratively(ctx context.Context, attemptVersion int64, actions []action.Action, isolationLevel isolation.Level) (int64, int, error) {
+	policy := trx.retryPolicy
+	start := trx.clock.NowInNano()
 	commitVersion := attemptVersion
-	attemptNumber := 0
-
-	for tryCommit {
-		var errCommit error
-		if attemptNumber == 0 {
-			_, errCommit = trx.doCommit(commitVersion, actions, isolationLevel)
-		} else if attemptVersion > DELTA_MAX_RETRY_COMMIT_ATTEMPTS {
-			return 0, errno.MaxCommitRetriesExceededError("max commit attempts exceeded")
-		} else {
-			commitVersion, err := trx.checkForConflicts(commitVersion, actions, attemptNumber, isolationLevel)
-			if err != nil {
-				return 0, err
+
+	for attemptNumber := 0; ; attemptNumber++ {
+		if attemptNumber > 0 {
+			if attemptNumber >= policy.maxAttempts() {
+				return 0, attemptNumber, errno.MaxCommitRetriesExceededError(
+					fmt.Sprintf("max commit attempts (%d) exceeded, the last attempted version is %d", policy.maxAttempts(), commitVersion))
+			}
+			backoff := policy.backoff(attemptNumber)
+			if policy.MaxElapsedTime > 0 && time.Duration(trx.clock.NowInNano()-start)+backoff > policy.MaxElapsedTime {
+				return 0, attemptNumber, errno.MaxCommitRetriesExceededError(
+					fmt.Sprintf("max commit retry time (%s) exceeded after %d attempts, the last attempted version is %d",
+						policy.MaxElapsedTime, attemptNumber, commitVersion))
+			}
+			if err := sleepContext(ctx, backoff); err != nil {
+				return 0, attemptNumber, err
 			}
-			_, errCommit = trx.doCommit(commitVersion, actions, isolationLevel)
 		}
-
-		if errCommit == nil {
-			tryCommit = false
-			break
+		if err := errno.CheckContext(ctx); err != nil {
+			return 0, attemptNumber, err
 		}
 
-		if eris.Is(errCommit, errno.ErrFileAlreadyExists) {
-			attemptNumber++
-		} else {
-			return 0, errCommit
+		var err error
+		commitVersion, err = trx.attemptCommit(ctx, commitVersion, actions, attemptNumber, isolationLevel)
+		if err == nil {
+			return commitVersion, attemptNumber + 1, nil
+		}
+		if !eris.Is(err, errno.ErrFileAlreadyExists) {
+			return 0, attemptNumber + 1, err
 		}
 	}
+}
 
-	return commitVersion, nil
+// attemptCommit writes the actions at commitVersion, or at the next version after the conflicts are checked for a retry.
+// It returns the attempted version. The lock is not held between the attempts, so the backoff does not block other writers.
+func (trx *optimisticTransactionImp) attemptCommit(ctx context.Context, commitVersion int64, actions []action.Action, attemptNumber int, isolationLevel isolation.Level) (int64, error) {
+	trx.lock.Lock()
+	defer trx.lock.Unlock()
+
+	if attemptNumber > 0 {
+		nextVersion, err := trx.checkForConflicts(ctx, commitVersion, actions, attemptNumber, isolationLevel)
+		if err != nil {
+			return commitVersion, err
+		}
+		commitVersion = nextVersion
+	}
+	_, err := trx.doCommit(ctx, commitVersion, actions, isolationLevel)
+	return commitVersion, err
 }
 
-func (trx *optimisticTransactionImp) doCommit(attemptVersion int64, actions []action.Action, isolationLevel isolation.Level) (int64, error) {
+func (trx *optimisticTransactionImp) doCommit(ctx context.Context, attemptVersion int64, actions []action.Action, isolationLevel isolation.Level) (int64, error) {
 	// we ignoore somoe logs here
 
 	actionStrings, err := action.UtilFnMapToString(actions)
@@ -583,13 +774,13 @@ func (trx *optimisticTransactionImp) doCommit(attemptVersion int64, actions []ac
 		return 0, err
 	}
 
-	if err := trx.logStore.Write(filenames.DeltaFile(trx.logStore.Root(), attemptVersion),
+	if err := trx.logStore.Write(ctx, filenames.DeltaFile(trx.logStore.Root(), attemptVersion),
 		iter.FromSlice(actionStrings), false); err != nil {
 		return 0, err
 	}
 
 	// note: we use updateInternal so we don't need to use the reentrant lock, but if some bugs happened, check this.
-	s, err := trx.snapshotManager.updateInternal()
+	s, err := trx.snapshotManager.updateInternal(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -600,8 +791,8 @@ func (trx *optimisticTransactionImp) doCommit(attemptVersion int64, actions []ac
 	return attemptVersion, nil
 }
 
-func (trx *optimisticTransactionImp) checkForConflicts(checkVersion int64, actions []action.Action, attemptNumber int, commitIsolationLevel isolation.Level) (int64, error) {
-	nextAttemptVersion, err := trx.getNextAttemptVersion()
+func (trx *optimisticTransactionImp) checkForConflicts(ctx context.Context, checkVersion int64, actions []action.Action, attemptNumber int, commitIsolationLevel isolation.Level) (int64, error) {
+	nextAttemptVersion, err := trx.getNextAttemptVersion(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -623,7 +814,7 @@ func (trx *optimisticTransactionImp) checkForConflicts(checkVersion int64, actio
 	}
 
 	for otherCommitVersion := checkVersion; otherCommitVersion < nextAttemptVersion; otherCommitVersion++ {
-		conflictChecker, err := newConflictChecker(currentTransactionInfo, otherCommitVersion, commitIsolationLevel)
+		conflictChecker, err := newConflictChecker(ctx, currentTransactionInfo, otherCommitVersion, commitIsolationLevel)
 		if err != nil {
 			return 0, err
 		}
@@ -635,39 +826,30 @@ func (trx *optimisticTransactionImp) checkForConflicts(checkVersion int64, actio
 	return nextAttemptVersion, nil
 }
 
-func (trx *optimisticTransactionImp) getNextAttemptVersion() (int64, error) {
+func (trx *optimisticTransactionImp) getNextAttemptVersion(ctx context.Context) (int64, error) {
 	// here we call updateInternal without locking
-	if s, err := trx.snapshotManager.updateInternal(); err != nil {
+	if s, err := trx.snapshotManager.updateInternal(ctx); err != nil {
 		return 0, err
 	} else {
 		return 1 + s.Version(), nil
 	}
 }
 
-func (trx *optimisticTransactionImp) postCommit(commitVersion int64) error {
-	trx.committed = true
+func (trx *optimisticTransactionImp) SetRetryPolicy(policy RetryPolicy) {
+	trx.retryPolicy = policy
+}
 
-	metadata, err := trx.snapshot.Metadata()
-	if err != nil {
-		return err
-	}
-	checkpointInterval := DeltaConfigCheckpointInterval.fromMetadata(metadata)
+// postCommit runs the built-in hooks, the hooks of the log and the hooks of the transaction,
+// and returns the errors of the failed hooks.
+func (trx *optimisticTransactionImp) postCommit(ctx context.Context, commitVersion int64, actions []action.Action) []error {
+	trx.committed = true
 
-	if trx.shouldCheckpoint(commitVersion, checkpointInterval) {
-		snaptshot, err := trx.snapshotManager.getSnapshotForVersionAsOf(commitVersion)
-		if err != nil {
-			return err
-		}
-		if err := checkpoint(trx.logPath, trx.logStore, snaptshot); err != nil {
-			if eris.Is(err, errno.ErrIllegalState) {
-				log.Println("Failed to checkpoint table state." + err.Error())
-			} else {
-				return err
-			}
-		}
-	}
+	commitInfo := actions[0].(*action.CommitInfo)
+	commitInfo.Version = &commitVersion
+	committed := &CommittedVersion{Version: commitVersion, Actions: actions[1:], CommitInfo: commitInfo}
 
-	return nil
+	hooks := append([]PostCommitHook{&checkpointHook{trx: trx}}, trx.postCommitHooks...)
+	return runPostCommitHooks(ctx, hooks, committed)
 }
 
 func (trx *optimisticTransactionImp) shouldCheckpoint(committedVersion int64, checkpointInterval int) bool {
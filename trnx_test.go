@@ -1,11 +1,13 @@
 package deltago
 
 import (
+	"context"
 	"testing"
 
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/errno"
 	"github.com/csimplestring/delta-go/internal/util"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/csimplestring/delta-go/op"
 	"github.com/csimplestring/delta-go/types"
@@ -727,3 +729,156 @@ func TestTrx_relative_path_is_unchanged(t *testing.T) {
 // 	}
 
 // }
+
+func TestTrx_upgrade_protocol(t *testing.T) {
+	f := newTrxTestFixture()
+
+	for _, tt := range newTestLogCases("file") {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.clean()
+
+			log, err := tt.getTempLog()
+			assert.NoError(t, err)
+			setUpTestTrxLog([]action.Action{f.metadata_colXY, action.DefaultProtocol()}, log, f, t)
+
+			trx, err := log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.UpgradeProtocol([]string{"unknownFeature"}, f.engineInfo)
+			assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+			_, err = trx.UpgradeProtocol([]string{action.FeatureGeneratedColumns}, f.engineInfo)
+			assert.ErrorIs(t, err, errno.ErrUnsupportedOperation)
+
+			// already enabled by the legacy protocol
+			res, err := trx.UpgradeProtocol([]string{action.FeatureAppendOnly}, f.engineInfo)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), res.Version)
+
+			res, err = trx.UpgradeProtocol([]string{action.FeatureDeletionVectors}, f.engineInfo)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), res.Version)
+
+			s, err := log.Update()
+			assert.NoError(t, err)
+			p, err := s.Protocol()
+			assert.NoError(t, err)
+			assert.Equal(t, &action.Protocol{
+				MinReaderVersion: 3,
+				MinWriterVersion: 7,
+				ReaderFeatures:   []string{action.FeatureDeletionVectors},
+				WriterFeatures:   []string{action.FeatureAppendOnly, action.FeatureInvariants, action.FeatureDeletionVectors},
+			}, p)
+
+			ci, err := log.CommitInfoAt(2)
+			assert.NoError(t, err)
+			assert.Equal(t, op.UPGRADEPROTOCOL.String(), ci.Operation)
+
+			// the table with the new protocol can still be written
+			trx, err = log.StartTransaction()
+			assert.NoError(t, err)
+			res, err = trx.Commit(iter.FromSlice([]action.Action{f.addA}), f.op, f.engineInfo)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), res.Version)
+		})
+	}
+}
+
+func TestTrx_commit_to_table_with_unsupported_writer_feature(t *testing.T) {
+	f := newTrxTestFixture()
+
+	for _, tt := range newTestLogCases("file") {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.clean()
+
+			log, err := tt.getTempLog()
+			assert.NoError(t, err)
+			setUpTestTrxLog([]action.Action{f.metadata_colXY}, log, f, t)
+
+			// the protocol can not be committed by delta-go, so it is written by another writer
+			p := &action.Protocol{MinReaderVersion: 1, MinWriterVersion: 7, WriterFeatures: []string{action.FeatureIdentityColumns}}
+			protocolJson, err := p.Json()
+			assert.NoError(t, err)
+			l := log.(*logImpl)
+			err = l.store.Write(context.Background(), filenames.DeltaFile(l.logPath, 1), iter.FromSlice([]string{protocolJson}), false)
+			assert.NoError(t, err)
+
+			s, err := log.Update()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), s.Version())
+
+			trx, err := log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.Commit(iter.FromSlice([]action.Action{f.addA}), f.op, f.engineInfo)
+			assert.ErrorIs(t, err, errno.ErrUnsupportedOperation)
+		})
+	}
+}
+
+func TestTrx_operation_metrics(t *testing.T) {
+	f := newTrxTestFixture()
+
+	for _, tt := range newTestLogCases("file") {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.clean()
+
+			log, err := tt.getTempLog()
+			assert.NoError(t, err)
+			setUpTestTrxLog([]action.Action{f.metadata_colXY}, log, f, t)
+
+			ci, err := log.CommitInfoAt(0)
+			assert.NoError(t, err)
+			assert.Nil(t, ci.OperationMetrics)
+
+			trx, err := log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.Commit(iter.FromSlice([]action.Action{
+				&action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 10, ModificationTime: 1, DataChange: true, Stats: `{"numRecords":3}`},
+				&action.AddFile{Path: "b", PartitionValues: map[string]string{}, Size: 20, ModificationTime: 1, DataChange: true, Stats: `{"numRecords":4}`},
+			}), &op.Operation{Name: op.WRITE, Metrics: map[string]int64{op.MetricExecutionTimeMs: 42}}, f.engineInfo)
+			assert.NoError(t, err)
+
+			ci, err = log.CommitInfoAt(1)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{
+				op.MetricNumFiles:        "2",
+				op.MetricNumOutputBytes:  "30",
+				op.MetricNumOutputRows:   "7",
+				op.MetricNumAddedFiles:   "2",
+				op.MetricNumRemovedFiles: "0",
+				op.MetricNumRemovedBytes: "0",
+				op.MetricExecutionTimeMs: "42",
+			}, ci.OperationMetrics)
+
+			// the rows are unknown without the stats, unless the engine provides them
+			trx, err = log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.Commit(iter.FromSlice([]action.Action{
+				&action.RemoveFile{Path: "a", DeletionTimestamp: util.PtrOf[int64](1), Size: util.PtrOf[int64](10), DataChange: true},
+				f.addB,
+			}), f.op, f.engineInfo)
+			assert.NoError(t, err)
+
+			ci, err = log.CommitInfoAt(2)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{
+				op.MetricNumFiles:        "1",
+				op.MetricNumOutputBytes:  "1",
+				op.MetricNumAddedFiles:   "1",
+				op.MetricNumRemovedFiles: "1",
+				op.MetricNumRemovedBytes: "10",
+			}, ci.OperationMetrics)
+
+			trx, err = log.StartTransaction()
+			assert.NoError(t, err)
+			_, err = trx.Commit(iter.FromSlice([]action.Action{f.addA}),
+				&op.Operation{Name: op.WRITE, Metrics: map[string]int64{op.MetricNumOutputRows: 5}}, f.engineInfo)
+			assert.NoError(t, err)
+
+			ci, err = log.CommitInfoAt(3)
+			assert.NoError(t, err)
+			assert.Equal(t, "5", ci.OperationMetrics[op.MetricNumOutputRows])
+		})
+	}
+}
@@ -29,7 +29,7 @@ func (u *unaryExp) String() string {
 }
 
 func (u *unaryExp) References() []string {
-	return nil
+	return u.Child.References()
 }
 
 // Children returns List of the immediate children of this node
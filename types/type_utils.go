@@ -132,6 +132,54 @@ func IsWriteCompatible(existingSchema *StructType, newSchema *StructType) bool {
 	return isStructWriteCompatible(existingSchema, newSchema)
 }
 
+func isDatatypeReadCompatible(readType DataType, newType DataType) bool {
+
+	if Is[*StructType](readType) && Is[*StructType](newType) {
+		return IsReadCompatible(readType.(*StructType), newType.(*StructType))
+	}
+	if Is[*ArrayType](readType) && Is[*ArrayType](newType) {
+		r := readType.(*ArrayType)
+		n := newType.(*ArrayType)
+		return (r.ContainsNull || !n.ContainsNull) && isDatatypeReadCompatible(r.ElementType, n.ElementType)
+	}
+	if Is[*MapType](readType) && Is[*MapType](newType) {
+		r := readType.(*MapType)
+		n := newType.(*MapType)
+		return (r.ValueContainsNull || !n.ValueContainsNull) &&
+			isDatatypeReadCompatible(r.KeyType, n.KeyType) &&
+			isDatatypeReadCompatible(r.ValueType, n.ValueType)
+	}
+	return readType.Name() == newType.Name()
+}
+
+// IsReadCompatible returns whether the data written with newSchema can be read with readSchema.
+// Our rules are to return false if the new schema:
+// - Drops any column that is present in the read schema
+// - Converts nullable=false to nullable=true for any column of the read schema
+// - Changes any datatype
+// - Adds a column which is not nullable
+func IsReadCompatible(readSchema *StructType, newSchema *StructType) bool {
+	read := toFieldMap(readSchema.GetFields())
+	fields := toFieldMap(newSchema.GetFields())
+	for _, readField := range readSchema.GetFields() {
+		v := fields.get(readField.Name)
+		if v.IsAbsent() {
+			return false
+		}
+		newField := v.MustGet()
+		if readField.Name != newField.Name || (!readField.Nullable && newField.Nullable) ||
+			!isDatatypeReadCompatible(readField.DataType, newField.DataType) {
+			return false
+		}
+	}
+	for _, newField := range newSchema.GetFields() {
+		if read.get(newField.Name).IsAbsent() && !newField.Nullable {
+			return false
+		}
+	}
+	return true
+}
+
 func toFieldMap(fields []*StructField) *caseInsensitiveMap {
 	// note:  we simply lower the key, but Scala version creates a dedicated map called 'CaseInsensitiveMap'
 	m := make(map[string]*StructField, len(fields))
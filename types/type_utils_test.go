@@ -144,6 +144,23 @@ func Test_isWriteCompatible(t *testing.T) {
 
 }
 
+func Test_isReadCompatible(t *testing.T) {
+	read := (&StructType{}).Add3("x", &IntegerType{}, true).Add3("s", (&StructType{}).Add3("a", &StringType{}, false), true)
+
+	// additive nullable columns, also in the nested structs
+	assert.True(t, IsReadCompatible(read, read))
+	assert.True(t, IsReadCompatible(read, (&StructType{}).Add3("x", &IntegerType{}, true).
+		Add3("s", (&StructType{}).Add3("a", &StringType{}, false).Add3("b", &StringType{}, true), true).
+		Add3("y", &IntegerType{}, true)))
+	assert.True(t, IsReadCompatible(read, (&StructType{}).Add3("x", &IntegerType{}, false).Add3("s", (&StructType{}).Add3("a", &StringType{}, false), true)))
+
+	assert.False(t, IsReadCompatible(read, (&StructType{}).Add3("x", &IntegerType{}, true)))
+	assert.False(t, IsReadCompatible(read, read.Add3("y", &IntegerType{}, false)))
+	assert.False(t, IsReadCompatible(read, (&StructType{}).Add3("x", &StringType{}, true).Add3("s", (&StructType{}).Add3("a", &StringType{}, false), true)))
+	assert.False(t, IsReadCompatible(read, (&StructType{}).Add3("x", &IntegerType{}, true).Add3("s", (&StructType{}).Add3("a", &StringType{}, true), true)))
+	assert.False(t, IsReadCompatible(read, (&StructType{}).Add3("X", &IntegerType{}, true).Add3("s", (&StructType{}).Add3("a", &StringType{}, false), true)))
+}
+
 func TestExplodeNestedFieldNames(t *testing.T) {
 	s := NewStructType([]*StructField{
 		NewStructField("a", &IntegerType{}, true),
@@ -0,0 +1,309 @@
+package deltago
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/path"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/op"
+	"github.com/csimplestring/delta-go/store"
+)
+
+// engineInfo is recorded in the commits made by the operations of this library, e.g. VACUUM.
+const engineInfo = "delta-go"
+
+// VacuumResult is the outcome of a Vacuum.
+type VacuumResult struct {
+	// DryRun is true if no file was deleted.
+	DryRun bool
+	// Files are the paths relative to the table root of the deleted files, or the files to delete in a dry run.
+	Files []string
+	// SizeInBytes is the total size of Files.
+	SizeInBytes int64
+}
+
+// Vacuum deletes the files in the table directory which are no longer referenced by the table
+// and are older than the retention. If retention is absent, the table's deletedFileRetentionDuration is used.
+// When dryRun is true nothing is deleted, the files which would be deleted are returned instead.
+// Otherwise the VACUUM START and VACUUM END commits are recorded around the deletion.
+// Empty directories are left as they are.
+func (l *logImpl) Vacuum(retention mo.Option[time.Duration], dryRun bool) (*VacuumResult, error) {
+	return l.VacuumContext(context.Background(), retention, dryRun)
+}
+
+// VacuumContext is Vacuum with a context to cancel the listing and the deletion of the files.
+// If it is canceled after VACUUM START is committed, VACUUM END may not be recorded.
+func (l *logImpl) VacuumContext(ctx context.Context, retention mo.Option[time.Duration], dryRun bool) (*VacuumResult, error) {
+	res, err := l.vacuum(ctx, retention, dryRun)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) vacuum(ctx context.Context, retention mo.Option[time.Duration], dryRun bool) (*VacuumResult, error) {
+	snapshot, err := l.snapshotReader.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Version() < 0 {
+		return nil, eris.Wrap(errno.ErrIllegalState, "cannot vacuum a table which does not exist: "+l.dataPath)
+	}
+
+	metadata, err := snapshot.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	configuredRetention := DeltaConfigTombstoneRetention.fromMetadata(metadata)
+	retentionToUse := retention.OrElse(configuredRetention)
+	if retentionToUse < 0 {
+		return nil, eris.Wrap(errno.ErrIllegalArgument, "retention for vacuum must not be negative")
+	}
+	retentionCheckEnabled := !l.config.DisableRetentionDurationCheck
+	if retentionCheckEnabled && retentionToUse < configuredRetention {
+		return nil, errno.VacuumRetentionTooShortError(retentionToUse, configuredRetention)
+	}
+
+	deleteBeforeTimestamp := l.clock.NowInMillis() - retentionToUse.Milliseconds()
+
+	dataStore, scheme, err := l.openDataStore()
+	if err != nil {
+		return nil, err
+	}
+
+	validFiles, err := l.validFiles(ctx, snapshot, scheme, deleteBeforeTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := listVacuumCandidates(ctx, dataStore, metadata.PartitionColumns, validFiles, deleteBeforeTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VacuumResult{DryRun: dryRun}
+	for _, f := range candidates {
+		result.Files = append(result.Files, f.Path())
+		result.SizeInBytes += int64(f.Size())
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	startParams := map[string]any{
+		"retentionCheckEnabled":  retentionCheckEnabled,
+		"defaultRetentionMillis": configuredRetention.Milliseconds(),
+	}
+	if retention.IsPresent() {
+		startParams["specifiedRetentionMillis"] = retention.MustGet().Milliseconds()
+	}
+	if err := l.commitVacuumOperation(ctx, op.VACUUMSTART, startParams); err != nil {
+		return nil, err
+	}
+
+	deleteErr := deleteVacuumCandidates(ctx, dataStore, result.Files)
+
+	status := "COMPLETED"
+	if deleteErr != nil {
+		status = "FAILED"
+	}
+	if err := l.commitVacuumOperation(ctx, op.VACUUMEND, map[string]any{"status": status}); err != nil {
+		if deleteErr != nil {
+			log.Println("Failed to record VACUUM END. " + err.Error())
+			return nil, deleteErr
+		}
+		return nil, err
+	}
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return result, nil
+}
+
+// openDataStore opens a store rooted at the table directory.
+func (l *logImpl) openDataStore() (store.Store, string, error) {
+	dataPath := strings.TrimSuffix(l.dataPath, "/") + "/"
+	u, err := url.Parse(dataPath)
+	if err != nil {
+		return nil, "", eris.Wrap(err, dataPath)
+	}
+	// the lakeFS store appends _delta_log to any path it is opened at and prefixes its bucket with it,
+	// so it cannot be rooted at the table directory to list or check the data files
+	if u.Scheme == "lakefs" {
+		return nil, "", errno.UnsupportedFileSystem("accessing the data files is not supported for " + dataPath)
+	}
+	s, err := store.New(dataPath, l.mux)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, u.Scheme, nil
+}
+
+// validFiles returns the paths relative to the table root of the files which must be kept:
+// the files of the snapshot and the files removed after deleteBeforeTimestamp, with their deletion vector files.
+func (l *logImpl) validFiles(ctx context.Context, snapshot *snapshotImp, scheme string, deleteBeforeTimestamp int64) (mapset.Set[string], error) {
+	tableRoot, err := path.Canonicalize(strings.TrimSuffix(l.dataPath, "/")+"/", scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	// the files of the deletion vectors on disk are in the table directory too
+	addDeletionVector := func(dv *action.DeletionVectorDescriptor) error {
+		if dv == nil || !dv.IsOnDisk() {
+			return nil
+		}
+		p, err := dv.AbsolutePath(tableRoot)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	}
+	allFiles, err := snapshot.AllFilesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range allFiles {
+		paths = append(paths, f.Path)
+		if err := addDeletionVector(f.DeletionVector); err != nil {
+			return nil, err
+		}
+	}
+	tombstones, err := snapshot.tombstones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range tombstones {
+		if f.DelTimestamp() > deleteBeforeTimestamp {
+			paths = append(paths, f.Path)
+			if err := addDeletionVector(f.DeletionVector); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	valid := mapset.NewThreadUnsafeSet[string]()
+	for _, p := range paths {
+		rel, ok, err := relativizeToTable(tableRoot, scheme, p)
+		if err != nil {
+			return nil, err
+		}
+		// files outside the table directory are never listed, hence never deleted
+		if ok {
+			valid.Add(rel)
+		}
+	}
+	return valid, nil
+}
+
+// relativizeToTable converts the path of a file action to the form of the keys listed in the table directory.
+// It returns false if the file is not in the table directory.
+func relativizeToTable(tableRoot string, scheme string, p string) (string, bool, error) {
+	p, err := path.Canonicalize(p, scheme)
+	if err != nil {
+		return "", false, err
+	}
+	if strings.Contains(p, "://") {
+		if !strings.HasPrefix(p, tableRoot) {
+			return "", false, nil
+		}
+		p = strings.TrimPrefix(p, tableRoot)
+	}
+	// paths in the log are url encoded
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return "", false, eris.Wrap(errno.ErrIllegalState, "invalid file path in the log: "+p)
+	}
+	return decoded, true, nil
+}
+
+// listVacuumCandidates lists the table directory recursively and returns the files not in validFiles and
+// last modified before deleteBeforeTimestamp, sorted by path.
+func listVacuumCandidates(ctx context.Context, dataStore store.Store, partitionColumns []string, validFiles mapset.Set[string],
+	deleteBeforeTimestamp int64) ([]*store.FileMeta, error) {
+
+	it, err := dataStore.ListFrom(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var (
+		candidates []*store.FileMeta
+		f          *store.FileMeta
+	)
+	for f, err = it.Next(); err == nil; f, err = it.Next() {
+		if isHiddenPath(partitionColumns, f.Path()) || validFiles.Contains(f.Path()) {
+			continue
+		}
+		if f.TimeModified().UnixMilli() < deleteBeforeTimestamp {
+			candidates = append(candidates, f)
+		}
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Path() < candidates[j].Path()
+	})
+	return candidates, nil
+}
+
+// isHiddenPath checks if any part of the path is hidden, i.e. starts with '.' or '_'.
+// Partition directories like '_col=value', change data and delta index directories are not hidden.
+func isHiddenPath(partitionColumns []string, p string) bool {
+	for _, name := range strings.Split(p, "/") {
+		if isHiddenName(partitionColumns, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHiddenName(partitionColumns []string, name string) bool {
+	if !strings.HasPrefix(name, ".") && !strings.HasPrefix(name, "_") {
+		return false
+	}
+	if strings.HasPrefix(name, "_delta_index") || strings.HasPrefix(name, "_change_data") {
+		return false
+	}
+	for _, c := range partitionColumns {
+		if strings.HasPrefix(name, c+"=") {
+			return false
+		}
+	}
+	return true
+}
+
+func deleteVacuumCandidates(ctx context.Context, dataStore store.Store, files []string) error {
+	for _, f := range files {
+		if err := dataStore.Delete(ctx, f); err != nil && !eris.Is(err, errno.ErrFileNotFound) {
+			return eris.Wrap(err, "deleting file "+f)
+		}
+	}
+	return nil
+}
+
+func (l *logImpl) commitVacuumOperation(ctx context.Context, name op.Name, params map[string]any) error {
+	trx, err := l.StartTransactionContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = trx.CommitContext(ctx, iter.FromSlice[action.Action](nil), &op.Operation{Name: name, Parameters: params}, engineInfo)
+	return err
+}
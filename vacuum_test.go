@@ -0,0 +1,193 @@
+package deltago
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/z85"
+	"github.com/csimplestring/delta-go/iter"
+	"github.com/csimplestring/delta-go/op"
+)
+
+type vacuumTestFixture struct {
+	tt       *testLogCase
+	log      Log
+	tableDir string
+}
+
+func newVacuumTestFixture(t *testing.T, config Config) *vacuumTestFixture {
+	tt := newTestLogCases("file")[0]
+	tt.config = config
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(tt.urlstr)
+	assert.NoError(t, err)
+
+	return &vacuumTestFixture{
+		tt:       tt,
+		log:      log,
+		tableDir: u.Path + "/" + tt.tempDir,
+	}
+}
+
+// createFile creates a data file in the table directory, last modified age ago.
+func (f *vacuumTestFixture) createFile(t *testing.T, name string, age time.Duration) {
+	p := filepath.Join(f.tableDir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	assert.NoError(t, os.WriteFile(p, []byte("data"), 0644))
+	ts := time.Now().Add(-age)
+	assert.NoError(t, os.Chtimes(p, ts, ts))
+}
+
+func (f *vacuumTestFixture) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(f.tableDir, name))
+	return err == nil
+}
+
+func (f *vacuumTestFixture) commit(t *testing.T, actions ...action.Action) {
+	trx, err := f.log.StartTransaction()
+	assert.NoError(t, err)
+	_, err = trx.Commit(iter.FromSlice(actions), getTestManualUpdate(), getTestEngineInfo())
+	assert.NoError(t, err)
+}
+
+// setUp creates a table with the active file a, the file b removed long ago, the file c removed recently,
+// the untracked file d and the hidden files.
+func (f *vacuumTestFixture) setUp(t *testing.T) {
+	f.commit(t, getTestMetedata(),
+		&action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true},
+		&action.AddFile{Path: "b", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true},
+		&action.AddFile{Path: "c", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true},
+	)
+	old := time.Now().Add(-30 * 24 * time.Hour).UnixMilli()
+	recent := time.Now().Add(-1 * time.Hour).UnixMilli()
+	f.commit(t,
+		&action.RemoveFile{Path: "b", DeletionTimestamp: &old, DataChange: true},
+		&action.RemoveFile{Path: "c", DeletionTimestamp: &recent, DataChange: true},
+	)
+
+	for _, name := range []string{"a", "b", "c", "dir/d", "_hidden/e", ".f"} {
+		f.createFile(t, name, 30*24*time.Hour)
+	}
+	f.createFile(t, "g", time.Minute)
+}
+
+func TestVacuum_dry_run(t *testing.T) {
+	f := newVacuumTestFixture(t, getTestFileConfig())
+	defer f.tt.clean()
+	f.setUp(t)
+
+	res, err := f.log.Vacuum(mo.None[time.Duration](), true)
+	assert.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, []string{"b", "dir/d"}, res.Files)
+	assert.Equal(t, int64(8), res.SizeInBytes)
+
+	assert.True(t, f.exists("b"))
+	assert.True(t, f.exists("dir/d"))
+
+	s, err := f.log.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), s.Version())
+}
+
+func TestVacuum_delete_unreferenced_files(t *testing.T) {
+	f := newVacuumTestFixture(t, getTestFileConfig())
+	defer f.tt.clean()
+	f.setUp(t)
+
+	res, err := f.log.Vacuum(mo.None[time.Duration](), false)
+	assert.NoError(t, err)
+	assert.False(t, res.DryRun)
+	assert.Equal(t, []string{"b", "dir/d"}, res.Files)
+
+	for _, name := range []string{"b", "dir/d"} {
+		assert.False(t, f.exists(name))
+	}
+	for _, name := range []string{"a", "c", "g", "_hidden/e", ".f", "_delta_log/00000000000000000000.json"} {
+		assert.True(t, f.exists(name))
+	}
+
+	start, err := f.log.CommitInfoAt(2)
+	assert.NoError(t, err)
+	assert.Equal(t, op.VACUUMSTART.String(), start.Operation)
+	assert.Equal(t, true, start.OperationParameters["retentionCheckEnabled"])
+
+	end, err := f.log.CommitInfoAt(3)
+	assert.NoError(t, err)
+	assert.Equal(t, op.VACUUMEND.String(), end.Operation)
+	assert.Equal(t, "COMPLETED", end.OperationParameters["status"])
+}
+
+func TestVacuum_retention_check(t *testing.T) {
+	f := newVacuumTestFixture(t, getTestFileConfig())
+	defer f.tt.clean()
+	f.setUp(t)
+
+	_, err := f.log.Vacuum(mo.Some(time.Duration(0)), true)
+	assert.ErrorIs(t, err, errno.ErrIllegalArgument)
+}
+
+func TestVacuum_retention_check_disabled(t *testing.T) {
+	f := newVacuumTestFixture(t, Config{StoreType: "file", DisableRetentionDurationCheck: true})
+	defer f.tt.clean()
+	f.setUp(t)
+
+	res, err := f.log.Vacuum(mo.Some(time.Duration(0)), true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "dir/d", "g"}, res.Files)
+}
+
+func TestVacuum_keep_deletion_vector_files(t *testing.T) {
+	f := newVacuumTestFixture(t, getTestFileConfig())
+	defer f.tt.clean()
+	f.setUp(t)
+
+	// dvFile returns a deletion vector stored in a file of the table directory, with the path of the file
+	dvFile := func(prefix string, id byte) (*action.DeletionVectorDescriptor, string) {
+		uuid := make([]byte, 16)
+		uuid[15] = id
+		offset := int32(1)
+		dv := &action.DeletionVectorDescriptor{
+			StorageType:    action.DeletionVectorStorageUUIDRelative,
+			PathOrInlineDv: prefix + z85.Encode(uuid),
+			Offset:         &offset,
+			SizeInBytes:    10,
+			Cardinality:    1,
+		}
+		p, err := dv.AbsolutePath("")
+		assert.NoError(t, err)
+		return dv, strings.TrimPrefix(p, "/")
+	}
+	active, activePath := dvFile("", 1)
+	removed, removedPath := dvFile("ab", 2)
+	_, orphanPath := dvFile("", 3)
+
+	recent := time.Now().Add(-1 * time.Hour).UnixMilli()
+	f.commit(t,
+		&action.RemoveFile{Path: "a", DeletionTimestamp: &recent, DataChange: true},
+		&action.AddFile{Path: "a", PartitionValues: map[string]string{}, Size: 1, ModificationTime: 1, DataChange: true, DeletionVector: active},
+		&action.RemoveFile{Path: "c", DeletionTimestamp: &recent, DataChange: true, DeletionVector: removed},
+	)
+	for _, name := range []string{activePath, removedPath, orphanPath} {
+		f.createFile(t, name, 30*24*time.Hour)
+	}
+
+	res, err := f.log.Vacuum(mo.None[time.Duration](), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", orphanPath, "dir/d"}, res.Files)
+	assert.True(t, f.exists(activePath))
+	assert.True(t, f.exists(removedPath))
+	assert.False(t, f.exists(orphanPath))
+}
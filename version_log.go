@@ -1,6 +1,7 @@
 package deltago
 
 import (
+	"context"
 	"github.com/csimplestring/delta-go/action"
 	"github.com/csimplestring/delta-go/iter"
 	"github.com/csimplestring/delta-go/store"
@@ -33,6 +34,8 @@ func (v *InMemVersionLog) ActionIter() (iter.Iter[action.Action], error) {
 }
 
 type MemOptimizedVersionLog struct {
+	// ctx is the context of the Changes call which created the VersionLog, it is used to read the file
+	ctx     context.Context
 	version int64
 	path    string
 	store   store.Store
@@ -43,7 +46,7 @@ func (m *MemOptimizedVersionLog) Version() int64 {
 }
 
 func (m *MemOptimizedVersionLog) Actions() ([]action.Action, error) {
-	i, err := m.store.Read(m.path)
+	i, err := m.store.Read(m.ctx, m.path)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +58,7 @@ func (m *MemOptimizedVersionLog) Actions() ([]action.Action, error) {
 }
 
 func (m *MemOptimizedVersionLog) ActionIter() (iter.Iter[action.Action], error) {
-	i, err := m.store.Read(m.path)
+	i, err := m.store.Read(m.ctx, m.path)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +74,8 @@ func (m *MemOptimizedVersionLog) ActionIter() (iter.Iter[action.Action], error)
 }
 
 type MemOptimizedCheckpoint struct {
+	// ctx is the context of the Changes call which created the VersionLog, it is used to read the file
+	ctx     context.Context
 	version int64
 	path    string
 	store   store.Store
@@ -82,8 +87,7 @@ func (m *MemOptimizedCheckpoint) Version() int64 {
 }
 
 func (m *MemOptimizedCheckpoint) Actions() ([]action.Action, error) {
-	cr := *(m.cr)
-	i, err := cr.Read(m.path)
+	i, err := readCheckpointFile(m.ctx, m.store, *m.cr, m.path)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +111,5 @@ func (m *MemOptimizedCheckpoint) Actions() ([]action.Action, error) {
 }
 
 func (m *MemOptimizedCheckpoint) ActionIter() (iter.Iter[action.Action], error) {
-	cr := *(m.cr)
-	return cr.Read(m.path)
+	return readCheckpointFile(m.ctx, m.store, *m.cr, m.path)
 }
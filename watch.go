@@ -0,0 +1,213 @@
+package deltago
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+	"github.com/csimplestring/delta-go/iter"
+)
+
+const (
+	defaultWatchPollInterval    = time.Second
+	defaultWatchMaxPollInterval = 30 * time.Second
+)
+
+// WatchOptions are the options of Watch.
+type WatchOptions struct {
+	// StartVersion is the first version to deliver, by default the version after the latest one.
+	StartVersion mo.Option[int64]
+	// PollInterval is the interval to list the log after a new version is found, 1 second if it is not positive.
+	PollInterval time.Duration
+	// MaxPollInterval is the maximum interval to list the log, the interval is doubled from PollInterval
+	// up to it while no new version is found. It is 30 seconds if it is not positive.
+	MaxPollInterval time.Duration
+	// FileNotifications lists the log as soon as the file system notifies a change in the log directory,
+	// in addition to the polling. It is only supported for file:// tables on Linux, otherwise the log is only polled.
+	FileNotifications bool
+}
+
+// WatchEvent is a new version of the table, or the error which stops Watch.
+type WatchEvent struct {
+	// VersionLog are the actions of the new version, nil if Err is set.
+	VersionLog VersionLog
+	Err        error
+}
+
+// Watch delivers the new versions of the table in order and without gaps, from the start version.
+// The log is listed from the next version to deliver only. The returned channel is closed when the context is done,
+// or after an event with an error, e.g. if the delta file of the next version is deleted before it is delivered.
+// The next version is listed only after the previous events are received.
+func (l *logImpl) Watch(ctx context.Context, opts WatchOptions) (<-chan WatchEvent, error) {
+	res, err := l.watch(ctx, opts)
+	if err != nil {
+		return nil, errno.ContextError(ctx, err)
+	}
+	return res, nil
+}
+
+func (l *logImpl) watch(ctx context.Context, opts WatchOptions) (<-chan WatchEvent, error) {
+	next, ok := opts.StartVersion.Get()
+	if !ok {
+		s, err := l.snapshotReader.update(ctx)
+		if err != nil {
+			return nil, err
+		}
+		next = s.Version() + 1
+	}
+	if next < 0 {
+		return nil, eris.Wrap(errno.ErrIllegalArgument, "invalid StartVersion")
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultWatchPollInterval
+	}
+	if opts.MaxPollInterval <= 0 {
+		opts.MaxPollInterval = defaultWatchMaxPollInterval
+	}
+	if opts.MaxPollInterval < opts.PollInterval {
+		opts.MaxPollInterval = opts.PollInterval
+	}
+
+	var notifier logNotifier
+	if opts.FileNotifications {
+		var err error
+		notifier, err = l.newLogNotifier()
+		if err != nil {
+			log.Println("File notifications are not used in the watch of " + l.dataPath + ". " + err.Error())
+		}
+	}
+
+	events := make(chan WatchEvent)
+	go l.runWatch(ctx, next, opts, notifier, events)
+	return events, nil
+}
+
+func (l *logImpl) runWatch(ctx context.Context, next int64, opts WatchOptions, notifier logNotifier, events chan<- WatchEvent) {
+	defer close(events)
+
+	var notifications <-chan struct{}
+	if notifier != nil {
+		notifications = notifier.Events()
+		defer notifier.Close()
+	}
+
+	interval := opts.PollInterval
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-notifications:
+			timer.Stop()
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		delivered, err := l.deliverNewVersions(ctx, next, events)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			select {
+			case events <- WatchEvent{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		next += delivered
+		if delivered > 0 {
+			interval = opts.PollInterval
+		} else if interval *= 2; interval > opts.MaxPollInterval {
+			interval = opts.MaxPollInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// deliverNewVersions lists the delta files from the version next and delivers them in order,
+// it returns the number of the delivered versions.
+func (l *logImpl) deliverNewVersions(ctx context.Context, next int64, events chan<- WatchEvent) (int64, error) {
+	files, err := l.store.ListFrom(ctx, filenames.DeltaFile(l.store.Root(), next))
+	if err != nil {
+		// the log directory of a new table may not be created yet
+		if eris.Is(err, errno.ErrFileNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer files.Close()
+
+	var delivered int64
+	for f, err := files.Next(); err != io.EOF; f, err = files.Next() {
+		if err != nil {
+			return delivered, err
+		}
+		if !filenames.IsDeltaFile(f.Path()) {
+			continue
+		}
+		version := filenames.DeltaVersion(f.Path())
+		if version < next+delivered {
+			continue
+		}
+		if version > next+delivered {
+			return delivered, errno.WatchDataLossError(next + delivered)
+		}
+
+		actions, err := l.readDeltaFile(ctx, version)
+		if err != nil {
+			if eris.Is(err, errno.ErrFileNotFound) {
+				return delivered, errno.WatchDataLossError(version)
+			}
+			return delivered, err
+		}
+		select {
+		case events <- WatchEvent{VersionLog: &InMemVersionLog{version: version, actions: actions}}:
+		case <-ctx.Done():
+			return delivered, ctx.Err()
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+func (l *logImpl) readDeltaFile(ctx context.Context, version int64) ([]action.Action, error) {
+	lines, err := l.store.Read(ctx, filenames.DeltaFile(l.store.Root(), version))
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	return iter.Map(lines, action.FromJson)
+}
+
+// logNotifier notifies the changes in the log directory.
+type logNotifier interface {
+	// Events receives a value when the log directory changes, the changes not received yet are coalesced.
+	Events() <-chan struct{}
+	Close() error
+}
+
+func (l *logImpl) newLogNotifier() (logNotifier, error) {
+	u, err := url.Parse(l.logPath)
+	if err != nil {
+		return nil, eris.Wrap(err, l.logPath)
+	}
+	if u.Scheme != "file" {
+		return nil, errno.UnsupportedFileSystem("file notifications are not supported for " + l.logPath)
+	}
+	return newDirNotifier(u.Path)
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package deltago
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/rotisserie/eris"
+)
+
+// inotifyNotifier notifies the files created in a directory by inotify.
+type inotifyNotifier struct {
+	f      *os.File
+	events chan struct{}
+}
+
+func newDirNotifier(dir string) (logNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, eris.Wrap(err, "initializing inotify")
+	}
+	// the delta files are linked from temp files, or created and written if link(2) is not supported
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE|syscall.IN_MOVED_TO|syscall.IN_CLOSE_WRITE); err != nil {
+		syscall.Close(fd)
+		return nil, eris.Wrap(err, "watching "+dir)
+	}
+
+	// the non-blocking file is read by the runtime poller, so that Close unblocks the read
+	n := &inotifyNotifier{f: os.NewFile(uintptr(fd), dir), events: make(chan struct{}, 1)}
+	go n.run()
+	return n, nil
+}
+
+func (n *inotifyNotifier) run() {
+	buf := make([]byte, 4096)
+	for {
+		if _, err := n.f.Read(buf); err != nil {
+			return
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *inotifyNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+func (n *inotifyNotifier) Close() error {
+	return n.f.Close()
+}
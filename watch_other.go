@@ -0,0 +1,13 @@
+//go:build !linux
+
+package deltago
+
+import (
+	"runtime"
+
+	"github.com/csimplestring/delta-go/errno"
+)
+
+func newDirNotifier(dir string) (logNotifier, error) {
+	return nil, errno.UnsupportedFileSystem("file notifications are not supported on " + runtime.GOOS)
+}
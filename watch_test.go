@@ -0,0 +1,102 @@
+package deltago
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/csimplestring/delta-go/action"
+	"github.com/csimplestring/delta-go/errno"
+	"github.com/csimplestring/delta-go/internal/util/filenames"
+)
+
+func TestLog_watch(t *testing.T) {
+	tt := newTestLogCases("file")[0]
+	defer tt.clean()
+
+	log, err := tt.getTempLog()
+	assert.NoError(t, err)
+
+	receive := func(events <-chan WatchEvent) WatchEvent {
+		select {
+		case e, ok := <-events:
+			assert.True(t, ok)
+			return e
+		case <-time.After(10 * time.Second):
+			t.Fatal("no event is received")
+			return WatchEvent{}
+		}
+	}
+	addedPath := func(e WatchEvent) string {
+		assert.NoError(t, e.Err)
+		actions, err := e.VersionLog.Actions()
+		assert.NoError(t, err)
+		for _, a := range actions {
+			if f, ok := a.(*action.AddFile); ok {
+				return f.Path
+			}
+		}
+		return ""
+	}
+
+	commitTestActions(t, log, getTestMetedata(), testAddFile("a"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := log.Watch(ctx, WatchOptions{PollInterval: 10 * time.Millisecond, MaxPollInterval: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	commitTestActions(t, log, testAddFile("b"))
+	commitTestActions(t, log, testAddFile("c"))
+	e := receive(events)
+	assert.Equal(t, int64(1), e.VersionLog.Version())
+	assert.Equal(t, "b", addedPath(e))
+	e = receive(events)
+	assert.Equal(t, int64(2), e.VersionLog.Version())
+	assert.Equal(t, "c", addedPath(e))
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+
+	// all the versions from the start version are delivered
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = log.Watch(ctx, WatchOptions{StartVersion: mo.Some[int64](0), PollInterval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	for v := int64(0); v <= 2; v++ {
+		assert.Equal(t, v, receive(events).VersionLog.Version())
+	}
+
+	// the file notifications deliver the new versions before the next poll
+	if runtime.GOOS == "linux" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, err := log.Watch(ctx, WatchOptions{PollInterval: time.Hour, FileNotifications: true})
+		assert.NoError(t, err)
+		// the first poll happens immediately
+		time.Sleep(100 * time.Millisecond)
+		commitTestActions(t, log, testAddFile("d"))
+		e := receive(events)
+		assert.Equal(t, int64(3), e.VersionLog.Version())
+		assert.Equal(t, "d", addedPath(e))
+	}
+
+	// the deleted versions are reported as data loss
+	logDir := strings.TrimPrefix(log.(*logImpl).logPath, "file://")
+	assert.NoError(t, os.Remove(filepath.Join(logDir, filenames.DeltaFile("", 0))))
+	events, err = log.Watch(ctx, WatchOptions{StartVersion: mo.Some[int64](0), PollInterval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	e = receive(events)
+	assert.True(t, eris.Is(e.Err, errno.ErrIllegalState))
+	assert.Nil(t, e.VersionLog)
+	_, ok = <-events
+	assert.False(t, ok)
+}